@@ -8,23 +8,26 @@ import (
 	"os"
 
 	"path/filepath"
-	"regexp"
 
 	"spotiflac/backend"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
-var isrcRegex = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}\d{2}\d{5}$`)
-
 func isValidISRC(isrc string) bool {
-	return isrcRegex.MatchString(isrc)
+	return backend.IsValidISRC(isrc)
 }
 
 type App struct {
 	ctx context.Context
+
+	availabilityMu     sync.Mutex
+	availabilityCancel context.CancelFunc
+	availabilityRun    int
 }
 
 func NewApp() *App {
@@ -37,6 +40,11 @@ func (a *App) startup(ctx context.Context) {
 	if err := backend.InitHistoryDB("SpotiFLAC"); err != nil {
 		fmt.Printf("Failed to init history DB: %v\n", err)
 	}
+
+	if settings, err := a.LoadSettings(); err == nil && settings != nil {
+		applySanitizeSettings(settings)
+		applyLyricsSettings(settings)
+	}
 }
 
 func (a *App) shutdown(ctx context.Context) {
@@ -51,37 +59,43 @@ type SpotifyMetadataRequest struct {
 }
 
 type DownloadRequest struct {
-	ISRC                 string `json:"isrc"`
-	Service              string `json:"service"`
-	Query                string `json:"query,omitempty"`
-	TrackName            string `json:"track_name,omitempty"`
-	ArtistName           string `json:"artist_name,omitempty"`
-	AlbumName            string `json:"album_name,omitempty"`
-	AlbumArtist          string `json:"album_artist,omitempty"`
-	ReleaseDate          string `json:"release_date,omitempty"`
-	CoverURL             string `json:"cover_url,omitempty"`
-	ApiURL               string `json:"api_url,omitempty"`
-	OutputDir            string `json:"output_dir,omitempty"`
-	AudioFormat          string `json:"audio_format,omitempty"`
-	FilenameFormat       string `json:"filename_format,omitempty"`
-	TrackNumber          bool   `json:"track_number,omitempty"`
-	Position             int    `json:"position,omitempty"`
-	UseAlbumTrackNumber  bool   `json:"use_album_track_number,omitempty"`
-	SpotifyID            string `json:"spotify_id,omitempty"`
-	EmbedLyrics          bool   `json:"embed_lyrics,omitempty"`
-	EmbedMaxQualityCover bool   `json:"embed_max_quality_cover,omitempty"`
-	ServiceURL           string `json:"service_url,omitempty"`
-	Duration             int    `json:"duration,omitempty"`
-	ItemID               string `json:"item_id,omitempty"`
-	SpotifyTrackNumber   int    `json:"spotify_track_number,omitempty"`
-	SpotifyDiscNumber    int    `json:"spotify_disc_number,omitempty"`
-	SpotifyTotalTracks   int    `json:"spotify_total_tracks,omitempty"`
-	SpotifyTotalDiscs    int    `json:"spotify_total_discs,omitempty"`
-	Copyright            string `json:"copyright,omitempty"`
-	Publisher            string `json:"publisher,omitempty"`
-	PlaylistName         string `json:"playlist_name,omitempty"`
-	PlaylistOwner        string `json:"playlist_owner,omitempty"`
-	AllowFallback        bool   `json:"allow_fallback"`
+	ISRC                 string   `json:"isrc"`
+	Service              string   `json:"service"`
+	Query                string   `json:"query,omitempty"`
+	TrackName            string   `json:"track_name,omitempty"`
+	ArtistName           string   `json:"artist_name,omitempty"`
+	AlbumName            string   `json:"album_name,omitempty"`
+	AlbumArtist          string   `json:"album_artist,omitempty"`
+	ReleaseDate          string   `json:"release_date,omitempty"`
+	CoverURL             string   `json:"cover_url,omitempty"`
+	ApiURL               string   `json:"api_url,omitempty"`
+	OutputDir            string   `json:"output_dir,omitempty"`
+	AudioFormat          string   `json:"audio_format,omitempty"`
+	FilenameFormat       string   `json:"filename_format,omitempty"`
+	TrackNumber          bool     `json:"track_number,omitempty"`
+	Position             int      `json:"position,omitempty"`
+	UseAlbumTrackNumber  bool     `json:"use_album_track_number,omitempty"`
+	SpotifyID            string   `json:"spotify_id,omitempty"`
+	EmbedLyrics          bool     `json:"embed_lyrics,omitempty"`
+	EmbedMaxQualityCover bool     `json:"embed_max_quality_cover,omitempty"`
+	ServiceURL           string   `json:"service_url,omitempty"`
+	Duration             int      `json:"duration,omitempty"`
+	ItemID               string   `json:"item_id,omitempty"`
+	SpotifyTrackNumber   int      `json:"spotify_track_number,omitempty"`
+	SpotifyDiscNumber    int      `json:"spotify_disc_number,omitempty"`
+	SpotifyTotalTracks   int      `json:"spotify_total_tracks,omitempty"`
+	SpotifyTotalDiscs    int      `json:"spotify_total_discs,omitempty"`
+	Copyright            string   `json:"copyright,omitempty"`
+	Publisher            string   `json:"publisher,omitempty"`
+	PlaylistName         string   `json:"playlist_name,omitempty"`
+	PlaylistOwner        string   `json:"playlist_owner,omitempty"`
+	AllowFallback        bool     `json:"allow_fallback"`
+	Artists              []string `json:"artists,omitempty"`
+	AlbumArtists         []string `json:"album_artists,omitempty"`
+	ArtistSeparator      string   `json:"artist_separator,omitempty"`
+	EmbedCredits         bool     `json:"embed_credits,omitempty"`
+	CatalogTrackID       string   `json:"catalog_track_id,omitempty"`
+	UPC                  string   `json:"upc,omitempty"`
 }
 
 type DownloadResponse struct {
@@ -91,6 +105,7 @@ type DownloadResponse struct {
 	Error         string `json:"error,omitempty"`
 	AlreadyExists bool   `json:"already_exists,omitempty"`
 	ItemID        string `json:"item_id,omitempty"`
+	Service       string `json:"service,omitempty"`
 }
 
 func (a *App) GetStreamingURLs(spotifyTrackID string, region string) (string, error) {
@@ -99,7 +114,7 @@ func (a *App) GetStreamingURLs(spotifyTrackID string, region string) (string, er
 	}
 
 	fmt.Printf("[GetStreamingURLs] Called for track ID: %s, Region: %s\n", spotifyTrackID, region)
-	client := backend.NewSongLinkClient()
+	client := backend.NewSongLinkClient().WithContext(a.ctx)
 	urls, err := client.GetAllURLsFromSpotify(spotifyTrackID, region)
 	if err != nil {
 		return "", err
@@ -128,6 +143,37 @@ func (a *App) GetSpotifyMetadata(req SpotifyMetadataRequest) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(req.Timeout*float64(time.Second)))
 	defer cancel()
 
+	if !backend.IsSpotifyURL(req.URL) && backend.DetectLinkPlatform(req.URL) != "" {
+		data, resolution, err := backend.ResolveExternalLink(ctx, req.URL, time.Duration(req.Delay*float64(time.Second)))
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve link: %v", err)
+		}
+
+		if track, ok := data.(backend.TrackResponse); ok && resolution != nil && isValidISRC(resolution.ISRC) {
+			track.Track.ISRC = resolution.ISRC
+			data = track
+		}
+
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode response: %v", err)
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(payload, &response); err != nil {
+			return "", fmt.Errorf("failed to encode response: %v", err)
+		}
+		if resolution != nil {
+			response["resolution"] = resolution
+		}
+
+		jsonData, err := json.MarshalIndent(response, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to encode response: %v", err)
+		}
+
+		return string(jsonData), nil
+	}
+
 	settings, err := a.LoadSettings()
 
 	if err == nil && settings != nil {
@@ -210,7 +256,7 @@ func (a *App) SearchSpotifyByType(req SpotifySearchByTypeRequest) ([]backend.Sea
 
 func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 
-	if req.Service == "qobuz" && req.ISRC == "" && req.SpotifyID == "" {
+	if req.Service == "qobuz" && req.ISRC == "" && req.SpotifyID == "" && req.CatalogTrackID == "" {
 		return DownloadResponse{
 			Success: false,
 			Error:   "Spotify ID is required for Qobuz",
@@ -275,12 +321,14 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 
 			var trackResp struct {
 				Track struct {
-					Copyright   string `json:"copyright"`
-					Publisher   string `json:"publisher"`
-					TotalDiscs  int    `json:"total_discs"`
-					TotalTracks int    `json:"total_tracks"`
-					TrackNumber int    `json:"track_number"`
-					ReleaseDate string `json:"release_date"`
+					Copyright        string   `json:"copyright"`
+					Publisher        string   `json:"publisher"`
+					TotalDiscs       int      `json:"total_discs"`
+					TotalTracks      int      `json:"total_tracks"`
+					TrackNumber      int      `json:"track_number"`
+					ReleaseDate      string   `json:"release_date"`
+					ArtistsList      []string `json:"artists_list"`
+					AlbumArtistsList []string `json:"album_artists_list"`
 				} `json:"track"`
 			}
 			if jsonData, jsonErr := json.Marshal(trackData); jsonErr == nil {
@@ -304,14 +352,46 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 					if req.ReleaseDate == "" && trackResp.Track.ReleaseDate != "" {
 						req.ReleaseDate = trackResp.Track.ReleaseDate
 					}
+					if len(req.Artists) == 0 && len(trackResp.Track.ArtistsList) > 0 {
+						req.Artists = trackResp.Track.ArtistsList
+					}
+					if len(req.AlbumArtists) == 0 && len(trackResp.Track.AlbumArtistsList) > 0 {
+						req.AlbumArtists = trackResp.Track.AlbumArtistsList
+					}
 				}
 			}
 		}
 	}
 
-	if req.TrackName != "" && req.ArtistName != "" {
-		expectedFilename := backend.BuildExpectedFilename(req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, req.FilenameFormat, req.PlaylistName, req.PlaylistOwner, req.TrackNumber, req.Position, req.SpotifyDiscNumber, req.UseAlbumTrackNumber)
-		expectedPath := filepath.Join(req.OutputDir, expectedFilename)
+	filenameArtist, filenameAlbumArtist := req.ArtistName, req.AlbumArtist
+	if req.ArtistSeparator != "" {
+		if len(req.Artists) > 0 {
+			filenameArtist = backend.JoinArtists(req.Artists, req.ArtistSeparator)
+		}
+		if len(req.AlbumArtists) > 0 {
+			filenameAlbumArtist = backend.JoinArtists(req.AlbumArtists, req.ArtistSeparator)
+		}
+	}
+
+	if req.TrackName != "" && filenameArtist != "" {
+		expectedData := backend.NewTrackTemplateData(
+			req.TrackName,
+			filenameArtist,
+			req.AlbumName,
+			filenameAlbumArtist,
+			req.ReleaseDate,
+			req.PlaylistName,
+			req.PlaylistOwner,
+			req.ISRC,
+			req.Position,
+			req.SpotifyTrackNumber,
+			req.SpotifyDiscNumber,
+			req.SpotifyTotalTracks,
+			req.SpotifyTotalDiscs,
+			req.UseAlbumTrackNumber && req.Service != "amazon",
+		)
+		expectedFilename := backend.BuildExpectedFilename(expectedData, req.FilenameFormat, req.TrackNumber, ".flac")
+		expectedPath := backend.FitPath(req.OutputDir, expectedFilename)
 
 		if fileInfo, err := os.Stat(expectedPath); err == nil && fileInfo.Size() > 100*1024 {
 
@@ -330,7 +410,7 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 	case "amazon":
 		downloader := backend.NewAmazonDownloader()
 		if req.ServiceURL != "" {
-			filename, err = downloader.DownloadByURL(req.ServiceURL, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.PlaylistName, req.PlaylistOwner, req.TrackNumber, req.Position, req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, req.CoverURL, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.EmbedMaxQualityCover, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL)
+			filename, err = downloader.DownloadByURL(req.ServiceURL, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.PlaylistName, req.PlaylistOwner, req.TrackNumber, req.Position, req.TrackName, filenameArtist, req.AlbumName, filenameAlbumArtist, req.ReleaseDate, req.CoverURL, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.EmbedMaxQualityCover, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL)
 		} else {
 			if req.SpotifyID == "" {
 				return DownloadResponse{
@@ -338,14 +418,14 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 					Error:   "Spotify ID is required for Amazon Music",
 				}, fmt.Errorf("spotify ID is required for Amazon Music")
 			}
-			filename, err = downloader.DownloadBySpotifyID(req.SpotifyID, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.PlaylistName, req.PlaylistOwner, req.TrackNumber, req.Position, req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, req.CoverURL, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.EmbedMaxQualityCover, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL)
+			filename, err = downloader.DownloadBySpotifyID(req.SpotifyID, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.PlaylistName, req.PlaylistOwner, req.TrackNumber, req.Position, req.TrackName, filenameArtist, req.AlbumName, filenameAlbumArtist, req.ReleaseDate, req.CoverURL, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.EmbedMaxQualityCover, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL)
 		}
 
 	case "tidal":
 		if req.ApiURL == "" || req.ApiURL == "auto" {
 			downloader := backend.NewTidalDownloader("")
 			if req.ServiceURL != "" {
-				filename, err = downloader.DownloadByURLWithFallback(req.ServiceURL, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.TrackNumber, req.Position, req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, req.UseAlbumTrackNumber, req.CoverURL, req.EmbedMaxQualityCover, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL, req.AllowFallback)
+				filename, err = downloader.DownloadByURLWithFallback(req.ServiceURL, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.TrackNumber, req.Position, req.TrackName, filenameArtist, req.AlbumName, filenameAlbumArtist, req.ReleaseDate, req.UseAlbumTrackNumber, req.CoverURL, req.EmbedMaxQualityCover, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL, req.AllowFallback)
 			} else {
 				if req.SpotifyID == "" {
 					return DownloadResponse{
@@ -353,12 +433,12 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 						Error:   "Spotify ID is required for Tidal",
 					}, fmt.Errorf("spotify ID is required for Tidal")
 				}
-				filename, err = downloader.Download(req.SpotifyID, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.TrackNumber, req.Position, req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, req.UseAlbumTrackNumber, req.CoverURL, req.EmbedMaxQualityCover, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL, req.AllowFallback)
+				filename, err = downloader.Download(req.SpotifyID, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.TrackNumber, req.Position, req.TrackName, filenameArtist, req.AlbumName, filenameAlbumArtist, req.ReleaseDate, req.UseAlbumTrackNumber, req.CoverURL, req.EmbedMaxQualityCover, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL, req.AllowFallback)
 			}
 		} else {
 			downloader := backend.NewTidalDownloader(req.ApiURL)
 			if req.ServiceURL != "" {
-				filename, err = downloader.DownloadByURL(req.ServiceURL, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.TrackNumber, req.Position, req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, req.UseAlbumTrackNumber, req.CoverURL, req.EmbedMaxQualityCover, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL, req.AllowFallback)
+				filename, err = downloader.DownloadByURL(req.ServiceURL, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.TrackNumber, req.Position, req.TrackName, filenameArtist, req.AlbumName, filenameAlbumArtist, req.ReleaseDate, req.UseAlbumTrackNumber, req.CoverURL, req.EmbedMaxQualityCover, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL, req.AllowFallback)
 			} else {
 				if req.SpotifyID == "" {
 					return DownloadResponse{
@@ -366,7 +446,7 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 						Error:   "Spotify ID is required for Tidal",
 					}, fmt.Errorf("spotify ID is required for Tidal")
 				}
-				filename, err = downloader.Download(req.SpotifyID, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.TrackNumber, req.Position, req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, req.UseAlbumTrackNumber, req.CoverURL, req.EmbedMaxQualityCover, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL, req.AllowFallback)
+				filename, err = downloader.Download(req.SpotifyID, req.OutputDir, req.AudioFormat, req.FilenameFormat, req.TrackNumber, req.Position, req.TrackName, filenameArtist, req.AlbumName, filenameAlbumArtist, req.ReleaseDate, req.UseAlbumTrackNumber, req.CoverURL, req.EmbedMaxQualityCover, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL, req.AllowFallback)
 			}
 		}
 
@@ -378,37 +458,27 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 			quality = "6"
 		}
 
-		deezerISRC := req.ISRC
-
-		if len(deezerISRC) != 12 || !isValidISRC(deezerISRC) {
-			deezerISRC = ""
-		}
-
-		if deezerISRC == "" && req.SpotifyID != "" {
-
-			songlinkClient := backend.NewSongLinkClient()
-			deezerURL, err := songlinkClient.GetDeezerURLFromSpotify(req.SpotifyID)
-			if err != nil {
-				return DownloadResponse{
-					Success: false,
-					Error:   fmt.Sprintf("Failed to get Deezer URL: %v", err),
-				}, err
-			}
-			deezerISRC, err = backend.GetDeezerISRC(deezerURL)
-			if err != nil {
+		if req.CatalogTrackID != "" {
+			trackID, parseErr := strconv.ParseInt(req.CatalogTrackID, 10, 64)
+			if parseErr != nil {
 				return DownloadResponse{
 					Success: false,
-					Error:   fmt.Sprintf("Failed to get ISRC from Deezer: %v", err),
-				}, err
+					Error:   fmt.Sprintf("Invalid Qobuz track ID: %s", req.CatalogTrackID),
+				}, fmt.Errorf("invalid Qobuz track ID: %s", req.CatalogTrackID)
 			}
+			filename, err = downloader.DownloadByTrackID(trackID, req.OutputDir, quality, req.FilenameFormat, req.TrackNumber, req.Position, req.TrackName, filenameArtist, req.AlbumName, filenameAlbumArtist, req.ReleaseDate, req.UseAlbumTrackNumber, req.CoverURL, req.EmbedMaxQualityCover, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL, req.AllowFallback)
+			break
 		}
-		if deezerISRC == "" {
+
+		resolution, resolveErr := resolveISRCForRequest(req)
+		if resolveErr != nil {
 			return DownloadResponse{
 				Success: false,
-				Error:   "ISRC is required for Qobuz (could not fetch from Deezer)",
-			}, fmt.Errorf("ISRC is required for Qobuz")
+				Error:   fmt.Sprintf("ISRC is required for Qobuz: %v", resolveErr),
+			}, fmt.Errorf("ISRC is required for Qobuz: %w", resolveErr)
 		}
-		filename, err = downloader.DownloadByISRC(deezerISRC, req.OutputDir, quality, req.FilenameFormat, req.TrackNumber, req.Position, req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, req.UseAlbumTrackNumber, req.CoverURL, req.EmbedMaxQualityCover, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL, req.AllowFallback)
+		fmt.Printf("Using ISRC %s (from %s)\n", resolution.ISRC, resolution.Source)
+		filename, err = downloader.DownloadByISRC(resolution.ISRC, req.OutputDir, quality, req.FilenameFormat, req.TrackNumber, req.Position, req.TrackName, filenameArtist, req.AlbumName, filenameAlbumArtist, req.ReleaseDate, req.UseAlbumTrackNumber, req.CoverURL, req.EmbedMaxQualityCover, req.SpotifyTrackNumber, req.SpotifyDiscNumber, req.SpotifyTotalTracks, req.SpotifyTotalDiscs, req.Copyright, req.Publisher, spotifyURL, req.AllowFallback)
 
 	default:
 		return DownloadResponse{
@@ -443,53 +513,46 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 		filename = strings.TrimPrefix(filename, "EXISTS:")
 	}
 
-	if !alreadyExists && req.SpotifyID != "" && req.EmbedLyrics && strings.HasSuffix(filename, ".flac") {
-		go func(filePath, spotifyID, trackName, artistName string) {
-			fmt.Printf("\n========== LYRICS FETCH START ==========\n")
-			fmt.Printf("Spotify ID: %s\n", spotifyID)
-			fmt.Printf("Track: %s\n", trackName)
-			fmt.Printf("Artist: %s\n", artistName)
-			fmt.Println("Searching all sources...")
+	if !alreadyExists && (len(req.Artists) > 0 || len(req.AlbumArtists) > 0 || filenameArtist != req.ArtistName || filenameAlbumArtist != req.AlbumArtist) {
+		if err := backend.EmbedArtistTags(filename, req.ArtistName, req.AlbumArtist, req.Artists, req.AlbumArtists); err != nil {
+			fmt.Printf("Warning: Failed to write artist tags: %v\n", err)
+		}
+	}
 
-			lyricsClient := backend.NewLyricsClient()
+	if !alreadyExists && req.CatalogTrackID != "" {
+		if err := backend.EmbedCatalogTags(filename, req.ISRC, req.UPC); err != nil {
+			fmt.Printf("Warning: Failed to write catalog tags: %v\n", err)
+		}
+	}
 
-			lyricsResp, source, err := lyricsClient.FetchLyricsAllSources(spotifyID, trackName, artistName, 0)
+	if !alreadyExists && req.EmbedCredits {
+		isrc := ""
+		if req.Service != "qobuz" {
+			isrc = resolveRequestISRC(req)
+		}
+		if req.SpotifyID != "" || isrc != "" {
+			credits, err := backend.FetchTrackCredits(req.SpotifyID, isrc)
 			if err != nil {
-				fmt.Printf("All sources failed: %v\n", err)
-				fmt.Printf("========== LYRICS FETCH END (FAILED) ==========\n\n")
-				return
-			}
-
-			if lyricsResp == nil || len(lyricsResp.Lines) == 0 {
-				fmt.Println("No lyrics content found")
-				fmt.Printf("========== LYRICS FETCH END (FAILED) ==========\n\n")
-				return
-			}
-
-			fmt.Printf("Lyrics found from: %s\n", source)
-			fmt.Printf("Sync type: %s\n", lyricsResp.SyncType)
-			fmt.Printf("Total lines: %d\n", len(lyricsResp.Lines))
-
-			lyrics := lyricsClient.ConvertToLRC(lyricsResp, trackName, artistName)
-			if lyrics == "" {
-				fmt.Println("No lyrics content to embed")
-				fmt.Printf("========== LYRICS FETCH END (FAILED) ==========\n\n")
-				return
+				fmt.Printf("Warning: Failed to fetch credits: %v\n", err)
+			} else if err := backend.EmbedTrackCredits(filename, credits); err != nil {
+				fmt.Printf("Warning: Failed to write credits: %v\n", err)
 			}
+		}
+	}
 
-			fmt.Printf("\n--- Full LRC Content ---\n")
-			fmt.Println(lyrics)
-			fmt.Printf("--- End LRC Content ---\n\n")
-
-			fmt.Printf("Embedding into: %s\n", filePath)
-			if err := backend.EmbedLyricsOnly(filePath, lyrics); err != nil {
-				fmt.Printf("Failed to embed lyrics: %v\n", err)
-				fmt.Printf("========== LYRICS FETCH END (FAILED) ==========\n\n")
-			} else {
-				fmt.Printf("Lyrics embedded successfully!\n")
-				fmt.Printf("========== LYRICS FETCH END (SUCCESS) ==========\n\n")
-			}
-		}(filename, req.SpotifyID, req.TrackName, req.ArtistName)
+	if !alreadyExists && req.SpotifyID != "" && req.EmbedLyrics && backend.SupportsLyricsEmbedding(filename) {
+		query := backend.LyricsQuery{
+			SpotifyID:  req.SpotifyID,
+			TrackName:  req.TrackName,
+			ArtistName: req.ArtistName,
+			AlbumName:  req.AlbumName,
+		}
+		if isValidISRC(req.ISRC) {
+			query.ISRC = req.ISRC
+		}
+		go func(id, filePath string, query backend.LyricsQuery) {
+			backend.NewCachedLyricsClient("SpotiFLAC").EmbedLyricsForDownload(id, filePath, query)
+		}(itemID, filename, query)
 	}
 
 	message := "Download completed successfully"
@@ -553,6 +616,7 @@ func (a *App) DownloadTrack(req DownloadRequest) (DownloadResponse, error) {
 		File:          filename,
 		AlreadyExists: alreadyExists,
 		ItemID:        itemID,
+		Service:       req.Service,
 	}, nil
 }
 
@@ -762,6 +826,516 @@ func (a *App) AnalyzeMultipleTracks(filePaths []string) (string, error) {
 	return string(jsonData), nil
 }
 
+type FindDuplicatesRequest struct {
+	RootDir   string  `json:"root_dir"`
+	Threshold float64 `json:"threshold,omitempty"`
+}
+
+func (a *App) FindDuplicateTracks(req FindDuplicatesRequest) (*backend.DuplicateScanResult, error) {
+	if req.RootDir == "" {
+		return nil, fmt.Errorf("directory path is required")
+	}
+
+	result, err := backend.FindDuplicates(req.RootDir, req.Threshold, func(done, total int) {
+		runtime.EventsEmit(a.ctx, "duplicates:progress", map[string]int{
+			"done":  done,
+			"total": total,
+		})
+	}, "SpotiFLAC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicates: %v", err)
+	}
+
+	return result, nil
+}
+
+type KeepBestDuplicateRequest struct {
+	KeepPath    string   `json:"keep_path"`
+	RemovePaths []string `json:"remove_paths"`
+	TrashDir    string   `json:"trash_dir,omitempty"`
+}
+
+func (a *App) KeepBestDuplicate(req KeepBestDuplicateRequest) ([]backend.DuplicateRemoveResult, error) {
+	if len(req.RemovePaths) == 0 {
+		return nil, fmt.Errorf("no duplicate copies selected")
+	}
+	return backend.RemoveDuplicateCopies(req.KeepPath, req.RemovePaths, req.TrashDir, "SpotiFLAC")
+}
+
+func (a *App) ScanLibrary(roots []string) (*backend.LibraryScanResult, error) {
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("at least one library folder is required")
+	}
+
+	result, err := backend.ScanLibrary(roots, func(done, total int) {
+		runtime.EventsEmit(a.ctx, "library:progress", map[string]int{
+			"done":  done,
+			"total": total,
+		})
+	}, "SpotiFLAC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan library: %v", err)
+	}
+
+	return result, nil
+}
+
+func (a *App) GetLibraryRoots() ([]backend.LibraryRoot, error) {
+	return backend.GetLibraryRoots("SpotiFLAC")
+}
+
+func (a *App) RemoveLibraryRoot(root string) error {
+	return backend.RemoveLibraryRoot(root, "SpotiFLAC")
+}
+
+func (a *App) QueryLibrary(query backend.LibraryQuery) ([]backend.LibraryTrack, error) {
+	return backend.QueryLibrary(query, "SpotiFLAC")
+}
+
+func (a *App) GetLibraryAlbumsMissingCovers() ([]backend.LibraryAlbum, error) {
+	return backend.GetLibraryAlbumsMissingCovers("SpotiFLAC")
+}
+
+func (a *App) GetLibraryTracksWithoutLyrics() ([]backend.LibraryTrack, error) {
+	return backend.GetLibraryTracksWithoutLyrics("SpotiFLAC")
+}
+
+func (a *App) GetLibraryUpgradeCandidates() ([]backend.LibraryUpgradeCandidate, error) {
+	return backend.GetLibraryUpgradeCandidates(a.ctx, "SpotiFLAC")
+}
+
+func (a *App) BackfillLyrics(req backend.LyricsBackfillRequest) (*backend.LyricsBackfillResult, error) {
+	result, err := backend.BackfillLyrics(req, func(done, total int) {
+		runtime.EventsEmit(a.ctx, "lyrics:backfill-progress", map[string]int{
+			"done":  done,
+			"total": total,
+		})
+	}, "SpotiFLAC")
+	if err != nil {
+		return nil, fmt.Errorf("failed to backfill lyrics: %v", err)
+	}
+
+	return result, nil
+}
+
+type UpgradeScanRequest struct {
+	Source string `json:"source"`
+	Folder string `json:"folder,omitempty"`
+}
+
+func (a *App) ScanForUpgrades(req UpgradeScanRequest) (*backend.UpgradeScanResult, error) {
+	progress := func(done, total int) {
+		runtime.EventsEmit(a.ctx, "upgrade:progress", map[string]int{
+			"done":  done,
+			"total": total,
+		})
+	}
+
+	switch req.Source {
+	case "history", "":
+		return backend.ScanUpgradesFromHistory(progress, "SpotiFLAC")
+	case "folder":
+		if req.Folder == "" {
+			return nil, fmt.Errorf("folder path is required")
+		}
+		return backend.ScanUpgradesInFolder(req.Folder, progress)
+	default:
+		return nil, fmt.Errorf("invalid upgrade scan source: %s", req.Source)
+	}
+}
+
+func (a *App) UpgradeFiles(reqs []backend.UpgradeRequest) []backend.UpgradeResult {
+	results := make([]backend.UpgradeResult, 0, len(reqs))
+	for i, req := range reqs {
+		results = append(results, backend.UpgradeFile(req, "SpotiFLAC"))
+		runtime.EventsEmit(a.ctx, "upgrade:replace-progress", map[string]int{
+			"done":  i + 1,
+			"total": len(reqs),
+		})
+	}
+	return results
+}
+
+type AlbumCompletionRequest struct {
+	Folder         string `json:"folder"`
+	Service        string `json:"service,omitempty"`
+	AudioFormat    string `json:"audio_format,omitempty"`
+	FilenameFormat string `json:"filename_format,omitempty"`
+	EmbedLyrics    bool   `json:"embed_lyrics,omitempty"`
+	AllowFallback  bool   `json:"allow_fallback"`
+}
+
+type AlbumCompletionResponse struct {
+	Report   *backend.AlbumCompletionReport `json:"report"`
+	Requests []DownloadRequest              `json:"requests"`
+}
+
+func (a *App) GetMissingAlbumTracks(req AlbumCompletionRequest) (*AlbumCompletionResponse, error) {
+	if req.Folder == "" {
+		return nil, fmt.Errorf("album folder is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	report, err := backend.BuildAlbumCompletionReport(ctx, req.Folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build missing track report: %v", err)
+	}
+
+	requests := make([]DownloadRequest, 0, len(report.Missing))
+	for _, track := range report.Missing {
+		dl := downloadRequestFromTrack(track, req.Folder, req.Service, req.AudioFormat, req.FilenameFormat)
+		dl.EmbedLyrics = req.EmbedLyrics
+		dl.AllowFallback = req.AllowFallback
+		requests = append(requests, dl)
+	}
+
+	return &AlbumCompletionResponse{
+		Report:   report,
+		Requests: requests,
+	}, nil
+}
+
+type AlbumSidecarRequest struct {
+	OutputDir  string                        `json:"output_dir"`
+	SpotifyURL string                        `json:"spotify_url,omitempty"`
+	Album      *backend.AlbumResponsePayload `json:"album,omitempty"`
+	Service    string                        `json:"service"`
+	Quality    string                        `json:"quality"`
+	Downloads  []backend.SidecarDownload     `json:"downloads"`
+	WriteJSON  bool                          `json:"write_json"`
+	WriteNFO   bool                          `json:"write_nfo"`
+	WriteCUE   bool                          `json:"write_cue"`
+}
+
+func (a *App) WriteAlbumSidecars(req AlbumSidecarRequest) ([]string, error) {
+	if req.OutputDir == "" {
+		for _, download := range req.Downloads {
+			if download.File != "" {
+				req.OutputDir = filepath.Dir(download.File)
+				break
+			}
+		}
+	}
+	if req.OutputDir == "" {
+		return nil, fmt.Errorf("output directory is required")
+	}
+
+	album := req.Album
+	if album == nil {
+		if req.SpotifyURL == "" {
+			return nil, fmt.Errorf("album data or Spotify URL is required")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		data, err := backend.GetFilteredSpotifyData(ctx, req.SpotifyURL, false, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch album: %v", err)
+		}
+		payload, ok := data.(*backend.AlbumResponsePayload)
+		if !ok {
+			return nil, fmt.Errorf("URL is not a Spotify album")
+		}
+		album = payload
+	}
+
+	sidecar := backend.BuildAlbumSidecar(album, req.Service, req.Quality, req.Downloads)
+	return backend.WriteAlbumSidecars(sidecar, backend.SidecarOptions{
+		OutputDir: req.OutputDir,
+		WriteJSON: req.WriteJSON,
+		WriteNFO:  req.WriteNFO,
+		WriteCUE:  req.WriteCUE,
+	})
+}
+
+func resolveISRCForRequest(req DownloadRequest) (*backend.ISRCResolution, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	return backend.ResolveISRC(ctx, backend.ISRCQuery{
+		SpotifyID:   req.SpotifyID,
+		ISRC:        req.ISRC,
+		TrackName:   req.TrackName,
+		ArtistName:  req.ArtistName,
+		AlbumName:   req.AlbumName,
+		DurationSec: req.Duration,
+	})
+}
+
+func resolveRequestISRC(req DownloadRequest) string {
+	resolution, err := resolveISRCForRequest(req)
+	if err != nil {
+		return ""
+	}
+	return resolution.ISRC
+}
+
+func (a *App) ResolveISRC(req backend.ISRCQuery) (*backend.ISRCResolution, error) {
+	if req.ISRC == "" && req.SpotifyID == "" && (req.TrackName == "" || req.ArtistName == "") {
+		return nil, fmt.Errorf("spotify ID, ISRC or track name and artist are required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	return backend.ResolveISRC(ctx, req)
+}
+
+func (a *App) ClearISRCCache() error {
+	return backend.ClearISRCCache("SpotiFLAC")
+}
+
+type PlaylistImportRequest struct {
+	FilePath       string  `json:"file_path,omitempty"`
+	Content        string  `json:"content,omitempty"`
+	Format         string  `json:"format,omitempty"`
+	Name           string  `json:"name,omitempty"`
+	Threshold      float64 `json:"threshold,omitempty"`
+	OutputDir      string  `json:"output_dir"`
+	Service        string  `json:"service,omitempty"`
+	AudioFormat    string  `json:"audio_format,omitempty"`
+	FilenameFormat string  `json:"filename_format,omitempty"`
+	EmbedLyrics    bool    `json:"embed_lyrics,omitempty"`
+	AllowFallback  bool    `json:"allow_fallback"`
+	Enqueue        bool    `json:"enqueue"`
+}
+
+type PlaylistImportResponse struct {
+	Report   *backend.ImportReport `json:"report"`
+	Requests []DownloadRequest     `json:"requests"`
+}
+
+func (a *App) ImportPlaylist(req PlaylistImportRequest) (*PlaylistImportResponse, error) {
+	content := req.Content
+	baseDir := ""
+	if req.FilePath != "" {
+		data, err := os.ReadFile(req.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read playlist file: %v", err)
+		}
+		content = string(data)
+		baseDir = filepath.Dir(req.FilePath)
+		if req.Name == "" {
+			req.Name = strings.TrimSuffix(filepath.Base(req.FilePath), filepath.Ext(req.FilePath))
+		}
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("playlist content is empty")
+	}
+
+	format := req.Format
+	if format == "" {
+		format = backend.DetectImportFormat(content, req.FilePath)
+	}
+
+	tracks, err := backend.ParsePlaylistImport(content, format, baseDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("no tracks found in playlist")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	report := backend.ResolveImportedTracks(ctx, tracks, req.Threshold, 300*time.Millisecond, func(done, total int) {
+		runtime.EventsEmit(a.ctx, "import:progress", map[string]int{
+			"done":  done,
+			"total": total,
+		})
+	})
+	report.Format = format
+
+	requests := make([]DownloadRequest, 0, report.Confident)
+	for _, match := range report.Matches {
+		if !match.Confident {
+			continue
+		}
+
+		dl := downloadRequestFromTrack(backend.AlbumTrackMetadata{
+			SpotifyID:   match.Match.ID,
+			ISRC:        match.Match.ID,
+			Name:        match.Match.Name,
+			Artists:     match.Match.Artists,
+			AlbumName:   match.Match.AlbumName,
+			ReleaseDate: match.Match.ReleaseDate,
+			Images:      match.Match.Images,
+			DurationMS:  match.Match.Duration,
+		}, req.OutputDir, req.Service, req.AudioFormat, req.FilenameFormat)
+		dl.TrackNumber = false
+		dl.UseAlbumTrackNumber = false
+		dl.Position = len(requests) + 1
+		dl.PlaylistName = req.Name
+		dl.EmbedLyrics = req.EmbedLyrics
+		dl.AllowFallback = req.AllowFallback
+
+		if req.Enqueue {
+			dl.ItemID = a.AddToDownloadQueue(dl.ISRC, dl.TrackName, dl.ArtistName, dl.AlbumName)
+		}
+		requests = append(requests, dl)
+	}
+
+	return &PlaylistImportResponse{
+		Report:   report,
+		Requests: requests,
+	}, nil
+}
+
+func downloadRequestFromTrack(track backend.AlbumTrackMetadata, outputDir, service, audioFormat, filenameFormat string) DownloadRequest {
+	return DownloadRequest{
+		ISRC:                track.ISRC,
+		Service:             service,
+		TrackName:           track.Name,
+		ArtistName:          track.Artists,
+		AlbumName:           track.AlbumName,
+		AlbumArtist:         track.AlbumArtist,
+		ReleaseDate:         track.ReleaseDate,
+		CoverURL:            track.Images,
+		OutputDir:           outputDir,
+		AudioFormat:         audioFormat,
+		FilenameFormat:      filenameFormat,
+		TrackNumber:         true,
+		Position:            track.TrackNumber,
+		UseAlbumTrackNumber: true,
+		SpotifyID:           track.SpotifyID,
+		Duration:            track.DurationMS / 1000,
+		SpotifyTrackNumber:  track.TrackNumber,
+		SpotifyDiscNumber:   track.DiscNumber,
+		SpotifyTotalTracks:  track.TotalTracks,
+		SpotifyTotalDiscs:   track.TotalDiscs,
+		Artists:             track.ArtistsList,
+		AlbumArtists:        track.AlbumArtistsList,
+	}
+}
+
+type CatalogSearchRequest struct {
+	Service string `json:"service"`
+	Query   string `json:"query"`
+	Type    string `json:"type,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+	Offset  int    `json:"offset,omitempty"`
+}
+
+func (a *App) SearchCatalog(req CatalogSearchRequest) (*backend.CatalogSearchResult, error) {
+	return backend.SearchCatalog(req.Service, req.Query, req.Type, req.Limit, req.Offset)
+}
+
+func (a *App) GetCatalogAlbum(service, albumID string) (*backend.CatalogCollection, error) {
+	return backend.GetCatalogCollection(service, "album", albumID)
+}
+
+func (a *App) GetCatalogPlaylist(service, playlistID string) (*backend.CatalogCollection, error) {
+	return backend.GetCatalogCollection(service, "playlist", playlistID)
+}
+
+type CatalogDownloadRequest struct {
+	Service              string   `json:"service"`
+	Type                 string   `json:"type"`
+	ID                   string   `json:"id"`
+	TrackIDs             []string `json:"track_ids,omitempty"`
+	OutputDir            string   `json:"output_dir"`
+	AudioFormat          string   `json:"audio_format,omitempty"`
+	FilenameFormat       string   `json:"filename_format,omitempty"`
+	EmbedMaxQualityCover bool     `json:"embed_max_quality_cover,omitempty"`
+	AllowFallback        bool     `json:"allow_fallback"`
+	Enqueue              bool     `json:"enqueue"`
+}
+
+type CatalogDownloadResponse struct {
+	Collection *backend.CatalogCollection `json:"collection"`
+	Requests   []DownloadRequest          `json:"requests"`
+}
+
+func (a *App) PrepareCatalogDownload(req CatalogDownloadRequest) (*CatalogDownloadResponse, error) {
+	if req.Type == "" {
+		req.Type = "album"
+	}
+
+	collection, err := backend.GetCatalogCollection(req.Service, req.Type, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool, len(req.TrackIDs))
+	for _, id := range req.TrackIDs {
+		selected[id] = true
+	}
+
+	requests := make([]DownloadRequest, 0, len(collection.Tracks))
+	for i, track := range collection.Tracks {
+		if len(selected) > 0 && !selected[track.ID] {
+			continue
+		}
+
+		dl := downloadRequestFromCatalogTrack(track, collection, req.OutputDir, req.AudioFormat, req.FilenameFormat)
+		if collection.Type == "playlist" {
+			dl.TrackNumber = false
+			dl.UseAlbumTrackNumber = false
+			dl.Position = i + 1
+			dl.PlaylistName = collection.Title
+			dl.PlaylistOwner = collection.Artist
+		}
+		dl.EmbedMaxQualityCover = req.EmbedMaxQualityCover
+		dl.AllowFallback = req.AllowFallback
+
+		if req.Enqueue {
+			dl.ItemID = a.AddToDownloadQueue(dl.ISRC, dl.TrackName, dl.ArtistName, dl.AlbumName)
+		}
+		requests = append(requests, dl)
+	}
+
+	return &CatalogDownloadResponse{
+		Collection: collection,
+		Requests:   requests,
+	}, nil
+}
+
+func downloadRequestFromCatalogTrack(track backend.CatalogTrack, collection *backend.CatalogCollection, outputDir, audioFormat, filenameFormat string) DownloadRequest {
+	albumArtist := track.AlbumArtist
+	if albumArtist == "" && collection.Type == "album" {
+		albumArtist = collection.Artist
+	}
+	releaseDate := track.ReleaseDate
+	if releaseDate == "" {
+		releaseDate = collection.ReleaseDate
+	}
+	coverURL := track.CoverURL
+	if coverURL == "" {
+		coverURL = collection.CoverURL
+	}
+
+	return DownloadRequest{
+		ISRC:                track.ISRC,
+		Service:             collection.Service,
+		TrackName:           track.Title,
+		ArtistName:          track.Artist,
+		AlbumName:           track.Album,
+		AlbumArtist:         albumArtist,
+		ReleaseDate:         releaseDate,
+		CoverURL:            coverURL,
+		OutputDir:           outputDir,
+		AudioFormat:         audioFormat,
+		FilenameFormat:      filenameFormat,
+		TrackNumber:         true,
+		Position:            track.TrackNumber,
+		UseAlbumTrackNumber: true,
+		ServiceURL:          track.URL,
+		Duration:            track.DurationMS / 1000,
+		SpotifyTrackNumber:  track.TrackNumber,
+		SpotifyDiscNumber:   track.DiscNumber,
+		SpotifyTotalTracks:  len(collection.Tracks),
+		SpotifyTotalDiscs:   collection.TotalDiscs,
+		Copyright:           collection.Copyright,
+		Publisher:           collection.Label,
+		Artists:             track.Artists,
+		CatalogTrackID:      track.ID,
+		UPC:                 collection.UPC,
+	}
+}
+
 type LyricsDownloadRequest struct {
 	SpotifyID           string `json:"spotify_id"`
 	TrackName           string `json:"track_name"`
@@ -775,6 +1349,9 @@ type LyricsDownloadRequest struct {
 	Position            int    `json:"position"`
 	UseAlbumTrackNumber bool   `json:"use_album_track_number"`
 	DiscNumber          int    `json:"disc_number"`
+	Format              string `json:"format,omitempty"`
+	ISRC                string `json:"isrc,omitempty"`
+	Refresh             bool   `json:"refresh,omitempty"`
 }
 
 func (a *App) DownloadLyrics(req LyricsDownloadRequest) (backend.LyricsDownloadResponse, error) {
@@ -785,7 +1362,7 @@ func (a *App) DownloadLyrics(req LyricsDownloadRequest) (backend.LyricsDownloadR
 		}, fmt.Errorf("spotify ID is required")
 	}
 
-	client := backend.NewLyricsClient()
+	client := backend.NewCachedLyricsClient("SpotiFLAC")
 	backendReq := backend.LyricsDownloadRequest{
 		SpotifyID:           req.SpotifyID,
 		TrackName:           req.TrackName,
@@ -799,6 +1376,9 @@ func (a *App) DownloadLyrics(req LyricsDownloadRequest) (backend.LyricsDownloadR
 		Position:            req.Position,
 		UseAlbumTrackNumber: req.UseAlbumTrackNumber,
 		DiscNumber:          req.DiscNumber,
+		Format:              req.Format,
+		ISRC:                req.ISRC,
+		Refresh:             req.Refresh,
 	}
 
 	resp, err := client.DownloadLyrics(backendReq)
@@ -984,7 +1564,7 @@ func (a *App) CheckTrackAvailability(spotifyTrackID string, isrc string) (string
 		return "", fmt.Errorf("spotify track ID is required")
 	}
 
-	client := backend.NewSongLinkClient()
+	client := backend.NewSongLinkClient().WithContext(a.ctx)
 	availability, err := client.CheckTrackAvailability(spotifyTrackID, isrc)
 	if err != nil {
 		return "", err
@@ -998,6 +1578,86 @@ func (a *App) CheckTrackAvailability(spotifyTrackID string, isrc string) (string
 	return string(jsonData), nil
 }
 
+type AvailabilityMatrixRequest struct {
+	TrackList []backend.AlbumTrackMetadata `json:"track_list"`
+	Refresh   bool                         `json:"refresh"`
+}
+
+func (a *App) CheckAvailabilityMatrix(req AvailabilityMatrixRequest) (*backend.AvailabilityMatrix, error) {
+	if len(req.TrackList) == 0 {
+		return nil, fmt.Errorf("track list is required")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.availabilityMu.Lock()
+	if a.availabilityCancel != nil {
+		a.availabilityCancel()
+	}
+	a.availabilityCancel = cancel
+	a.availabilityRun++
+	run := a.availabilityRun
+	a.availabilityMu.Unlock()
+	defer func() {
+		a.availabilityMu.Lock()
+		cancel()
+		if a.availabilityRun == run {
+			a.availabilityCancel = nil
+		}
+		a.availabilityMu.Unlock()
+	}()
+
+	done := 0
+	return backend.BuildAvailabilityMatrix(ctx, req.TrackList, req.Refresh, func(index int, row backend.AvailabilityMatrixRow) {
+		done++
+		runtime.EventsEmit(a.ctx, "availability:row", map[string]interface{}{
+			"index": index,
+			"row":   row,
+			"done":  done,
+			"total": len(req.TrackList),
+		})
+	}, "SpotiFLAC")
+}
+
+func (a *App) CancelAvailabilityMatrix() {
+	a.availabilityMu.Lock()
+	defer a.availabilityMu.Unlock()
+	if a.availabilityCancel != nil {
+		a.availabilityCancel()
+		a.availabilityCancel = nil
+	}
+}
+
+func (a *App) ClearAvailabilityCache() error {
+	return backend.ClearAvailabilityCache("SpotiFLAC")
+}
+
+func (a *App) ResolveLink(link string) (*backend.ExternalLinkResolution, error) {
+	if link == "" {
+		return nil, fmt.Errorf("link is required")
+	}
+
+	client := backend.NewSongLinkClient().WithContext(a.ctx)
+	return client.ResolveLink(link)
+}
+
+func (a *App) GetCachedSongLinks(spotifyID string, region string) (*backend.SongLinkCacheEntry, error) {
+	if spotifyID == "" {
+		return nil, fmt.Errorf("spotify track ID is required")
+	}
+	return backend.GetCachedSongLinks(spotifyID, region, "SpotiFLAC")
+}
+
+func (a *App) DeleteCachedSongLinks(spotifyID string) error {
+	if spotifyID == "" {
+		return fmt.Errorf("spotify track ID is required")
+	}
+	return backend.DeleteCachedSongLinks(spotifyID, "SpotiFLAC")
+}
+
+func (a *App) ClearSongLinkCache() error {
+	return backend.ClearSongLinkCache("SpotiFLAC")
+}
+
 func (a *App) IsFFmpegInstalled() (bool, error) {
 	return backend.IsFFmpegInstalled()
 }
@@ -1088,6 +1748,154 @@ func (a *App) ReadFileMetadata(filePath string) (*backend.AudioMetadata, error)
 	return backend.ReadAudioMetadata(filePath)
 }
 
+func (a *App) ReadAllTags(files []string) []backend.FileTags {
+	return backend.ReadAllTagsForFiles(files)
+}
+
+func (a *App) ApplyTagEdits(req backend.TagEditRequest) (*backend.TagEditResult, error) {
+	return backend.ApplyTagEdits(req, "SpotiFLAC")
+}
+
+func (a *App) GetTagEditJournal() ([]backend.TagJournal, error) {
+	return backend.GetTagJournal("SpotiFLAC")
+}
+
+func (a *App) UndoTagEdit(journalID string) ([]backend.TagEditFileResult, error) {
+	if journalID == "" {
+		return nil, fmt.Errorf("journal ID is required")
+	}
+	return backend.UndoTagEdit(journalID, "SpotiFLAC")
+}
+
+func (a *App) PreviewReorganizeLibrary(req backend.ReorganizeRequest) (*backend.ReorganizeResult, error) {
+	return backend.PreviewReorganize(req)
+}
+
+func (a *App) ReorganizeLibrary(req backend.ReorganizeRequest) (*backend.ReorganizeResult, error) {
+	return backend.ApplyReorganize(req, "SpotiFLAC")
+}
+
+func (a *App) GetReorganizeJournal() ([]backend.ReorganizeJournal, error) {
+	return backend.GetReorganizeJournal("SpotiFLAC")
+}
+
+func (a *App) UndoReorganizeLibrary(journalID string) (*backend.ReorganizeResult, error) {
+	if journalID == "" {
+		return nil, fmt.Errorf("journal ID is required")
+	}
+	return backend.UndoReorganize(journalID, "SpotiFLAC")
+}
+
+func (a *App) PreviewFilenameTemplate(format string, data backend.TemplateData) string {
+	return backend.RenderTemplate(format, data, backend.SanitizeFilename)
+}
+
+func (a *App) GetSanitizeOptions() backend.SanitizeOptions {
+	return backend.GetSanitizeOptions()
+}
+
+func (a *App) SetSanitizeOptions(options backend.SanitizeOptions) error {
+	return backend.SetSanitizeOptions(options)
+}
+
+func (a *App) PreviewSanitizedFilename(name, profile string) string {
+	return backend.SanitizeFilenameWithProfile(name, profile)
+}
+
+func applySanitizeSettings(settings map[string]interface{}) {
+	options := backend.GetSanitizeOptions()
+	if profile, ok := settings["sanitizeProfile"].(string); ok {
+		options.Profile = profile
+	}
+	if n, ok := settings["maxFilenameLength"].(float64); ok {
+		options.MaxComponentLength = int(n)
+	}
+	if n, ok := settings["maxPathLength"].(float64); ok {
+		options.MaxPathLength = int(n)
+	}
+	if err := backend.SetSanitizeOptions(options); err != nil {
+		fmt.Printf("Ignoring sanitization settings: %v\n", err)
+	}
+}
+
+func applyLyricsSettings(settings map[string]interface{}) {
+	config := backend.GetLyricsProviderConfig()
+	if providers, ok := settings["lyricsProviders"].([]interface{}); ok {
+		config.Providers = settingsStringList(providers)
+	}
+	if n, ok := settings["lyricsMatchThreshold"].(float64); ok {
+		config.Threshold = n
+	}
+	if n, ok := settings["lyricsFallbackThreshold"].(float64); ok {
+		config.FallbackThreshold = n
+	}
+	if allow, ok := settings["lyricsAllowUnofficial"].(bool); ok {
+		config.AllowUnofficial = allow
+	}
+	if dirs, ok := settings["lyricsFolders"].([]interface{}); ok {
+		config.LocalDirs = settingsStringList(dirs)
+	}
+	if autoAlign, ok := settings["lyricsAutoAlign"].(bool); ok {
+		config.AutoAlign = autoAlign
+	}
+	if variants, ok := settings["lyricsVariants"].([]interface{}); ok {
+		config.Variants = settingsStringList(variants)
+	}
+	if err := backend.SetLyricsProviderConfig(config); err != nil {
+		fmt.Printf("Ignoring lyrics provider settings: %v\n", err)
+	}
+}
+
+func settingsStringList(values []interface{}) []string {
+	list := make([]string, 0, len(values))
+	for _, value := range values {
+		if str, ok := value.(string); ok && str != "" {
+			list = append(list, str)
+		}
+	}
+	return list
+}
+
+func (a *App) GetLyricsProviderConfig() backend.LyricsProviderConfig {
+	return backend.GetLyricsProviderConfig()
+}
+
+func (a *App) SetLyricsProviderConfig(config backend.LyricsProviderConfig) error {
+	return backend.SetLyricsProviderConfig(config)
+}
+
+func (a *App) SearchLyrics(query backend.LyricsQuery) []backend.LyricsCandidate {
+	return backend.NewLyricsClient().SearchLyricsCandidates(query)
+}
+
+func (a *App) AlignLyrics(req backend.LyricsAlignRequest) (*backend.LyricsAlignment, error) {
+	return backend.AlignLyricsFile(req)
+}
+
+func (a *App) GetCachedLyrics(query backend.LyricsQuery) (*backend.LyricsCacheEntry, error) {
+	return backend.GetCachedLyrics(query, "SpotiFLAC")
+}
+
+func (a *App) ListCachedLyrics() ([]backend.LyricsCacheEntry, error) {
+	return backend.ListCachedLyrics("SpotiFLAC")
+}
+
+func (a *App) UpdateCachedLyrics(query backend.LyricsQuery, content string) (*backend.LyricsCacheEntry, error) {
+	return backend.UpdateCachedLyrics(query, content, "SpotiFLAC")
+}
+
+func (a *App) DeleteCachedLyrics(query backend.LyricsQuery) error {
+	return backend.DeleteCachedLyrics(query, "SpotiFLAC")
+}
+
+func (a *App) PruneLyricsCache() (int, error) {
+	return backend.PruneLyricsCache("SpotiFLAC")
+}
+
+func (a *App) ClearLyricsCache() error {
+	return backend.ClearLyricsCache("SpotiFLAC")
+}
+
 func (a *App) PreviewRenameFiles(files []string, format string) []backend.RenamePreview {
 	return backend.PreviewRename(files, format)
 }
@@ -1158,20 +1966,26 @@ func (a *App) ReadImageAsBase64(filePath string) (string, error) {
 }
 
 type CheckFileExistenceRequest struct {
-	SpotifyID           string `json:"spotify_id"`
-	TrackName           string `json:"track_name"`
-	ArtistName          string `json:"artist_name"`
-	AlbumName           string `json:"album_name,omitempty"`
-	AlbumArtist         string `json:"album_artist,omitempty"`
-	ReleaseDate         string `json:"release_date,omitempty"`
-	TrackNumber         int    `json:"track_number,omitempty"`
-	DiscNumber          int    `json:"disc_number,omitempty"`
-	Position            int    `json:"position,omitempty"`
-	UseAlbumTrackNumber bool   `json:"use_album_track_number,omitempty"`
-	FilenameFormat      string `json:"filename_format,omitempty"`
-	IncludeTrackNumber  bool   `json:"include_track_number,omitempty"`
-	AudioFormat         string `json:"audio_format,omitempty"`
-	RelativePath        string `json:"relative_path,omitempty"`
+	SpotifyID           string   `json:"spotify_id"`
+	TrackName           string   `json:"track_name"`
+	ArtistName          string   `json:"artist_name"`
+	AlbumName           string   `json:"album_name,omitempty"`
+	AlbumArtist         string   `json:"album_artist,omitempty"`
+	ReleaseDate         string   `json:"release_date,omitempty"`
+	TrackNumber         int      `json:"track_number,omitempty"`
+	DiscNumber          int      `json:"disc_number,omitempty"`
+	TotalTracks         int      `json:"total_tracks,omitempty"`
+	TotalDiscs          int      `json:"total_discs,omitempty"`
+	ISRC                string   `json:"isrc,omitempty"`
+	Position            int      `json:"position,omitempty"`
+	UseAlbumTrackNumber bool     `json:"use_album_track_number,omitempty"`
+	FilenameFormat      string   `json:"filename_format,omitempty"`
+	IncludeTrackNumber  bool     `json:"include_track_number,omitempty"`
+	AudioFormat         string   `json:"audio_format,omitempty"`
+	RelativePath        string   `json:"relative_path,omitempty"`
+	Artists             []string `json:"artists,omitempty"`
+	AlbumArtists        []string `json:"album_artists,omitempty"`
+	ArtistSeparator     string   `json:"artist_separator,omitempty"`
 }
 
 type CheckFileExistenceResult struct {
@@ -1244,39 +2058,45 @@ func (a *App) CheckFilesExistence(outputDir string, rootDir string, tracks []Che
 				filenameFormat = defaultFilenameFormat
 			}
 
-			trackNumber := t.Position
-			if t.UseAlbumTrackNumber && t.TrackNumber > 0 {
-				trackNumber = t.TrackNumber
-			}
-
 			fileExt := ".flac"
 			if t.AudioFormat == "mp3" {
 				fileExt = ".mp3"
 			}
 
-			expectedFilenameBase := backend.BuildExpectedFilename(
+			artistName, albumArtist := t.ArtistName, t.AlbumArtist
+			if t.ArtistSeparator != "" {
+				if len(t.Artists) > 0 {
+					artistName = backend.JoinArtists(t.Artists, t.ArtistSeparator)
+				}
+				if len(t.AlbumArtists) > 0 {
+					albumArtist = backend.JoinArtists(t.AlbumArtists, t.ArtistSeparator)
+				}
+			}
+
+			expectedData := backend.NewTrackTemplateData(
 				t.TrackName,
-				t.ArtistName,
+				artistName,
 				t.AlbumName,
-				t.AlbumArtist,
+				albumArtist,
 				t.ReleaseDate,
-				filenameFormat,
 				"",
 				"",
-				t.IncludeTrackNumber,
-				trackNumber,
+				t.ISRC,
+				t.Position,
+				t.TrackNumber,
 				t.DiscNumber,
+				t.TotalTracks,
+				t.TotalDiscs,
 				t.UseAlbumTrackNumber,
 			)
-
-			expectedFilename := strings.TrimSuffix(expectedFilenameBase, ".flac") + fileExt
+			expectedFilename := backend.BuildExpectedFilename(expectedData, filenameFormat, t.IncludeTrackNumber, fileExt)
 
 			targetDir := outputDir
 			if t.RelativePath != "" {
 				targetDir = filepath.Join(outputDir, t.RelativePath)
 			}
 
-			expectedPath := filepath.Join(targetDir, expectedFilename)
+			expectedPath := backend.FitPath(targetDir, expectedFilename)
 
 			if fileInfo, err := os.Stat(expectedPath); err == nil && fileInfo.Size() > 100*1024 {
 				res.Exists = true
@@ -1363,6 +2183,9 @@ func (a *App) SaveSettings(settings map[string]interface{}) error {
 		return err
 	}
 
+	applySanitizeSettings(settings)
+	applyLyricsSettings(settings)
+
 	return os.WriteFile(configPath, data, 0644)
 }
 
@@ -1402,46 +2225,100 @@ func (a *App) CreateM3U8File(m3u8Name string, outputDir string, filePaths []stri
 		return nil
 	}
 
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
-		return err
+	entries := make([]backend.PlaylistEntry, 0, len(filePaths))
+	for _, path := range filePaths {
+		if path != "" {
+			entries = append(entries, backend.PlaylistEntry{Path: path})
+		}
+	}
+	if len(entries) == 0 {
+		return nil
 	}
 
-	fnName := m3u8Name
+	_, err := backend.ExportPlaylist(backend.PlaylistExportRequest{
+		Name:      m3u8Name,
+		OutputDir: outputDir,
+		Entries:   entries,
+	})
+	return err
+}
 
-	safeName := backend.SanitizeFilename(fnName)
-	if safeName == "" {
-		safeName = "playlist"
-	}
+func (a *App) ExportPlaylist(req backend.PlaylistExportRequest) ([]string, error) {
+	return backend.ExportPlaylist(req)
+}
 
-	m3u8Path := filepath.Join(outputDir, safeName+".m3u8")
+type PlaylistRegenerateRequest struct {
+	Name       string                   `json:"name"`
+	OutputDir  string                   `json:"output_dir"`
+	SpotifyURL string                   `json:"spotify_url,omitempty"`
+	SpotifyIDs []string                 `json:"spotify_ids,omitempty"`
+	SearchDirs []string                 `json:"search_dirs,omitempty"`
+	Targets    []backend.PlaylistTarget `json:"targets"`
+}
 
-	f, err := os.Create(m3u8Path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+type PlaylistRegenerateResponse struct {
+	Files   []string                     `json:"files"`
+	Matched int                          `json:"matched"`
+	Missing []backend.AlbumTrackMetadata `json:"missing,omitempty"`
+}
 
-	if _, err := f.WriteString("#EXTM3U\n"); err != nil {
-		return err
+func (a *App) RegeneratePlaylist(req PlaylistRegenerateRequest) (*PlaylistRegenerateResponse, error) {
+	if req.OutputDir == "" {
+		return nil, fmt.Errorf("output directory is required")
 	}
 
-	for _, path := range filePaths {
-		if path == "" {
-			continue
-		}
+	response := &PlaylistRegenerateResponse{}
+	var entries []backend.PlaylistEntry
 
-		relPath, err := filepath.Rel(outputDir, path)
-		if err != nil {
+	if req.SpotifyURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		defer cancel()
 
-			relPath = path
+		data, err := backend.GetFilteredSpotifyData(ctx, req.SpotifyURL, false, 0)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch playlist: %v", err)
 		}
 
-		relPath = filepath.ToSlash(relPath)
+		var tracks []backend.AlbumTrackMetadata
+		switch payload := data.(type) {
+		case backend.PlaylistResponsePayload:
+			tracks = payload.TrackList
+			if req.Name == "" {
+				req.Name = payload.PlaylistInfo.Owner.Name
+			}
+		case *backend.AlbumResponsePayload:
+			tracks = payload.TrackList
+			if req.Name == "" {
+				req.Name = payload.AlbumInfo.Name
+			}
+		default:
+			return nil, fmt.Errorf("URL is not a Spotify playlist or album")
+		}
 
-		if _, err := f.WriteString(relPath + "\n"); err != nil {
-			return err
+		searchDirs := req.SearchDirs
+		if len(searchDirs) == 0 {
+			searchDirs = []string{req.OutputDir}
+		}
+		entries, response.Missing = backend.MatchPlaylistTracks(tracks, searchDirs, "SpotiFLAC")
+	} else {
+		var err error
+		entries, err = backend.PlaylistEntriesFromHistory(req.SpotifyIDs, "SpotiFLAC")
+		if err != nil {
+			return nil, fmt.Errorf("failed to read download history: %v", err)
 		}
 	}
 
-	return nil
+	response.Matched = len(entries)
+	if len(entries) == 0 {
+		return response, fmt.Errorf("no downloaded files found for this playlist")
+	}
+
+	files, err := backend.ExportPlaylist(backend.PlaylistExportRequest{
+		Name:      req.Name,
+		OutputDir: req.OutputDir,
+		Entries:   entries,
+		Targets:   req.Targets,
+	})
+	response.Files = files
+	return response, err
 }
@@ -0,0 +1,351 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type LocalAlbumTrack struct {
+	Path        string `json:"path"`
+	Title       string `json:"title"`
+	Artist      string `json:"artist"`
+	TrackNumber int    `json:"track_number"`
+	DiscNumber  int    `json:"disc_number"`
+	ISRC        string `json:"isrc,omitempty"`
+	SpotifyID   string `json:"spotify_id,omitempty"`
+}
+
+type AlbumCompletionReport struct {
+	Folder       string                `json:"folder"`
+	Album        string                `json:"album"`
+	AlbumArtist  string                `json:"album_artist"`
+	SpotifyID    string                `json:"spotify_id"`
+	SpotifyURL   string                `json:"spotify_url"`
+	MatchedBy    string                `json:"matched_by"`
+	TotalTracks  int                   `json:"total_tracks"`
+	LocalTracks  []LocalAlbumTrack     `json:"local_tracks"`
+	Missing      []AlbumTrackMetadata  `json:"missing"`
+	Unmatched    []LocalAlbumTrack     `json:"unmatched,omitempty"`
+	AlbumPayload *AlbumResponsePayload `json:"album_payload,omitempty"`
+}
+
+func BuildAlbumCompletionReport(ctx context.Context, folder string) (*AlbumCompletionReport, error) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read folder: %w", err)
+	}
+
+	report := &AlbumCompletionReport{Folder: folder}
+	albumVotes := make(map[string]int)
+	artistVotes := make(map[string]int)
+	var isrcs []string
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".flac", ".mp3", ".m4a":
+		default:
+			continue
+		}
+
+		path := filepath.Join(folder, entry.Name())
+		metadata, err := ExtractFullMetadataFromFile(path)
+		if err != nil {
+			fmt.Printf("[AlbumCompletion] Failed to read tags from %s: %v\n", path, err)
+			metadata = Metadata{}
+		}
+
+		local := LocalAlbumTrack{
+			Path:        path,
+			Title:       metadata.Title,
+			Artist:      metadata.Artist,
+			TrackNumber: metadata.TrackNumber,
+			DiscNumber:  metadata.DiscNumber,
+			ISRC:        metadata.ISRC,
+		}
+		if local.Title == "" {
+			local.Title = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		}
+		report.LocalTracks = append(report.LocalTracks, local)
+
+		if metadata.Album != "" {
+			albumVotes[metadata.Album]++
+		}
+		albumArtist := metadata.AlbumArtist
+		if albumArtist == "" {
+			albumArtist = metadata.Artist
+		}
+		if albumArtist != "" {
+			artistVotes[albumArtist]++
+		}
+		if IsValidISRC(metadata.ISRC) {
+			isrcs = append(isrcs, metadata.ISRC)
+		}
+	}
+
+	if len(report.LocalTracks) == 0 {
+		return nil, fmt.Errorf("no audio files found in %s", folder)
+	}
+
+	report.Album = mostVoted(albumVotes)
+	report.AlbumArtist = mostVoted(artistVotes)
+
+	client := NewSpotifyMetadataClient()
+
+	for _, isrc := range isrcs {
+		albumID, err := findSpotifyAlbumByISRC(ctx, client, isrc)
+		if err != nil {
+			fmt.Printf("[AlbumCompletion] ISRC lookup failed for %s: %v\n", isrc, err)
+			continue
+		}
+		if albumID != "" {
+			report.SpotifyID = albumID
+			report.MatchedBy = "isrc"
+			break
+		}
+	}
+
+	if report.SpotifyID == "" {
+		if report.Album == "" {
+			return nil, fmt.Errorf("could not determine album name from tags")
+		}
+		albumID, err := findSpotifyAlbumBySearch(ctx, client, report.Album, report.AlbumArtist, len(report.LocalTracks))
+		if err != nil {
+			return nil, err
+		}
+		report.SpotifyID = albumID
+		report.MatchedBy = "search"
+	}
+
+	report.SpotifyURL = fmt.Sprintf("https://open.spotify.com/album/%s", report.SpotifyID)
+
+	data, err := client.GetFilteredData(ctx, report.SpotifyURL, false, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch Spotify album: %w", err)
+	}
+
+	payload, ok := data.(*AlbumResponsePayload)
+	if !ok {
+		return nil, fmt.Errorf("unexpected Spotify album payload")
+	}
+	report.AlbumPayload = payload
+	report.TotalTracks = len(payload.TrackList)
+	if payload.AlbumInfo.Name != "" {
+		report.Album = payload.AlbumInfo.Name
+	}
+	if payload.AlbumInfo.Artists != "" {
+		report.AlbumArtist = payload.AlbumInfo.Artists
+	}
+
+	trackIDs := make([]string, 0, len(payload.TrackList))
+	for _, track := range payload.TrackList {
+		if track.SpotifyID != "" {
+			trackIDs = append(trackIDs, track.SpotifyID)
+		}
+	}
+	trackISRCs := make(map[string]string)
+	albumISRCs := make(map[string]bool)
+	if ids, err := client.FetchTrackISRCs(ctx, trackIDs); err != nil {
+		fmt.Printf("[AlbumCompletion] Could not fetch album ISRCs: %v\n", err)
+	} else {
+		for id, track := range ids {
+			trackISRCs[id] = track.ISRC
+			albumISRCs[track.ISRC] = true
+		}
+	}
+
+	if report.MatchedBy == "isrc" && len(albumISRCs) > 0 {
+		contains := false
+		for _, isrc := range isrcs {
+			contains = contains || albumISRCs[isrc]
+		}
+		if !contains {
+			return nil, fmt.Errorf("Spotify album %s does not contain any local ISRC", report.SpotifyID)
+		}
+	}
+
+	matchAlbumTracks(report, payload.TrackList, trackISRCs)
+
+	return report, nil
+}
+
+func findSpotifyAlbumByISRC(ctx context.Context, client *SpotifyMetadataClient, isrc string) (string, error) {
+	results, err := client.SearchByType(ctx, "isrc:"+isrc, "track", 5, 0)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "", nil
+	}
+
+	ids := make([]string, 0, len(results))
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+	tracks, err := client.FetchTrackISRCs(ctx, ids)
+	if err != nil {
+		return "", err
+	}
+
+	for _, id := range ids {
+		if track, ok := tracks[id]; ok && track.ISRC == isrc && track.AlbumID != "" {
+			return track.AlbumID, nil
+		}
+	}
+	return "", nil
+}
+
+func findSpotifyAlbumBySearch(ctx context.Context, client *SpotifyMetadataClient, album, artist string, localCount int) (string, error) {
+	query := strings.TrimSpace(artist + " " + album)
+	results, err := client.SearchByType(ctx, query, "album", 10, 0)
+	if err != nil {
+		return "", fmt.Errorf("album search failed: %w", err)
+	}
+
+	wantAlbum := normalizeForMatch(album)
+	wantArtist := normalizeForMatch(artist)
+
+	bestID := ""
+	bestScore := 0
+	for _, result := range results {
+		score := 0
+		name := normalizeForMatch(result.Name)
+		switch {
+		case name == wantAlbum:
+			score += 10
+		case strings.Contains(name, wantAlbum) || strings.Contains(wantAlbum, name):
+			score += 5
+		}
+		if wantArtist != "" && strings.Contains(normalizeForMatch(result.Artists), wantArtist) {
+			score += 5
+		}
+		if result.TotalTracks > 0 && result.TotalTracks >= localCount {
+			score++
+		}
+		if score > bestScore {
+			bestScore = score
+			bestID = result.ID
+		}
+	}
+
+	if bestID == "" || bestScore < 5 {
+		return "", fmt.Errorf("no matching Spotify album found for %q", query)
+	}
+
+	return bestID, nil
+}
+
+func matchAlbumTracks(report *AlbumCompletionReport, tracks []AlbumTrackMetadata, trackISRCs map[string]string) {
+	discPositions := make([]int, len(tracks))
+	counters := make(map[int]int)
+	for i, track := range tracks {
+		disc := track.DiscNumber
+		if disc == 0 {
+			disc = 1
+		}
+		counters[disc]++
+		discPositions[i] = counters[disc]
+	}
+
+	matched := make([]bool, len(tracks))
+	localMatched := make([]bool, len(report.LocalTracks))
+
+	for li, local := range report.LocalTracks {
+		isrc := strings.ToUpper(local.ISRC)
+		if !IsValidISRC(isrc) {
+			continue
+		}
+		for ti, track := range tracks {
+			if !matched[ti] && trackISRCs[track.SpotifyID] == isrc {
+				matched[ti] = true
+				localMatched[li] = true
+				report.LocalTracks[li].SpotifyID = track.SpotifyID
+				break
+			}
+		}
+	}
+
+	for li, local := range report.LocalTracks {
+		if localMatched[li] {
+			continue
+		}
+		title := normalizeForMatch(local.Title)
+		for ti, track := range tracks {
+			if matched[ti] {
+				continue
+			}
+			if title != "" && normalizeForMatch(track.Name) == title {
+				matched[ti] = true
+				localMatched[li] = true
+				report.LocalTracks[li].SpotifyID = track.SpotifyID
+				break
+			}
+		}
+	}
+
+	for li, local := range report.LocalTracks {
+		if localMatched[li] || local.TrackNumber == 0 {
+			continue
+		}
+		disc := local.DiscNumber
+		if disc == 0 {
+			disc = 1
+		}
+		for ti, track := range tracks {
+			if matched[ti] {
+				continue
+			}
+			trackDisc := track.DiscNumber
+			if trackDisc == 0 {
+				trackDisc = 1
+			}
+			if trackDisc == disc && discPositions[ti] == local.TrackNumber {
+				matched[ti] = true
+				localMatched[li] = true
+				report.LocalTracks[li].SpotifyID = track.SpotifyID
+				break
+			}
+		}
+	}
+
+	for ti, track := range tracks {
+		if !matched[ti] {
+			report.Missing = append(report.Missing, track)
+		}
+	}
+
+	for li, local := range report.LocalTracks {
+		if !localMatched[li] {
+			report.Unmatched = append(report.Unmatched, local)
+		}
+	}
+
+	sort.Slice(report.LocalTracks, func(i, j int) bool {
+		a, b := report.LocalTracks[i], report.LocalTracks[j]
+		if a.DiscNumber != b.DiscNumber {
+			return a.DiscNumber < b.DiscNumber
+		}
+		if a.TrackNumber != b.TrackNumber {
+			return a.TrackNumber < b.TrackNumber
+		}
+		return a.Path < b.Path
+	})
+}
+
+func mostVoted(votes map[string]int) string {
+	best := ""
+	bestCount := 0
+	for value, count := range votes {
+		if count > bestCount || (count == bestCount && value < best) {
+			best = value
+			bestCount = count
+		}
+	}
+	return best
+}
@@ -1,9 +1,14 @@
 package backend
 
 import (
+	"encoding/json"
 	"fmt"
 	"math"
 	"os"
+	"os/exec"
+	pathfilepath "path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/go-flac/go-flac"
 	mewflac "github.com/mewkiz/flac"
@@ -205,3 +210,133 @@ func GetTrackMetadata(filepath string) (*AnalysisResult, error) {
 	result.BitDepth = fmt.Sprintf("%d-bit", result.BitsPerSample)
 	return result, nil
 }
+
+type AudioQuality struct {
+	Format     string  `json:"format"`
+	Codec      string  `json:"codec"`
+	SampleRate int     `json:"sample_rate"`
+	BitDepth   int     `json:"bit_depth"`
+	Channels   int     `json:"channels"`
+	Bitrate    int     `json:"bitrate"`
+	Duration   float64 `json:"duration"`
+	Size       int64   `json:"size"`
+	Lossless   bool    `json:"lossless"`
+}
+
+func ProbeAudioQuality(filePath string) (*AudioQuality, error) {
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	ext := strings.ToLower(pathfilepath.Ext(filePath))
+	quality := &AudioQuality{
+		Format: strings.TrimPrefix(ext, "."),
+		Size:   fileInfo.Size(),
+	}
+
+	if ext == ".flac" {
+		if meta, err := GetTrackMetadata(filePath); err == nil && meta.SampleRate > 0 {
+			quality.Codec = "flac"
+			quality.SampleRate = int(meta.SampleRate)
+			quality.BitDepth = int(meta.BitsPerSample)
+			quality.Duration = meta.Duration
+			quality.Lossless = true
+			if stream, err := mewflac.ParseFile(filePath); err == nil {
+				quality.Channels = int(stream.Info.NChannels)
+				stream.Close()
+			}
+			if quality.Duration > 0 {
+				quality.Bitrate = int(float64(quality.Size*8) / quality.Duration)
+			}
+			return quality, nil
+		}
+	}
+
+	ffprobePath, err := GetFFprobePath()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateExecutable(ffprobePath); err != nil {
+		return nil, fmt.Errorf("invalid ffprobe executable: %w", err)
+	}
+
+	cmd := exec.Command(ffprobePath,
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		"-select_streams", "a:0",
+		filePath,
+	)
+
+	setHideWindow(cmd)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var result struct {
+		Format struct {
+			Duration string `json:"duration"`
+			BitRate  string `json:"bit_rate"`
+		} `json:"format"`
+		Streams []struct {
+			CodecName        string `json:"codec_name"`
+			SampleRate       string `json:"sample_rate"`
+			Channels         int    `json:"channels"`
+			BitRate          string `json:"bit_rate"`
+			BitsPerRawSample string `json:"bits_per_raw_sample"`
+			BitsPerSample    int    `json:"bits_per_sample"`
+		} `json:"streams"`
+	}
+
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	if len(result.Streams) == 0 {
+		return nil, fmt.Errorf("no audio stream found in %s", filePath)
+	}
+
+	stream := result.Streams[0]
+	quality.Codec = stream.CodecName
+	quality.Channels = stream.Channels
+	quality.SampleRate, _ = strconv.Atoi(stream.SampleRate)
+	quality.Duration, _ = strconv.ParseFloat(result.Format.Duration, 64)
+
+	switch stream.CodecName {
+	case "flac", "alac", "wavpack", "ape", "tta":
+		quality.Lossless = true
+	default:
+		quality.Lossless = strings.HasPrefix(stream.CodecName, "pcm_")
+	}
+
+	if quality.Lossless {
+		if bits, err := strconv.Atoi(stream.BitsPerRawSample); err == nil && bits > 0 {
+			quality.BitDepth = bits
+		} else {
+			quality.BitDepth = stream.BitsPerSample
+		}
+	}
+
+	if bitrate, err := strconv.Atoi(stream.BitRate); err == nil && bitrate > 0 {
+		quality.Bitrate = bitrate
+	} else if bitrate, err := strconv.Atoi(result.Format.BitRate); err == nil {
+		quality.Bitrate = bitrate
+	}
+
+	return quality, nil
+}
+
+func (q *AudioQuality) Label() string {
+	if q.Lossless && q.BitDepth > 0 {
+		return fmt.Sprintf("%d-bit/%.1fkHz", q.BitDepth, float64(q.SampleRate)/1000.0)
+	}
+	if q.Bitrate > 0 {
+		return fmt.Sprintf("%s %dkbps", strings.ToUpper(q.Format), q.Bitrate/1000)
+	}
+	return strings.ToUpper(q.Format)
+}
@@ -0,0 +1,298 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type ServiceAvailability struct {
+	Available    bool   `json:"available"`
+	URL          string `json:"url,omitempty"`
+	TrackID      int64  `json:"track_id,omitempty"`
+	BitDepth     int    `json:"bit_depth,omitempty"`
+	SampleRate   int    `json:"sample_rate,omitempty"`
+	AudioQuality string `json:"audio_quality,omitempty"`
+}
+
+type AvailabilityMatrixRow struct {
+	SpotifyID string              `json:"spotify_id"`
+	ISRC      string              `json:"isrc,omitempty"`
+	Name      string              `json:"name"`
+	Artists   string              `json:"artists"`
+	Qobuz     ServiceAvailability `json:"qobuz"`
+	Tidal     ServiceAvailability `json:"tidal"`
+	Amazon    ServiceAvailability `json:"amazon"`
+	Best      string              `json:"best,omitempty"`
+	Cached    bool                `json:"cached,omitempty"`
+	CheckedAt int64               `json:"checked_at"`
+	Error     string              `json:"error,omitempty"`
+}
+
+type AvailabilityMatrixSummary struct {
+	Total  int            `json:"total"`
+	Qobuz  int            `json:"qobuz"`
+	Tidal  int            `json:"tidal"`
+	Amazon int            `json:"amazon"`
+	None   int            `json:"none"`
+	Failed int            `json:"failed"`
+	Best   map[string]int `json:"best"`
+}
+
+type AvailabilityMatrix struct {
+	Rows    []AvailabilityMatrixRow   `json:"rows"`
+	Summary AvailabilityMatrixSummary `json:"summary"`
+}
+
+const (
+	availabilityCacheBucket = "AvailabilityCache"
+	availabilityCacheTTL    = 7 * 24 * time.Hour
+	availabilityRowTimeout  = 45 * time.Second
+)
+
+func (r *AvailabilityMatrixRow) chooseBest() {
+	r.Best = ""
+	bestDepth, bestRate := -1, -1
+	for _, candidate := range []struct {
+		name    string
+		service ServiceAvailability
+	}{
+		{"qobuz", r.Qobuz},
+		{"tidal", r.Tidal},
+		{"amazon", r.Amazon},
+	} {
+		if !candidate.service.Available {
+			continue
+		}
+		depth, rate := candidate.service.BitDepth, candidate.service.SampleRate
+		if depth > bestDepth || (depth == bestDepth && rate > bestRate) {
+			r.Best = candidate.name
+			bestDepth, bestRate = depth, rate
+		}
+	}
+}
+
+func (r *AvailabilityMatrixRow) bestService() ServiceAvailability {
+	switch r.Best {
+	case "qobuz":
+		return r.Qobuz
+	case "tidal":
+		return r.Tidal
+	case "amazon":
+		return r.Amazon
+	}
+	return ServiceAvailability{}
+}
+
+func getCachedAvailability(spotifyID string) *AvailabilityMatrixRow {
+	if historyDB == nil || spotifyID == "" {
+		return nil
+	}
+
+	var found *AvailabilityMatrixRow
+	historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(availabilityCacheBucket))
+		if b == nil {
+			return nil
+		}
+		v := b.Get([]byte(spotifyID))
+		if v == nil {
+			return nil
+		}
+		var row AvailabilityMatrixRow
+		if err := json.Unmarshal(v, &row); err == nil && time.Since(time.Unix(row.CheckedAt, 0)) < availabilityCacheTTL {
+			found = &row
+		}
+		return nil
+	})
+	return found
+}
+
+func storeCachedAvailability(row AvailabilityMatrixRow) {
+	if historyDB == nil || row.SpotifyID == "" || row.Error != "" {
+		return
+	}
+
+	row.Cached = false
+	buf, err := json.Marshal(row)
+	if err != nil {
+		return
+	}
+
+	err = historyDB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(availabilityCacheBucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(row.SpotifyID), buf)
+	})
+	if err != nil {
+		fmt.Printf("[Availability] Failed to cache %s: %v\n", row.SpotifyID, err)
+	}
+}
+
+func ClearAvailabilityCache(appName string) error {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return err
+		}
+	}
+
+	return historyDB.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(availabilityCacheBucket)) == nil {
+			return nil
+		}
+		return tx.DeleteBucket([]byte(availabilityCacheBucket))
+	})
+}
+
+func checkTrackMatrixRow(ctx context.Context, track AlbumTrackMetadata) AvailabilityMatrixRow {
+	row := AvailabilityMatrixRow{
+		SpotifyID: track.SpotifyID,
+		Name:      track.Name,
+		Artists:   track.Artists,
+		CheckedAt: time.Now().Unix(),
+	}
+	links, err := NewSongLinkClient().WithContext(ctx).spotifyTrackLinks(track.SpotifyID, "")
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+
+	if resolution, err := ResolveISRC(ctx, ISRCQuery{
+		SpotifyID:   track.SpotifyID,
+		TrackName:   track.Name,
+		ArtistName:  track.Artists,
+		AlbumName:   track.AlbumName,
+		DurationSec: track.DurationMS / 1000,
+		DeezerURL:   links["deezer"],
+		TidalURL:    links["tidal"],
+	}); err == nil {
+		row.ISRC = resolution.ISRC
+	}
+
+	if row.ISRC != "" {
+		if qobuzTrack, err := NewQobuzDownloader().SearchByISRC(row.ISRC); err == nil && strings.EqualFold(qobuzTrack.ISRC, row.ISRC) {
+			row.Qobuz = ServiceAvailability{
+				Available:  true,
+				TrackID:    qobuzTrack.ID,
+				BitDepth:   qobuzTrack.MaximumBitDepth,
+				SampleRate: int(qobuzTrack.MaximumSamplingRate * 1000),
+			}
+		}
+	}
+
+	if tidalURL := links["tidal"]; tidalURL != "" {
+		row.Tidal = ServiceAvailability{Available: true, URL: tidalURL}
+		tidal := NewTidalDownloader("")
+		if trackID, err := tidal.GetTrackIDFromURL(tidalURL); err == nil {
+			row.Tidal.TrackID = trackID
+			if quality, err := tidal.GetTrackQuality(trackID); err == nil {
+				row.Tidal.AudioQuality = quality.AudioQuality
+				row.Tidal.BitDepth = quality.BitDepth
+				row.Tidal.SampleRate = quality.SampleRate
+			}
+		}
+	}
+
+	if amazonURL := links["amazonMusic"]; amazonURL != "" {
+		row.Amazon = ServiceAvailability{Available: true, URL: amazonURL}
+	}
+
+	row.chooseBest()
+	return row
+}
+
+func BuildAvailabilityMatrix(ctx context.Context, tracks []AlbumTrackMetadata, refresh bool, onRow func(index int, row AvailabilityMatrixRow), appName string) (*AvailabilityMatrix, error) {
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("no tracks to check")
+	}
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			fmt.Printf("[Availability] Cache unavailable: %v\n", err)
+		}
+	}
+
+	matrix := &AvailabilityMatrix{
+		Rows:    make([]AvailabilityMatrixRow, len(tracks)),
+		Summary: AvailabilityMatrixSummary{Total: len(tracks), Best: make(map[string]int)},
+	}
+
+	var pending []int
+	cached := 0
+	for i, track := range tracks {
+		var row *AvailabilityMatrixRow
+		if track.SpotifyID == "" {
+			row = &AvailabilityMatrixRow{Name: track.Name, Artists: track.Artists, Error: "spotify track ID is required"}
+		} else if !refresh {
+			row = getCachedAvailability(track.SpotifyID)
+			if row != nil {
+				row.Cached = true
+				cached++
+			}
+		}
+		if row == nil {
+			pending = append(pending, i)
+			continue
+		}
+		matrix.Rows[i] = *row
+		if onRow != nil {
+			onRow(i, *row)
+		}
+	}
+
+	if len(pending) > 0 {
+		fmt.Printf("[Availability] %d cached, checking %d tracks\n", cached, len(pending))
+	}
+
+	for _, i := range pending {
+		var row AvailabilityMatrixRow
+		if err := ctx.Err(); err != nil {
+			row = AvailabilityMatrixRow{
+				SpotifyID: tracks[i].SpotifyID,
+				Name:      tracks[i].Name,
+				Artists:   tracks[i].Artists,
+				Error:     err.Error(),
+			}
+		} else {
+			rowCtx, cancel := context.WithTimeout(ctx, availabilityRowTimeout)
+			row = checkTrackMatrixRow(rowCtx, tracks[i])
+			if err := rowCtx.Err(); err != nil && row.Error == "" {
+				row.Error = err.Error()
+			}
+			cancel()
+			storeCachedAvailability(row)
+		}
+		matrix.Rows[i] = row
+		if onRow != nil {
+			onRow(i, row)
+		}
+	}
+
+	for _, row := range matrix.Rows {
+		switch {
+		case row.Error != "":
+			matrix.Summary.Failed++
+			continue
+		case row.Best == "":
+			matrix.Summary.None++
+			continue
+		}
+		if row.Qobuz.Available {
+			matrix.Summary.Qobuz++
+		}
+		if row.Tidal.Available {
+			matrix.Summary.Tidal++
+		}
+		if row.Amazon.Available {
+			matrix.Summary.Amazon++
+		}
+		matrix.Summary.Best[row.Best]++
+	}
+
+	return matrix, nil
+}
@@ -0,0 +1,813 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CatalogTrack struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Version     string   `json:"version,omitempty"`
+	Artist      string   `json:"artist"`
+	Artists     []string `json:"artists,omitempty"`
+	Album       string   `json:"album"`
+	AlbumArtist string   `json:"album_artist,omitempty"`
+	ISRC        string   `json:"isrc,omitempty"`
+	TrackNumber int      `json:"track_number"`
+	DiscNumber  int      `json:"disc_number"`
+	DurationMS  int      `json:"duration_ms"`
+	Explicit    bool     `json:"explicit,omitempty"`
+	BitDepth    int      `json:"bit_depth,omitempty"`
+	SampleRate  float64  `json:"sample_rate,omitempty"`
+	HiRes       bool     `json:"hires,omitempty"`
+	Quality     string   `json:"quality,omitempty"`
+	CoverURL    string   `json:"cover_url,omitempty"`
+	ReleaseDate string   `json:"release_date,omitempty"`
+	URL         string   `json:"url,omitempty"`
+}
+
+type CatalogCollection struct {
+	Service     string         `json:"service"`
+	Type        string         `json:"type"`
+	ID          string         `json:"id"`
+	Title       string         `json:"title"`
+	Artist      string         `json:"artist,omitempty"`
+	CoverURL    string         `json:"cover_url,omitempty"`
+	ReleaseDate string         `json:"release_date,omitempty"`
+	Label       string         `json:"label,omitempty"`
+	UPC         string         `json:"upc,omitempty"`
+	Copyright   string         `json:"copyright,omitempty"`
+	HiRes       bool           `json:"hires,omitempty"`
+	TotalDiscs  int            `json:"total_discs,omitempty"`
+	Tracks      []CatalogTrack `json:"tracks"`
+}
+
+type CatalogLink struct {
+	Service string `json:"service"`
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+}
+
+var qobuzLinkPattern = regexp.MustCompile(`qobuz\.com/(?:[a-z]{2}-[a-z]{2}/)?(album|playlist|track)/(?:[^/?#]+/)?([A-Za-z0-9]+)`)
+
+var tidalLinkPattern = regexp.MustCompile(`tidal\.com/(?:browse/)?(album|playlist|track)/([A-Za-z0-9-]+)`)
+
+func ParseCatalogLink(link string) (CatalogLink, bool) {
+	if m := qobuzLinkPattern.FindStringSubmatch(link); m != nil {
+		return CatalogLink{Service: "qobuz", Type: m[1], ID: m[2]}, true
+	}
+	if m := tidalLinkPattern.FindStringSubmatch(link); m != nil {
+		return CatalogLink{Service: "tidal", Type: m[1], ID: m[2]}, true
+	}
+	return CatalogLink{}, false
+}
+
+type qobuzAlbumResponse struct {
+	ID                  string  `json:"id"`
+	Title               string  `json:"title"`
+	Version             string  `json:"version"`
+	ReleaseDateOriginal string  `json:"release_date_original"`
+	UPC                 string  `json:"upc"`
+	Copyright           string  `json:"copyright"`
+	Hires               bool    `json:"hires"`
+	MaximumBitDepth     int     `json:"maximum_bit_depth"`
+	MaximumSamplingRate float64 `json:"maximum_sampling_rate"`
+	MediaCount          int     `json:"media_count"`
+	Artist              struct {
+		Name string `json:"name"`
+	} `json:"artist"`
+	Image struct {
+		Large string `json:"large"`
+	} `json:"image"`
+	Label struct {
+		Name string `json:"name"`
+	} `json:"label"`
+	Tracks struct {
+		Items []QobuzTrack `json:"items"`
+	} `json:"tracks"`
+}
+
+type qobuzPlaylistResponse struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Owner struct {
+		Name string `json:"name"`
+	} `json:"owner"`
+	ImageRectangle []string `json:"image_rectangle"`
+	Tracks         struct {
+		Total int          `json:"total"`
+		Items []QobuzTrack `json:"items"`
+	} `json:"tracks"`
+}
+
+func (q *QobuzDownloader) getCatalogJSON(endpoint string, params url.Values, target interface{}) error {
+	params.Set("app_id", q.appID)
+	apiURL := fmt.Sprintf("https://www.qobuz.com/api.json/0.2/%s?%s", endpoint, params.Encode())
+
+	resp, err := q.client.Get(apiURL)
+	if err != nil {
+		return fmt.Errorf("failed to call Qobuz API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("Qobuz API returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if err := json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("failed to decode Qobuz response: %w", err)
+	}
+	return nil
+}
+
+func qobuzCatalogTrack(track QobuzTrack) CatalogTrack {
+	title := track.Title
+	if track.Version != "" && !strings.Contains(strings.ToLower(title), strings.ToLower(track.Version)) {
+		title = fmt.Sprintf("%s (%s)", title, track.Version)
+	}
+
+	disc := track.MediaNumber
+	if disc == 0 {
+		disc = 1
+	}
+
+	return CatalogTrack{
+		ID:          strconv.FormatInt(track.ID, 10),
+		Title:       title,
+		Version:     track.Version,
+		Artist:      track.Performer.Name,
+		Album:       track.Album.Title,
+		AlbumArtist: track.Album.Artist.Name,
+		ISRC:        strings.ToUpper(track.ISRC),
+		TrackNumber: track.TrackNumber,
+		DiscNumber:  disc,
+		DurationMS:  track.Duration * 1000,
+		BitDepth:    track.MaximumBitDepth,
+		SampleRate:  track.MaximumSamplingRate,
+		HiRes:       track.Hires || track.HiresStreamable,
+		CoverURL:    track.Album.Image.Large,
+		ReleaseDate: track.ReleaseDateOriginal,
+		URL:         fmt.Sprintf("https://open.qobuz.com/track/%d", track.ID),
+	}
+}
+
+func (q *QobuzDownloader) GetAlbum(albumID string) (*CatalogCollection, error) {
+	var album qobuzAlbumResponse
+	if err := q.getCatalogJSON("album/get", url.Values{"album_id": {albumID}}, &album); err != nil {
+		return nil, err
+	}
+	if album.Title == "" {
+		return nil, fmt.Errorf("qobuz album not found: %s", albumID)
+	}
+
+	collection := qobuzCatalogAlbum(album)
+
+	for _, item := range album.Tracks.Items {
+		item.Album.Title = collection.Title
+		item.Album.Artist.Name = album.Artist.Name
+		item.Album.Image.Large = album.Image.Large
+		item.Album.Label.Name = album.Label.Name
+		item.Album.UPC = album.UPC
+		if item.ReleaseDateOriginal == "" {
+			item.ReleaseDateOriginal = album.ReleaseDateOriginal
+		}
+		collection.Tracks = append(collection.Tracks, qobuzCatalogTrack(item))
+	}
+
+	return &collection, nil
+}
+
+func (q *QobuzDownloader) GetPlaylist(playlistID string) (*CatalogCollection, error) {
+	collection := &CatalogCollection{Service: "qobuz", Type: "playlist", ID: playlistID}
+
+	const pageSize = 500
+	for offset := 0; ; offset += pageSize {
+		var playlist qobuzPlaylistResponse
+		params := url.Values{
+			"playlist_id": {playlistID},
+			"extra":       {"tracks"},
+			"limit":       {strconv.Itoa(pageSize)},
+			"offset":      {strconv.Itoa(offset)},
+		}
+		if err := q.getCatalogJSON("playlist/get", params, &playlist); err != nil {
+			return nil, err
+		}
+
+		if offset == 0 {
+			collection.Title = playlist.Name
+			collection.Artist = playlist.Owner.Name
+			if len(playlist.ImageRectangle) > 0 {
+				collection.CoverURL = playlist.ImageRectangle[0]
+			}
+		}
+		for _, item := range playlist.Tracks.Items {
+			collection.Tracks = append(collection.Tracks, qobuzCatalogTrack(item))
+		}
+
+		if len(playlist.Tracks.Items) < pageSize || len(collection.Tracks) >= playlist.Tracks.Total {
+			break
+		}
+	}
+
+	if collection.Title == "" && len(collection.Tracks) == 0 {
+		return nil, fmt.Errorf("qobuz playlist not found: %s", playlistID)
+	}
+	return collection, nil
+}
+
+type tidalCatalogTrack struct {
+	ID           int64  `json:"id"`
+	Title        string `json:"title"`
+	Version      string `json:"version"`
+	Duration     int    `json:"duration"`
+	TrackNumber  int    `json:"trackNumber"`
+	VolumeNumber int    `json:"volumeNumber"`
+	ISRC         string `json:"isrc"`
+	Explicit     bool   `json:"explicit"`
+	AudioQuality string `json:"audioQuality"`
+	Copyright    string `json:"copyright"`
+	URL          string `json:"url"`
+	Artist       struct {
+		Name string `json:"name"`
+	} `json:"artist"`
+	Artists []struct {
+		Name string `json:"name"`
+	} `json:"artists"`
+	Album struct {
+		ID    int64  `json:"id"`
+		Title string `json:"title"`
+		Cover string `json:"cover"`
+	} `json:"album"`
+	MediaMetadata struct {
+		Tags []string `json:"tags"`
+	} `json:"mediaMetadata"`
+}
+
+type tidalCatalogInfo struct {
+	ID              json.Number `json:"id"`
+	UUID            string      `json:"uuid"`
+	Title           string      `json:"title"`
+	Cover           string      `json:"cover"`
+	SquareImage     string      `json:"squareImage"`
+	ReleaseDate     string      `json:"releaseDate"`
+	UPC             string      `json:"upc"`
+	Copyright       string      `json:"copyright"`
+	NumberOfTracks  int         `json:"numberOfTracks"`
+	NumberOfVolumes int         `json:"numberOfVolumes"`
+	AudioQuality    string      `json:"audioQuality"`
+	Artist          struct {
+		Name string `json:"name"`
+	} `json:"artist"`
+	Creator struct {
+		Name string `json:"name"`
+	} `json:"creator"`
+}
+
+func tidalImageURL(id string) string {
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf("https://resources.tidal.com/images/%s/1280x1280.jpg", strings.ReplaceAll(id, "-", "/"))
+}
+
+func (t *TidalDownloader) getCatalogJSON(path string) (interface{}, error) {
+	apis, err := t.GetAvailableAPIs()
+	if err != nil {
+		return nil, err
+	}
+	if t.apiURL != "" {
+		apis = append([]string{t.apiURL}, apis...)
+	}
+
+	client := &http.Client{Timeout: 20 * time.Second}
+
+	var lastError error
+	tried := make(map[string]bool)
+	for _, apiURL := range apis {
+		if tried[apiURL] {
+			continue
+		}
+		tried[apiURL] = true
+
+		resp, err := client.Get(apiURL + path)
+		if err != nil {
+			lastError = err
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastError = err
+			continue
+		}
+		if resp.StatusCode != 200 {
+			lastError = fmt.Errorf("HTTP %d", resp.StatusCode)
+			continue
+		}
+
+		var data interface{}
+		decoder := json.NewDecoder(strings.NewReader(string(body)))
+		decoder.UseNumber()
+		if err := decoder.Decode(&data); err != nil {
+			lastError = err
+			continue
+		}
+		return data, nil
+	}
+
+	return nil, fmt.Errorf("tidal catalog request failed: %v", lastError)
+}
+
+func findTidalInfo(data interface{}) *tidalCatalogInfo {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if _, hasTitle := v["title"]; hasTitle {
+			if _, isTrack := v["trackNumber"]; !isTrack {
+				var info tidalCatalogInfo
+				if remarshal(v, &info) == nil {
+					return &info
+				}
+			}
+		}
+		for _, key := range []string{"data", "album", "playlist"} {
+			if child, ok := v[key]; ok {
+				if info := findTidalInfo(child); info != nil {
+					return info
+				}
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if info := findTidalInfo(child); info != nil {
+				return info
+			}
+		}
+	}
+	return nil
+}
+
+func findTidalTracks(data interface{}) []tidalCatalogTrack {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if items, ok := v["items"].([]interface{}); ok {
+			var tracks []tidalCatalogTrack
+			for _, item := range items {
+				entry, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if inner, ok := entry["item"].(map[string]interface{}); ok {
+					if kind, _ := entry["type"].(string); kind != "" && kind != "track" {
+						continue
+					}
+					entry = inner
+				}
+				var track tidalCatalogTrack
+				if remarshal(entry, &track) == nil && track.ID != 0 {
+					tracks = append(tracks, track)
+				}
+			}
+			if len(tracks) > 0 {
+				return tracks
+			}
+		}
+		for _, child := range v {
+			if tracks := findTidalTracks(child); len(tracks) > 0 {
+				return tracks
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if tracks := findTidalTracks(child); len(tracks) > 0 {
+				return tracks
+			}
+		}
+	}
+	return nil
+}
+
+func findTidalPage(data interface{}) (int, int, bool) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if items, ok := v["items"].([]interface{}); ok {
+			total := len(items)
+			if n, ok := v["totalNumberOfItems"].(json.Number); ok {
+				if parsed, err := n.Int64(); err == nil {
+					total = int(parsed)
+				}
+			}
+			return len(items), total, true
+		}
+		for _, child := range v {
+			if count, total, ok := findTidalPage(child); ok {
+				return count, total, true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if count, total, ok := findTidalPage(child); ok {
+				return count, total, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func (t *TidalDownloader) getCatalogTracks(path string) (interface{}, []tidalCatalogTrack, error) {
+	const pageSize = 100
+
+	data, err := t.getCatalogJSON(fmt.Sprintf("%s&limit=%d&offset=0", path, pageSize))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tracks := findTidalTracks(data)
+	offset, total, _ := findTidalPage(data)
+	if info := findTidalInfo(data); info != nil && info.NumberOfTracks > total {
+		total = info.NumberOfTracks
+	}
+
+	seen := make(map[int64]bool)
+	for _, track := range tracks {
+		seen[track.ID] = true
+	}
+
+	for offset > 0 && offset < total {
+		page, err := t.getCatalogJSON(fmt.Sprintf("%s&limit=%d&offset=%d", path, pageSize, offset))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch tracks %d-%d of %d: %w", offset+1, offset+pageSize, total, err)
+		}
+		count, _, _ := findTidalPage(page)
+		if count == 0 {
+			break
+		}
+		for _, track := range findTidalTracks(page) {
+			if !seen[track.ID] {
+				seen[track.ID] = true
+				tracks = append(tracks, track)
+			}
+		}
+		offset += count
+	}
+
+	return data, tracks, nil
+}
+
+func remarshal(source interface{}, target interface{}) error {
+	data, err := json.Marshal(source)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+func tidalCatalogTrackFrom(track tidalCatalogTrack, albumArtist, releaseDate string) CatalogTrack {
+	title := track.Title
+	if track.Version != "" && !strings.Contains(strings.ToLower(title), strings.ToLower(track.Version)) {
+		title = fmt.Sprintf("%s (%s)", title, track.Version)
+	}
+
+	var artists []string
+	for _, artist := range track.Artists {
+		artists = append(artists, artist.Name)
+	}
+	artist := track.Artist.Name
+	if len(artists) > 0 {
+		artist = strings.Join(artists, ", ")
+	}
+
+	disc := track.VolumeNumber
+	if disc == 0 {
+		disc = 1
+	}
+
+	hires := track.AudioQuality == "HI_RES_LOSSLESS" || track.AudioQuality == "HI_RES"
+	for _, tag := range track.MediaMetadata.Tags {
+		if tag == "HIRES_LOSSLESS" {
+			hires = true
+		}
+	}
+
+	return CatalogTrack{
+		ID:          strconv.FormatInt(track.ID, 10),
+		Title:       title,
+		Version:     track.Version,
+		Artist:      artist,
+		Artists:     artists,
+		Album:       track.Album.Title,
+		AlbumArtist: albumArtist,
+		ISRC:        strings.ToUpper(track.ISRC),
+		TrackNumber: track.TrackNumber,
+		DiscNumber:  disc,
+		DurationMS:  track.Duration * 1000,
+		Explicit:    track.Explicit,
+		HiRes:       hires,
+		Quality:     track.AudioQuality,
+		CoverURL:    tidalImageURL(track.Album.Cover),
+		ReleaseDate: releaseDate,
+		URL:         fmt.Sprintf("https://tidal.com/browse/track/%d", track.ID),
+	}
+}
+
+func (t *TidalDownloader) GetAlbum(albumID string) (*CatalogCollection, error) {
+	data, tracks, err := t.getCatalogTracks("/album/?id=" + url.QueryEscape(albumID))
+	if err != nil {
+		return nil, err
+	}
+
+	collection := &CatalogCollection{Service: "tidal", Type: "album", ID: albumID}
+	if info := findTidalInfo(data); info != nil {
+		collection.Title = info.Title
+		collection.Artist = info.Artist.Name
+		collection.CoverURL = tidalImageURL(info.Cover)
+		collection.ReleaseDate = info.ReleaseDate
+		collection.UPC = info.UPC
+		collection.Copyright = info.Copyright
+		collection.HiRes = info.AudioQuality == "HI_RES_LOSSLESS" || info.AudioQuality == "HI_RES"
+		collection.TotalDiscs = info.NumberOfVolumes
+	}
+
+	for _, track := range tracks {
+		entry := tidalCatalogTrackFrom(track, collection.Artist, collection.ReleaseDate)
+		if entry.CoverURL == "" {
+			entry.CoverURL = collection.CoverURL
+		}
+		if entry.Album == "" {
+			entry.Album = collection.Title
+		}
+		collection.Tracks = append(collection.Tracks, entry)
+	}
+
+	if len(collection.Tracks) == 0 {
+		return nil, fmt.Errorf("tidal album has no tracks: %s", albumID)
+	}
+	return collection, nil
+}
+
+func (t *TidalDownloader) GetPlaylist(playlistID string) (*CatalogCollection, error) {
+	data, tracks, err := t.getCatalogTracks("/playlist/?id=" + url.QueryEscape(playlistID))
+	if err != nil {
+		return nil, err
+	}
+
+	collection := &CatalogCollection{Service: "tidal", Type: "playlist", ID: playlistID}
+	if info := findTidalInfo(data); info != nil {
+		collection.Title = info.Title
+		collection.Artist = info.Creator.Name
+		collection.CoverURL = tidalImageURL(info.SquareImage)
+	}
+
+	for _, track := range tracks {
+		collection.Tracks = append(collection.Tracks, tidalCatalogTrackFrom(track, "", ""))
+	}
+
+	if len(collection.Tracks) == 0 {
+		return nil, fmt.Errorf("tidal playlist has no tracks: %s", playlistID)
+	}
+	return collection, nil
+}
+
+func findTidalISRC(data interface{}) string {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if isrc, ok := v["isrc"].(string); ok && isrc != "" {
+			return isrc
+		}
+		for _, key := range []string{"data", "track", "item"} {
+			if child, ok := v[key]; ok {
+				if isrc := findTidalISRC(child); isrc != "" {
+					return isrc
+				}
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if isrc := findTidalISRC(child); isrc != "" {
+				return isrc
+			}
+		}
+	}
+	return ""
+}
+
+func (t *TidalDownloader) GetTrackISRC(trackID int64) (string, error) {
+	data, err := t.getCatalogJSON(fmt.Sprintf("/info/?id=%d", trackID))
+	if err != nil {
+		return "", err
+	}
+	isrc := findTidalISRC(data)
+	if isrc == "" {
+		return "", fmt.Errorf("tidal track %d has no ISRC", trackID)
+	}
+	return isrc, nil
+}
+
+type CatalogSearchResult struct {
+	Service string              `json:"service"`
+	Query   string              `json:"query"`
+	Type    string              `json:"type"`
+	Tracks  []CatalogTrack      `json:"tracks,omitempty"`
+	Albums  []CatalogCollection `json:"albums,omitempty"`
+}
+
+func qobuzCatalogAlbum(album qobuzAlbumResponse) CatalogCollection {
+	title := album.Title
+	if album.Version != "" {
+		title = fmt.Sprintf("%s (%s)", album.Title, album.Version)
+	}
+	return CatalogCollection{
+		Service:     "qobuz",
+		Type:        "album",
+		ID:          album.ID,
+		Title:       title,
+		Artist:      album.Artist.Name,
+		CoverURL:    album.Image.Large,
+		ReleaseDate: album.ReleaseDateOriginal,
+		Label:       album.Label.Name,
+		UPC:         album.UPC,
+		Copyright:   album.Copyright,
+		HiRes:       album.Hires,
+		TotalDiscs:  album.MediaCount,
+	}
+}
+
+func (q *QobuzDownloader) GetTrack(trackID int64) (*QobuzTrack, error) {
+	var track QobuzTrack
+	if err := q.getCatalogJSON("track/get", url.Values{"track_id": {strconv.FormatInt(trackID, 10)}}, &track); err != nil {
+		return nil, err
+	}
+	if track.ID == 0 {
+		return nil, fmt.Errorf("qobuz track not found: %d", trackID)
+	}
+	return &track, nil
+}
+
+func (q *QobuzDownloader) SearchCatalog(query, searchType string, limit, offset int) (*CatalogSearchResult, error) {
+	params := url.Values{
+		"query":  {query},
+		"limit":  {strconv.Itoa(limit)},
+		"offset": {strconv.Itoa(offset)},
+	}
+	result := &CatalogSearchResult{Service: "qobuz", Query: query, Type: searchType}
+
+	if searchType == "album" {
+		var resp struct {
+			Albums struct {
+				Items []qobuzAlbumResponse `json:"items"`
+			} `json:"albums"`
+		}
+		if err := q.getCatalogJSON("album/search", params, &resp); err != nil {
+			return nil, err
+		}
+		for _, album := range resp.Albums.Items {
+			result.Albums = append(result.Albums, qobuzCatalogAlbum(album))
+		}
+		return result, nil
+	}
+
+	var resp QobuzSearchResponse
+	if err := q.getCatalogJSON("track/search", params, &resp); err != nil {
+		return nil, err
+	}
+	for _, track := range resp.Tracks.Items {
+		result.Tracks = append(result.Tracks, qobuzCatalogTrack(track))
+	}
+	return result, nil
+}
+
+func findTidalAlbums(data interface{}) []tidalCatalogInfo {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		if items, ok := v["items"].([]interface{}); ok {
+			var albums []tidalCatalogInfo
+			for _, item := range items {
+				entry, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if _, isAlbum := entry["numberOfTracks"]; !isAlbum {
+					continue
+				}
+				var album tidalCatalogInfo
+				if remarshal(entry, &album) == nil {
+					albums = append(albums, album)
+				}
+			}
+			if len(albums) > 0 {
+				return albums
+			}
+		}
+		for _, child := range v {
+			if albums := findTidalAlbums(child); len(albums) > 0 {
+				return albums
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if albums := findTidalAlbums(child); len(albums) > 0 {
+				return albums
+			}
+		}
+	}
+	return nil
+}
+
+func (t *TidalDownloader) SearchCatalog(query, searchType string, limit, offset int) (*CatalogSearchResult, error) {
+	key := "s"
+	if searchType == "album" {
+		key = "al"
+	}
+	params := url.Values{
+		key:      {query},
+		"limit":  {strconv.Itoa(limit)},
+		"offset": {strconv.Itoa(offset)},
+	}
+
+	data, err := t.getCatalogJSON("/search/?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CatalogSearchResult{Service: "tidal", Query: query, Type: searchType}
+	if searchType == "album" {
+		for _, album := range findTidalAlbums(data) {
+			result.Albums = append(result.Albums, CatalogCollection{
+				Service:     "tidal",
+				Type:        "album",
+				ID:          album.ID.String(),
+				Title:       album.Title,
+				Artist:      album.Artist.Name,
+				CoverURL:    tidalImageURL(album.Cover),
+				ReleaseDate: album.ReleaseDate,
+				UPC:         album.UPC,
+				Copyright:   album.Copyright,
+				HiRes:       album.AudioQuality == "HI_RES_LOSSLESS" || album.AudioQuality == "HI_RES",
+				TotalDiscs:  album.NumberOfVolumes,
+			})
+		}
+		return result, nil
+	}
+
+	for _, track := range findTidalTracks(data) {
+		result.Tracks = append(result.Tracks, tidalCatalogTrackFrom(track, "", ""))
+	}
+	return result, nil
+}
+
+func SearchCatalog(service, query, searchType string, limit, offset int) (*CatalogSearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("search query cannot be empty")
+	}
+	if searchType != "album" {
+		searchType = "track"
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 25
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	switch service {
+	case "qobuz":
+		return NewQobuzDownloader().SearchCatalog(query, searchType, limit, offset)
+	case "tidal":
+		return NewTidalDownloader("").SearchCatalog(query, searchType, limit, offset)
+	}
+	return nil, fmt.Errorf("unsupported catalog service: %s", service)
+}
+
+func GetCatalogCollection(service, collectionType, id string) (*CatalogCollection, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%s id is required", collectionType)
+	}
+
+	switch service {
+	case "qobuz":
+		downloader := NewQobuzDownloader()
+		if collectionType == "playlist" {
+			return downloader.GetPlaylist(id)
+		}
+		return downloader.GetAlbum(id)
+	case "tidal":
+		downloader := NewTidalDownloader("")
+		if collectionType == "playlist" {
+			return downloader.GetPlaylist(id)
+		}
+		return downloader.GetAlbum(id)
+	}
+	return nil, fmt.Errorf("unsupported catalog service: %s", service)
+}
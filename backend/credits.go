@@ -0,0 +1,277 @@
+package backend
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type TrackCredits struct {
+	ISRC       string   `json:"isrc,omitempty"`
+	Genres     []string `json:"genres,omitempty"`
+	Composers  []string `json:"composers,omitempty"`
+	Lyricists  []string `json:"lyricists,omitempty"`
+	Producers  []string `json:"producers,omitempty"`
+	Performers []string `json:"performers,omitempty"`
+	Source     string   `json:"source,omitempty"`
+}
+
+var qobuzIgnoredRoles = map[string]bool{
+	"mainartist":          true,
+	"featuredartist":      true,
+	"associatedperformer": true,
+	"label":               true,
+	"publisher":           true,
+	"musicpublisher":      true,
+	"distributor":         true,
+	"studiopersonnel":     true,
+	"engineer":            true,
+	"recordingengineer":   true,
+	"masteringengineer":   true,
+	"mixingengineer":      true,
+	"soundengineer":       true,
+	"mixer":               true,
+	"programmer":          true,
+}
+
+func QobuzTrackCredits(track *QobuzTrack) *TrackCredits {
+	credits := &TrackCredits{Source: "qobuz"}
+	if track == nil {
+		return credits
+	}
+
+	credits.ISRC = strings.ToUpper(strings.TrimSpace(track.ISRC))
+	if genre := strings.TrimSpace(track.Album.Genre.Name); genre != "" {
+		credits.Genres = []string{genre}
+	}
+
+	parseQobuzPerformers(track.Performers, credits)
+
+	if composer := strings.TrimSpace(track.Composer.Name); composer != "" {
+		credits.Composers = appendUniqueFold(credits.Composers, composer)
+	}
+
+	return credits
+}
+
+func parseQobuzPerformers(performers string, credits *TrackCredits) {
+	for _, entry := range strings.Split(performers, " - ") {
+		parts := strings.Split(entry, ",")
+		name := strings.TrimSpace(parts[0])
+		if name == "" || len(parts) < 2 {
+			continue
+		}
+		credits.addRoles(name, parts[1:])
+	}
+}
+
+func (c *TrackCredits) addRoles(name string, roles []string) {
+	var performerRoles []string
+	for _, part := range roles {
+		role := strings.TrimSpace(part)
+		key := strings.ToLower(strings.ReplaceAll(role, " ", ""))
+		switch {
+		case key == "":
+			continue
+		case strings.Contains(key, "composer"):
+			c.Composers = appendUniqueFold(c.Composers, name)
+			if strings.Contains(key, "lyricist") {
+				c.Lyricists = appendUniqueFold(c.Lyricists, name)
+			}
+		case strings.Contains(key, "lyricist"), key == "writer", key == "author", key == "librettist":
+			c.Lyricists = appendUniqueFold(c.Lyricists, name)
+		case strings.Contains(key, "producer"):
+			c.Producers = appendUniqueFold(c.Producers, name)
+		case qobuzIgnoredRoles[key], strings.HasSuffix(key, "engineer"):
+			continue
+		default:
+			performerRoles = append(performerRoles, strings.ToLower(role))
+		}
+	}
+
+	if len(performerRoles) > 0 {
+		c.Performers = appendUniqueFold(c.Performers, fmt.Sprintf("%s (%s)", name, strings.Join(performerRoles, ", ")))
+	}
+}
+
+type spotifyCreditsResponse struct {
+	RoleCredits []struct {
+		RoleTitle string `json:"roleTitle"`
+		Artists   []struct {
+			Name     string   `json:"name"`
+			Subroles []string `json:"subroles"`
+		} `json:"artists"`
+	} `json:"roleCredits"`
+}
+
+var spotifyCreditRoleDefaults = map[string]string{
+	"writers":   "writer",
+	"composers": "composer",
+	"lyricists": "lyricist",
+	"producers": "producer",
+}
+
+func SpotifyTrackCredits(spotifyID string) (*TrackCredits, error) {
+	if spotifyID == "" {
+		return nil, fmt.Errorf("spotify track ID is required")
+	}
+
+	var resp spotifyCreditsResponse
+	endpoint := fmt.Sprintf("https://spclient.wg.spotify.com/track-credits-view/v0/experimental/%s/credits", url.PathEscape(spotifyID))
+	if err := NewSpotifyClient().Get(endpoint, &resp); err != nil {
+		return nil, err
+	}
+
+	credits := &TrackCredits{Source: "spotify"}
+	for _, group := range resp.RoleCredits {
+		for _, artist := range group.Artists {
+			name := strings.TrimSpace(artist.Name)
+			if name == "" {
+				continue
+			}
+			roles := artist.Subroles
+			if len(roles) == 0 {
+				if role, ok := spotifyCreditRoleDefaults[strings.ToLower(strings.TrimSpace(group.RoleTitle))]; ok {
+					roles = []string{role}
+				}
+			}
+			credits.addRoles(name, roles)
+		}
+	}
+	return credits, nil
+}
+
+func appendUniqueFold(values []string, value string) []string {
+	for _, existing := range values {
+		if strings.EqualFold(existing, value) {
+			return values
+		}
+	}
+	return append(values, value)
+}
+
+func (c *TrackCredits) ApplyTo(metadata *Metadata) {
+	if c == nil || metadata == nil {
+		return
+	}
+	if metadata.ISRC == "" {
+		metadata.ISRC = c.ISRC
+	}
+	if len(metadata.Genres) == 0 {
+		metadata.Genres = c.Genres
+	}
+	if len(metadata.Composers) == 0 {
+		metadata.Composers = c.Composers
+	}
+	if len(metadata.Lyricists) == 0 {
+		metadata.Lyricists = c.Lyricists
+	}
+	if len(metadata.Producers) == 0 {
+		metadata.Producers = c.Producers
+	}
+	if len(metadata.Performers) == 0 {
+		metadata.Performers = c.Performers
+	}
+}
+
+func (c *TrackCredits) Merge(other *TrackCredits) {
+	if c == nil || other == nil {
+		return
+	}
+	if c.ISRC == "" {
+		c.ISRC = other.ISRC
+	}
+	mergeValues := func(values, extra []string) []string {
+		for _, value := range extra {
+			values = appendUniqueFold(values, value)
+		}
+		return values
+	}
+	c.Genres = mergeValues(c.Genres, other.Genres)
+	if len(c.Composers) == 0 {
+		c.Composers = other.Composers
+	}
+	if len(c.Lyricists) == 0 {
+		c.Lyricists = other.Lyricists
+	}
+	if len(c.Producers) == 0 {
+		c.Producers = other.Producers
+	}
+	if len(c.Performers) == 0 {
+		c.Performers = other.Performers
+	}
+	switch {
+	case c.Source == "":
+		c.Source = other.Source
+	case other.Source != "" && other.Source != c.Source:
+		c.Source += "+" + other.Source
+	}
+}
+
+func (c *TrackCredits) IsEmpty() bool {
+	return c == nil || (c.ISRC == "" && len(c.Genres) == 0 && len(c.Composers) == 0 && len(c.Lyricists) == 0 &&
+		len(c.Producers) == 0 && len(c.Performers) == 0)
+}
+
+func FetchTrackCredits(spotifyID, isrc string) (*TrackCredits, error) {
+	isrc = strings.ToUpper(strings.TrimSpace(isrc))
+	if spotifyID == "" && !IsValidISRC(isrc) {
+		return nil, fmt.Errorf("invalid ISRC: %s", isrc)
+	}
+
+	credits := &TrackCredits{}
+	var errs []string
+	if spotifyID != "" {
+		spotifyCredits, err := SpotifyTrackCredits(spotifyID)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("spotify: %v", err))
+		} else {
+			credits.Merge(spotifyCredits)
+		}
+	}
+
+	if IsValidISRC(isrc) {
+		track, err := NewQobuzDownloader().SearchByISRC(isrc)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("qobuz: %v", err))
+		} else {
+			credits.Merge(QobuzTrackCredits(track))
+		}
+		if credits.ISRC == "" {
+			credits.ISRC = isrc
+		}
+	}
+
+	if credits.Source == "" && len(errs) > 0 {
+		return nil, fmt.Errorf("credits lookup failed (%s)", strings.Join(errs, "; "))
+	}
+	return credits, nil
+}
+
+func EmbedTrackCredits(filePath string, credits *TrackCredits) error {
+	if credits.IsEmpty() {
+		return nil
+	}
+
+	tags, err := ReadAllTags(filePath)
+	if err != nil {
+		return err
+	}
+
+	setIfMissing := func(field string, values []string) {
+		if len(values) > 0 && len(tags[field]) == 0 {
+			tags[field] = values
+		}
+	}
+
+	if credits.ISRC != "" {
+		setIfMissing("ISRC", []string{credits.ISRC})
+	}
+	setIfMissing("GENRE", credits.Genres)
+	setIfMissing("COMPOSER", credits.Composers)
+	setIfMissing("LYRICIST", credits.Lyricists)
+	setIfMissing("PRODUCER", credits.Producers)
+	setIfMissing("PERFORMER", credits.Performers)
+
+	return WriteAllTags(filePath, tags)
+}
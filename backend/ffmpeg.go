@@ -648,7 +648,13 @@ func ConvertAudio(req ConvertAudioRequest) ([]ConvertAudioResult, error) {
 			}
 
 			if lyrics != "" {
-				if err := EmbedLyricsOnlyUniversal(outputFile, lyrics); err != nil {
+				var err error
+				if parsed, perr := ParseLyricsContent(lyrics); perr == nil {
+					_, err = NewLyricsClient().EmbedLyrics(outputFile, parsed, inputMetadata.Title, inputMetadata.Artist)
+				} else {
+					err = EmbedLyricsOnlyUniversal(outputFile, lyrics)
+				}
+				if err != nil {
 					fmt.Printf("[FFmpeg] Warning: Failed to embed lyrics: %v\n", err)
 				} else {
 					fmt.Printf("[FFmpeg] Lyrics embedded successfully\n")
@@ -320,35 +320,15 @@ func GenerateFilename(metadata *AudioMetadata, format string, ext string) string
 		return ""
 	}
 
-	result := format
-
-	year := metadata.Year
-	if len(year) >= 4 {
-		year = year[:4]
-	}
-
-	result = strings.ReplaceAll(result, "{title}", sanitizeFilenameForRename(metadata.Title))
-	result = strings.ReplaceAll(result, "{artist}", sanitizeFilenameForRename(metadata.Artist))
-	result = strings.ReplaceAll(result, "{album}", sanitizeFilenameForRename(metadata.Album))
-	result = strings.ReplaceAll(result, "{album_artist}", sanitizeFilenameForRename(metadata.AlbumArtist))
-	result = strings.ReplaceAll(result, "{year}", sanitizeFilenameForRename(year))
-
-	if metadata.TrackNumber > 0 {
-		result = strings.ReplaceAll(result, "{track}", fmt.Sprintf("%02d", metadata.TrackNumber))
-	} else {
-		result = strings.ReplaceAll(result, "{track}", "")
-	}
-
-	if metadata.DiscNumber > 0 {
-		result = strings.ReplaceAll(result, "{disc}", fmt.Sprintf("%d", metadata.DiscNumber))
-	} else {
-		result = strings.ReplaceAll(result, "{disc}", "")
-	}
-
-	result = strings.TrimSpace(result)
-	result = strings.Join(strings.Fields(result), " ")
-
-	result = strings.Trim(result, " -._")
+	result := RenderTemplate(format, TemplateData{
+		Title:       metadata.Title,
+		Artist:      metadata.Artist,
+		Album:       metadata.Album,
+		AlbumArtist: metadata.AlbumArtist,
+		ReleaseDate: metadata.Year,
+		Track:       metadata.TrackNumber,
+		Disc:        metadata.DiscNumber,
+	}, sanitizeFilenameForRename)
 
 	if result == "" {
 		return ""
@@ -364,7 +344,11 @@ func sanitizeFilenameForRename(name string) string {
 	for _, char := range invalid {
 		result = strings.ReplaceAll(result, char, "")
 	}
-	return strings.TrimSpace(result)
+	result = strings.TrimSpace(result)
+	if result == "" {
+		return ""
+	}
+	return SanitizeFilename(result)
 }
 
 func PreviewRename(files []string, format string) []RenamePreview {
@@ -395,7 +379,7 @@ func PreviewRename(files []string, format string) []RenamePreview {
 		}
 
 		preview.NewName = newName
-		preview.NewPath = filepath.Join(filepath.Dir(filePath), newName)
+		preview.NewPath = FitPath(filepath.Dir(filePath), newName)
 
 		previews = append(previews, preview)
 	}
@@ -440,7 +424,7 @@ func RenameFiles(files []string, format string) []RenameResult {
 			continue
 		}
 
-		newPath := filepath.Join(filepath.Dir(filePath), newName)
+		newPath := FitPath(filepath.Dir(filePath), newName)
 		result.NewPath = newPath
 
 		if newPath != filePath {
@@ -452,6 +436,13 @@ func RenameFiles(files []string, format string) []RenameResult {
 			}
 		}
 
+		if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+			result.Error = err.Error()
+			result.Success = false
+			results = append(results, result)
+			continue
+		}
+
 		if err := os.Rename(filePath, newPath); err != nil {
 			result.Error = err.Error()
 			result.Success = false
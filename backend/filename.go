@@ -1,7 +1,7 @@
 package backend
 
 import (
-	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -9,67 +9,42 @@ import (
 	"unicode/utf8"
 )
 
-func BuildExpectedFilename(trackName, artistName, albumName, albumArtist, releaseDate, filenameFormat, playlistName, playlistOwner string, includeTrackNumber bool, position, discNumber int, useAlbumTrackNumber bool) string {
-
-	safeTitle := SanitizeFilename(trackName)
-	safeArtist := SanitizeFilename(artistName)
-	safeAlbum := SanitizeFilename(albumName)
-	safeAlbumArtist := SanitizeFilename(albumArtist)
-
-	safePlaylist := SanitizeFilename(playlistName)
-	safeCreator := SanitizeFilename(playlistOwner)
-
-	year := ""
-	if len(releaseDate) >= 4 {
-		year = releaseDate[:4]
+func NewTrackTemplateData(trackName, artistName, albumName, albumArtist, releaseDate, playlistName, playlistOwner, isrc string, position, trackNumber, discNumber, totalTracks, totalDiscs int, useAlbumTrackNumber bool) TemplateData {
+	track := position
+	if useAlbumTrackNumber && trackNumber > 0 {
+		track = trackNumber
 	}
+	if !IsValidISRC(isrc) {
+		isrc = ""
+	}
+	return TemplateData{
+		Title:       trackName,
+		Artist:      artistName,
+		Album:       albumName,
+		AlbumArtist: albumArtist,
+		ReleaseDate: releaseDate,
+		Playlist:    playlistName,
+		Creator:     playlistOwner,
+		ISRC:        isrc,
+		Track:       track,
+		Disc:        discNumber,
+		TotalTracks: totalTracks,
+		TotalDiscs:  totalDiscs,
+	}
+}
 
-	var filename string
-
-	if strings.Contains(filenameFormat, "{") {
-		filename = filenameFormat
-		filename = strings.ReplaceAll(filename, "{title}", safeTitle)
-		filename = strings.ReplaceAll(filename, "{artist}", safeArtist)
-		filename = strings.ReplaceAll(filename, "{album}", safeAlbum)
-		filename = strings.ReplaceAll(filename, "{album_artist}", safeAlbumArtist)
-		filename = strings.ReplaceAll(filename, "{year}", year)
-		filename = strings.ReplaceAll(filename, "{playlist}", safePlaylist)
-		filename = strings.ReplaceAll(filename, "{creator}", safeCreator)
-
-		if discNumber > 0 {
-			filename = strings.ReplaceAll(filename, "{disc}", fmt.Sprintf("%d", discNumber))
-		} else {
-			filename = strings.ReplaceAll(filename, "{disc}", "")
-		}
-
-		if position > 0 {
-			filename = strings.ReplaceAll(filename, "{track}", fmt.Sprintf("%02d", position))
-		} else {
-
-			filename = regexp.MustCompile(`\{track\}\.\s*`).ReplaceAllString(filename, "")
-			filename = regexp.MustCompile(`\{track\}\s*-\s*`).ReplaceAllString(filename, "")
-			filename = regexp.MustCompile(`\{track\}\s*`).ReplaceAllString(filename, "")
-		}
-	} else {
-
-		switch filenameFormat {
-		case "artist-title":
-			filename = fmt.Sprintf("%s - %s", safeArtist, safeTitle)
-		case "title":
-			filename = safeTitle
-		default:
-			filename = fmt.Sprintf("%s - %s", safeTitle, safeArtist)
-		}
+func BuildExpectedFilename(data TemplateData, filenameFormat string, includeTrackNumber bool, ext string) string {
+	return RenderFilename(filenameFormat, data, includeTrackNumber, ext)
+}
 
-		if includeTrackNumber && position > 0 {
-			filename = fmt.Sprintf("%02d. %s", position, filename)
-		}
+func buildDownloadFilename(format string, data TemplateData, includeTrackNumber bool, position int, useAlbumTrackNumber bool) string {
+	if !useAlbumTrackNumber || data.Track <= 0 {
+		data.Track = position
 	}
-
-	return filename + ".flac"
+	return RenderFilename(format, data, includeTrackNumber, ".flac")
 }
 
-func SanitizeFilename(name string) string {
+func sanitizeDefaultName(name string) string {
 
 	sanitized := strings.ReplaceAll(name, "/", " ")
 
@@ -132,6 +107,7 @@ func SanitizeFolderPath(folderPath string) string {
 	parts := strings.Split(normalizedPath, sep)
 	sanitizedParts := make([]string, 0, len(parts))
 
+	existing := true
 	for i, part := range parts {
 
 		if i == 0 && len(part) == 2 && part[1] == ':' {
@@ -144,7 +120,17 @@ func SanitizeFolderPath(folderPath string) string {
 			continue
 		}
 
-		sanitized := sanitizeFolderName(part)
+		var sanitized string
+		if existing {
+			sanitized = sanitizeDefaultName(part)
+			candidate := strings.Join(append(sanitizedParts, sanitized), sep)
+			if info, err := os.Stat(candidate); err != nil || !info.IsDir() {
+				existing = false
+			}
+		}
+		if !existing {
+			sanitized = sanitizeFolderName(part)
+		}
 		if sanitized != "" {
 			sanitizedParts = append(sanitizedParts, sanitized)
 		}
@@ -0,0 +1,765 @@
+package backend
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	mewflac "github.com/mewkiz/flac"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	fingerprintBucket = "Fingerprints"
+
+	fpSampleRate     = 11025
+	fpFrameSize      = 4096
+	fpFrameHop       = fpFrameSize / 3
+	fpMinFreq        = 28.0
+	fpMaxFreq        = 3520.0
+	fpNumBands       = 12
+	fpMaxSeconds     = 120
+	fpMaxOffset      = 80
+	fpMinOverlap     = 40
+	fpMaxDurationGap = 10.0
+
+	DefaultDuplicateThreshold = 0.85
+)
+
+type fpFilter struct {
+	kind   int
+	y      int
+	height int
+	width  int
+}
+
+type fpClassifier struct {
+	filter    fpFilter
+	quantizer [3]float64
+}
+
+var fpClassifiers = []fpClassifier{
+	{fpFilter{0, 4, 3, 15}, [3]float64{1.98215, 2.35817, 2.63523}},
+	{fpFilter{4, 4, 6, 15}, [3]float64{-1.03809, -0.651211, -0.282167}},
+	{fpFilter{1, 0, 4, 16}, [3]float64{-0.298702, 0.119262, 0.558497}},
+	{fpFilter{3, 8, 2, 12}, [3]float64{-0.105439, 0.0153946, 0.135898}},
+	{fpFilter{3, 4, 4, 8}, [3]float64{-0.142891, 0.0258736, 0.200632}},
+	{fpFilter{4, 0, 3, 5}, [3]float64{-0.826319, -0.590612, -0.368214}},
+	{fpFilter{1, 2, 2, 9}, [3]float64{-0.557409, -0.233035, 0.0534525}},
+	{fpFilter{2, 7, 3, 4}, [3]float64{-0.0646826, 0.00620476, 0.0784847}},
+	{fpFilter{2, 6, 2, 16}, [3]float64{-0.192387, -0.029699, 0.215855}},
+	{fpFilter{2, 1, 3, 2}, [3]float64{-0.0397818, -0.00568076, 0.0292026}},
+	{fpFilter{5, 10, 1, 15}, [3]float64{-0.53823, -0.369934, -0.190235}},
+	{fpFilter{3, 6, 2, 10}, [3]float64{-0.124877, 0.0296483, 0.139239}},
+	{fpFilter{2, 1, 1, 14}, [3]float64{-0.101475, 0.0225617, 0.231971}},
+	{fpFilter{3, 5, 6, 4}, [3]float64{-0.0799915, -0.00729616, 0.063262}},
+	{fpFilter{1, 9, 2, 12}, [3]float64{-0.272556, 0.019424, 0.302559}},
+	{fpFilter{3, 4, 2, 14}, [3]float64{-0.164292, -0.0321188, 0.08463}},
+}
+
+var fpChromaFilter = []float64{0.25, 0.75, 1.0, 0.75, 0.25}
+
+var fpGrayCode = []uint32{0, 1, 3, 2}
+
+type FingerprintRecord struct {
+	Path        string        `json:"path"`
+	Size        int64         `json:"size"`
+	ModTime     int64         `json:"mod_time"`
+	Duration    float64       `json:"duration"`
+	Fingerprint []uint32      `json:"fingerprint"`
+	Quality     *AudioQuality `json:"quality,omitempty"`
+}
+
+type DuplicateFile struct {
+	Path       string        `json:"path"`
+	Quality    *AudioQuality `json:"quality,omitempty"`
+	QualityStr string        `json:"quality_str"`
+	Similarity float64       `json:"similarity"`
+	Best       bool          `json:"best"`
+}
+
+type DuplicateGroup struct {
+	ID       string          `json:"id"`
+	BestPath string          `json:"best_path"`
+	Files    []DuplicateFile `json:"files"`
+}
+
+type DuplicateScanResult struct {
+	Scanned int              `json:"scanned"`
+	Indexed int              `json:"indexed"`
+	Failed  []string         `json:"failed,omitempty"`
+	Groups  []DuplicateGroup `json:"groups"`
+}
+
+type DuplicateRemoveResult struct {
+	Path    string `json:"path"`
+	MovedTo string `json:"moved_to,omitempty"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+func ComputeFingerprint(filePath string) ([]uint32, float64, error) {
+	samples, err := decodeFingerprintPCM(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if len(samples) < fpFrameSize*2 {
+		return nil, 0, fmt.Errorf("audio too short to fingerprint")
+	}
+
+	duration, err := GetAudioDuration(filePath)
+	if err != nil {
+		duration = float64(len(samples)) / fpSampleRate
+	}
+
+	return fingerprintFromSamples(samples), duration, nil
+}
+
+func decodeFingerprintPCM(filePath string) ([]float64, error) {
+	if installed, _ := IsFFmpegInstalled(); installed {
+		return decodePCMWithFFmpeg(filePath, fpSampleRate, fpMaxSeconds)
+	}
+
+	if strings.ToLower(filepath.Ext(filePath)) != ".flac" {
+		return nil, fmt.Errorf("ffmpeg is required to decode %s", filepath.Ext(filePath))
+	}
+
+	return decodeFLACResampled(filePath, fpSampleRate, fpMaxSeconds)
+}
+
+func decodePCMWithFFmpeg(filePath string, sampleRate, maxSeconds int) ([]float64, error) {
+	ffmpegPath, err := GetFFmpegPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateExecutable(ffmpegPath); err != nil {
+		return nil, fmt.Errorf("invalid ffmpeg executable: %w", err)
+	}
+
+	args := []string{"-v", "quiet", "-i", filePath}
+	if maxSeconds > 0 {
+		args = append(args, "-t", fmt.Sprintf("%d", maxSeconds))
+	}
+	args = append(args, "-vn", "-ac", "1", "-ar", fmt.Sprintf("%d", sampleRate), "-f", "s16le", "-")
+
+	cmd := exec.Command(ffmpegPath, args...)
+	setHideWindow(cmd)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	raw, readErr := io.ReadAll(stdout)
+	waitErr := cmd.Wait()
+	if readErr != nil {
+		return nil, fmt.Errorf("failed to read decoded audio: %w", readErr)
+	}
+	if waitErr != nil && len(raw) == 0 {
+		return nil, fmt.Errorf("ffmpeg decode failed: %w", waitErr)
+	}
+
+	samples := make([]float64, len(raw)/2)
+	for i := range samples {
+		samples[i] = float64(int16(binary.LittleEndian.Uint16(raw[i*2:])))
+	}
+
+	return samples, nil
+}
+
+func decodeFLACResampled(filePath string, sampleRate, maxSeconds int) ([]float64, error) {
+	stream, err := mewflac.ParseFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FLAC: %w", err)
+	}
+	defer stream.Close()
+
+	srcRate := int(stream.Info.SampleRate)
+	channels := int(stream.Info.NChannels)
+	if srcRate == 0 || channels == 0 {
+		return nil, fmt.Errorf("invalid FLAC stream info")
+	}
+
+	shift := int(stream.Info.BitsPerSample) - 16
+	ratio := float64(srcRate) / float64(sampleRate)
+	limit := maxSeconds * sampleRate
+
+	var samples []float64
+	var acc float64
+	var accCount int
+	next := ratio
+
+	pos := 0
+	for {
+		frame, err := stream.ParseNext()
+		if err != nil {
+			break
+		}
+
+		for i := 0; i < frame.Subframes[0].NSamples; i++ {
+			var sample float64
+			for ch := 0; ch < channels; ch++ {
+				sample += float64(frame.Subframes[ch].Samples[i])
+			}
+			sample /= float64(channels)
+			if shift > 0 {
+				sample /= float64(int(1) << shift)
+			} else if shift < 0 {
+				sample *= float64(int(1) << -shift)
+			}
+
+			acc += sample
+			accCount++
+			pos++
+
+			if float64(pos) >= next {
+				samples = append(samples, acc/float64(accCount))
+				acc, accCount = 0, 0
+				next += ratio
+				if limit > 0 && len(samples) >= limit {
+					return samples, nil
+				}
+			}
+		}
+	}
+
+	return samples, nil
+}
+
+func fingerprintFromSamples(samples []float64) []uint32 {
+	window := make([]float64, fpFrameSize)
+	for i := range window {
+		window[i] = 0.54 - 0.46*math.Cos(2.0*math.Pi*float64(i)/float64(fpFrameSize-1))
+	}
+
+	minIndex := int(math.Round(fpFrameSize * fpMinFreq / fpSampleRate))
+	if minIndex < 1 {
+		minIndex = 1
+	}
+	maxIndex := int(math.Round(fpFrameSize * fpMaxFreq / fpSampleRate))
+	if maxIndex > fpFrameSize/2 {
+		maxIndex = fpFrameSize / 2
+	}
+
+	notes := make([]int, fpFrameSize/2+1)
+	for i := minIndex; i < maxIndex; i++ {
+		freq := float64(i) * fpSampleRate / fpFrameSize
+		octave := math.Log2(freq / (440.0 / 16.0))
+		notes[i] = int(fpNumBands * (octave - math.Floor(octave)))
+	}
+
+	var chroma [][]float64
+	frame := make([]float64, fpFrameSize)
+	for start := 0; start+fpFrameSize <= len(samples); start += fpFrameHop {
+		for i := 0; i < fpFrameSize; i++ {
+			frame[i] = samples[start+i] * window[i]
+		}
+
+		spectrum := fft(frame)
+		features := make([]float64, fpNumBands)
+		for i := minIndex; i < maxIndex; i++ {
+			re, im := real(spectrum[i]), imag(spectrum[i])
+			features[notes[i]] += re*re + im*im
+		}
+		chroma = append(chroma, features)
+	}
+
+	image := make([][]float64, 0, len(chroma))
+	for i := 0; i+len(fpChromaFilter) <= len(chroma); i++ {
+		row := make([]float64, fpNumBands)
+		for j, coef := range fpChromaFilter {
+			for b := 0; b < fpNumBands; b++ {
+				row[b] += chroma[i+j][b] * coef
+			}
+		}
+
+		var norm float64
+		for _, v := range row {
+			norm += v * v
+		}
+		norm = math.Sqrt(norm)
+		for b := range row {
+			if norm < 0.01 {
+				row[b] = 0
+			} else {
+				row[b] /= norm
+			}
+		}
+		image = append(image, row)
+	}
+
+	integral := make([][]float64, len(image)+1)
+	integral[0] = make([]float64, fpNumBands+1)
+	for x, row := range image {
+		integral[x+1] = make([]float64, fpNumBands+1)
+		for y := 0; y < fpNumBands; y++ {
+			integral[x+1][y+1] = row[y] + integral[x][y+1] + integral[x+1][y] - integral[x][y]
+		}
+	}
+
+	area := func(x1, y1, x2, y2 int) float64 {
+		return integral[x2][y2] - integral[x1][y2] - integral[x2][y1] + integral[x1][y1]
+	}
+
+	maxWidth := 0
+	for _, c := range fpClassifiers {
+		if c.filter.width > maxWidth {
+			maxWidth = c.filter.width
+		}
+	}
+
+	var fingerprint []uint32
+	for x := 0; x+maxWidth <= len(image); x++ {
+		var bitsValue uint32
+		for i, c := range fpClassifiers {
+			value := applyFingerprintFilter(c.filter, x, area)
+			bitsValue |= fpGrayCode[quantizeFingerprintValue(value, c.quantizer)] << (2 * uint(i))
+		}
+		fingerprint = append(fingerprint, bitsValue)
+	}
+
+	return fingerprint
+}
+
+func applyFingerprintFilter(f fpFilter, x int, area func(x1, y1, x2, y2 int) float64) float64 {
+	y, w, h := f.y, f.width, f.height
+	var a, b float64
+
+	switch f.kind {
+	case 0:
+		a = area(x, y, x+w, y+h)
+	case 1:
+		h2 := h / 2
+		a = area(x, y+h2, x+w, y+h)
+		b = area(x, y, x+w, y+h2)
+	case 2:
+		w2 := w / 2
+		a = area(x+w2, y, x+w, y+h)
+		b = area(x, y, x+w2, y+h)
+	case 3:
+		w2, h2 := w/2, h/2
+		a = area(x, y+h2, x+w2, y+h) + area(x+w2, y, x+w, y+h2)
+		b = area(x, y, x+w2, y+h2) + area(x+w2, y+h2, x+w, y+h)
+	case 4:
+		h3 := h / 3
+		a = area(x, y+h3, x+w, y+2*h3)
+		b = area(x, y, x+w, y+h3) + area(x, y+2*h3, x+w, y+h)
+	case 5:
+		w3 := w / 3
+		a = area(x+w3, y, x+2*w3, y+h)
+		b = area(x, y, x+w3, y+h) + area(x+2*w3, y, x+w, y+h)
+	}
+
+	return math.Log(1.0+a) - math.Log(1.0+b)
+}
+
+func quantizeFingerprintValue(value float64, t [3]float64) int {
+	if value < t[1] {
+		if value < t[0] {
+			return 0
+		}
+		return 1
+	}
+	if value < t[2] {
+		return 2
+	}
+	return 3
+}
+
+func CompareFingerprints(a, b []uint32) float64 {
+	best := 0.0
+	for offset := -fpMaxOffset; offset <= fpMaxOffset; offset++ {
+		var errorBits, count int
+		for i := 0; i < len(a); i++ {
+			j := i + offset
+			if j < 0 || j >= len(b) {
+				continue
+			}
+			errorBits += bits.OnesCount32(a[i] ^ b[j])
+			count++
+		}
+		if count < fpMinOverlap {
+			continue
+		}
+		score := 1.0 - float64(errorBits)/float64(count*32)
+		if score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+func GetFingerprintRecord(filePath string, appName string) (*FingerprintRecord, bool, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, false, err
+		}
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var cached *FingerprintRecord
+	historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(fingerprintBucket))
+		if b == nil {
+			return nil
+		}
+		v := b.Get([]byte(filePath))
+		if v == nil {
+			return nil
+		}
+		var record FingerprintRecord
+		if err := json.Unmarshal(v, &record); err == nil {
+			cached = &record
+		}
+		return nil
+	})
+
+	if cached != nil && cached.Size == info.Size() && cached.ModTime == info.ModTime().UnixNano() {
+		return cached, true, nil
+	}
+
+	fingerprint, duration, err := ComputeFingerprint(filePath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	record := &FingerprintRecord{
+		Path:        filePath,
+		Size:        info.Size(),
+		ModTime:     info.ModTime().UnixNano(),
+		Duration:    duration,
+		Fingerprint: fingerprint,
+	}
+	if quality, err := ProbeAudioQuality(filePath); err == nil {
+		record.Quality = quality
+	}
+
+	buf, err := json.Marshal(record)
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = historyDB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(fingerprintBucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(filePath), buf)
+	})
+
+	return record, false, err
+}
+
+func pruneFingerprintIndex(rootDir string) error {
+	prefix := filepath.Clean(rootDir) + string(filepath.Separator)
+	return historyDB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(fingerprintBucket))
+		if b == nil {
+			return nil
+		}
+
+		var stale [][]byte
+		c := b.Cursor()
+		for k, _ := c.Seek([]byte(prefix)); k != nil && strings.HasPrefix(string(k), prefix); k, _ = c.Next() {
+			if !fileExists(string(k)) {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+		}
+
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func FindDuplicates(rootDir string, threshold float64, progressCallback func(done, total int), appName string) (*DuplicateScanResult, error) {
+	if rootDir == "" {
+		return nil, fmt.Errorf("directory path is required")
+	}
+	if threshold <= 0 || threshold > 1 {
+		threshold = DefaultDuplicateThreshold
+	}
+
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	var paths []string
+	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".flac", ".mp3", ".m4a":
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	if err := pruneFingerprintIndex(rootDir); err != nil {
+		fmt.Printf("[Duplicates] Warning: failed to prune fingerprint index: %v\n", err)
+	}
+
+	result := &DuplicateScanResult{Scanned: len(paths)}
+	var records []*FingerprintRecord
+
+	for i, path := range paths {
+		record, cached, err := GetFingerprintRecord(path, appName)
+		if err != nil {
+			fmt.Printf("[Duplicates] Failed to fingerprint %s: %v\n", path, err)
+			result.Failed = append(result.Failed, path)
+		} else {
+			if !cached {
+				result.Indexed++
+			}
+			if len(record.Fingerprint) > 0 {
+				records = append(records, record)
+			}
+		}
+
+		if progressCallback != nil {
+			progressCallback(i+1, len(paths))
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Duration < records[j].Duration
+	})
+
+	parent := make([]int, len(records))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
+
+	similarity := make(map[int]float64)
+	for i := 0; i < len(records); i++ {
+		for j := i + 1; j < len(records); j++ {
+			if records[j].Duration-records[i].Duration > fpMaxDurationGap {
+				break
+			}
+			score := CompareFingerprints(records[i].Fingerprint, records[j].Fingerprint)
+			if score < threshold {
+				continue
+			}
+			parent[find(j)] = find(i)
+			if score > similarity[i] {
+				similarity[i] = score
+			}
+			if score > similarity[j] {
+				similarity[j] = score
+			}
+		}
+	}
+
+	clusters := make(map[int][]int)
+	for i := range records {
+		root := find(i)
+		clusters[root] = append(clusters[root], i)
+	}
+
+	for _, members := range clusters {
+		if len(members) < 2 {
+			continue
+		}
+
+		files := make([]DuplicateFile, 0, len(members))
+		for _, idx := range members {
+			file := DuplicateFile{
+				Path:       records[idx].Path,
+				Quality:    records[idx].Quality,
+				Similarity: similarity[idx],
+			}
+			if file.Quality != nil {
+				file.QualityStr = file.Quality.Label()
+			}
+			files = append(files, file)
+		}
+
+		sort.SliceStable(files, func(i, j int) bool {
+			return compareAudioQuality(files[i].Quality, files[j].Quality) > 0
+		})
+		files[0].Best = true
+
+		result.Groups = append(result.Groups, DuplicateGroup{
+			ID:       fmt.Sprintf("dup-%d", len(result.Groups)+1),
+			BestPath: files[0].Path,
+			Files:    files,
+		})
+	}
+
+	sort.Slice(result.Groups, func(i, j int) bool {
+		return result.Groups[i].BestPath < result.Groups[j].BestPath
+	})
+	for i := range result.Groups {
+		result.Groups[i].ID = fmt.Sprintf("dup-%d", i+1)
+	}
+
+	return result, nil
+}
+
+func compareAudioQuality(a, b *AudioQuality) int {
+	if a == nil || b == nil {
+		switch {
+		case a != nil:
+			return 1
+		case b != nil:
+			return -1
+		}
+		return 0
+	}
+
+	if a.Lossless != b.Lossless {
+		if a.Lossless {
+			return 1
+		}
+		return -1
+	}
+
+	values := [][2]int64{
+		{int64(a.BitDepth), int64(b.BitDepth)},
+		{int64(a.SampleRate), int64(b.SampleRate)},
+		{int64(a.Bitrate), int64(b.Bitrate)},
+		{a.Size, b.Size},
+	}
+	for _, v := range values {
+		if v[0] > v[1] {
+			return 1
+		}
+		if v[0] < v[1] {
+			return -1
+		}
+	}
+	return 0
+}
+
+func RemoveDuplicateCopies(keepPath string, removePaths []string, trashDir string, appName string) ([]DuplicateRemoveResult, error) {
+	if keepPath == "" {
+		return nil, fmt.Errorf("path of the copy to keep is required")
+	}
+	if !fileExists(keepPath) {
+		return nil, fmt.Errorf("file to keep does not exist: %s", keepPath)
+	}
+
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	if trashDir != "" {
+		if err := os.MkdirAll(trashDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create trash directory: %w", err)
+		}
+	}
+
+	var results []DuplicateRemoveResult
+	for _, path := range removePaths {
+		result := DuplicateRemoveResult{Path: path}
+
+		if filepath.Clean(path) == filepath.Clean(keepPath) {
+			result.Error = "refusing to remove the copy being kept"
+			results = append(results, result)
+			continue
+		}
+
+		if trashDir != "" {
+			target := filepath.Join(trashDir, filepath.Base(path))
+			for n := 2; fileExists(target); n++ {
+				ext := filepath.Ext(path)
+				target = filepath.Join(trashDir, fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(filepath.Base(path), ext), n, ext))
+			}
+			if err := moveFile(path, target); err != nil {
+				result.Error = err.Error()
+				results = append(results, result)
+				continue
+			}
+			result.MovedTo = target
+		} else if err := os.Remove(path); err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		historyDB.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(fingerprintBucket))
+			if b == nil {
+				return nil
+			}
+			return b.Delete([]byte(path))
+		})
+
+		result.Success = true
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		in.Close()
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		in.Close()
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	in.Close()
+
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	return os.Remove(src)
+}
@@ -324,3 +324,46 @@ func DeleteFetchHistoryItem(id string, appName string) error {
 		return b.Delete([]byte(id))
 	})
 }
+
+func UpdateHistoryItems(update func(item *HistoryItem) bool, appName string) (int, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return 0, err
+		}
+	}
+
+	updated := 0
+	err := historyDB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(historyBucket))
+		if b == nil {
+			return nil
+		}
+
+		changes := make(map[string][]byte)
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var item HistoryItem
+			if err := json.Unmarshal(v, &item); err != nil {
+				continue
+			}
+			if !update(&item) {
+				continue
+			}
+			buf, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			changes[string(k)] = buf
+		}
+
+		for k, v := range changes {
+			if err := b.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+		updated = len(changes)
+		return nil
+	})
+
+	return updated, err
+}
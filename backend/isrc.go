@@ -0,0 +1,265 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var isrcPattern = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}\d{2}\d{5}$`)
+
+func IsValidISRC(isrc string) bool {
+	return isrcPattern.MatchString(isrc)
+}
+
+var isrcCountryCodes = func() map[string]bool {
+	codes := make(map[string]bool)
+	iso := "AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ " +
+		"CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR " +
+		"GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP " +
+		"KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT " +
+		"MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW " +
+		"SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG " +
+		"UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW"
+	for _, code := range strings.Fields(iso) {
+		codes[code] = true
+	}
+	for _, code := range strings.Fields("UK YU CS XK ZZ CP DG QM QN QO QP QQ QR QS QT QU QV QW QX QY QZ") {
+		codes[code] = true
+	}
+	return codes
+}()
+
+type ISRCInfo struct {
+	ISRC        string `json:"isrc"`
+	Country     string `json:"country"`
+	Registrant  string `json:"registrant"`
+	Year        string `json:"year"`
+	Designation string `json:"designation"`
+}
+
+func NormalizeISRC(value string) string {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	value = strings.TrimPrefix(value, "ISRC")
+	value = strings.TrimLeft(value, ": ")
+	return strings.NewReplacer("-", "", " ", "").Replace(value)
+}
+
+func ParseISRC(value string) (*ISRCInfo, error) {
+	isrc := NormalizeISRC(value)
+	if !IsValidISRC(isrc) {
+		return nil, fmt.Errorf("invalid ISRC format: %q", value)
+	}
+
+	info := &ISRCInfo{
+		ISRC:        isrc,
+		Country:     isrc[:2],
+		Registrant:  isrc[2:5],
+		Year:        isrc[5:7],
+		Designation: isrc[7:],
+	}
+	if !isrcCountryCodes[info.Country] {
+		return nil, fmt.Errorf("ISRC %s has unknown country code %s", isrc, info.Country)
+	}
+	if info.Registrant == "000" {
+		return nil, fmt.Errorf("ISRC %s has no registrant", isrc)
+	}
+	if info.Designation == "00000" {
+		return nil, fmt.Errorf("ISRC %s has no designation code", isrc)
+	}
+	return info, nil
+}
+
+type ISRCQuery struct {
+	SpotifyID   string `json:"spotify_id,omitempty"`
+	ISRC        string `json:"isrc,omitempty"`
+	TrackName   string `json:"track_name,omitempty"`
+	ArtistName  string `json:"artist_name,omitempty"`
+	AlbumName   string `json:"album_name,omitempty"`
+	DurationSec int    `json:"duration,omitempty"`
+	DeezerURL   string `json:"deezer_url,omitempty"`
+	TidalURL    string `json:"tidal_url,omitempty"`
+}
+
+type ISRCResolution struct {
+	ISRCInfo
+	Source string `json:"source"`
+}
+
+const (
+	ISRCSourceProvided = "provided"
+	ISRCSourceCache    = "cache"
+	ISRCSourceSpotify  = "spotify"
+	ISRCSourceQobuz    = "qobuz"
+	ISRCSourceDeezer   = "deezer"
+	ISRCSourceTidal    = "tidal"
+
+	isrcCacheBucket = "ISRCCache"
+)
+
+type isrcCacheEntry struct {
+	ISRC      string `json:"isrc"`
+	Source    string `json:"source"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func getCachedISRC(spotifyID string) *isrcCacheEntry {
+	if historyDB == nil || spotifyID == "" {
+		return nil
+	}
+
+	var found *isrcCacheEntry
+	historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(isrcCacheBucket))
+		if b == nil {
+			return nil
+		}
+		if v := b.Get([]byte(spotifyID)); v != nil {
+			var entry isrcCacheEntry
+			if err := json.Unmarshal(v, &entry); err == nil {
+				found = &entry
+			}
+		}
+		return nil
+	})
+	return found
+}
+
+func storeCachedISRC(spotifyID string, resolution *ISRCResolution) {
+	if historyDB == nil || spotifyID == "" {
+		return
+	}
+
+	buf, err := json.Marshal(isrcCacheEntry{
+		ISRC:      resolution.ISRC,
+		Source:    resolution.Source,
+		Timestamp: time.Now().Unix(),
+	})
+	if err != nil {
+		return
+	}
+
+	err = historyDB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(isrcCacheBucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(spotifyID), buf)
+	})
+	if err != nil {
+		fmt.Printf("[ISRC] Failed to cache %s: %v\n", spotifyID, err)
+	}
+}
+
+func ClearISRCCache(appName string) error {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return err
+		}
+	}
+
+	return historyDB.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(isrcCacheBucket)) == nil {
+			return nil
+		}
+		return tx.DeleteBucket([]byte(isrcCacheBucket))
+	})
+}
+
+func ResolveISRC(ctx context.Context, query ISRCQuery) (*ISRCResolution, error) {
+	accept := func(value, source string) *ISRCResolution {
+		if !IsValidISRC(NormalizeISRC(value)) {
+			return nil
+		}
+		info, err := ParseISRC(value)
+		if err != nil {
+			fmt.Printf("[ISRC] Ignoring %s result: %v\n", source, err)
+			return nil
+		}
+		return &ISRCResolution{ISRCInfo: *info, Source: source}
+	}
+
+	if resolution := accept(query.ISRC, ISRCSourceProvided); resolution != nil {
+		return resolution, nil
+	}
+
+	if entry := getCachedISRC(query.SpotifyID); entry != nil {
+		if resolution := accept(entry.ISRC, ISRCSourceCache); resolution != nil {
+			return resolution, nil
+		}
+	}
+
+	var errs []string
+	if query.SpotifyID != "" && ctx.Err() == nil {
+		tracks, err := NewSpotifyMetadataClient().FetchTrackISRCs(ctx, []string{query.SpotifyID})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("spotify: %v", err))
+		} else if resolution := accept(tracks[query.SpotifyID].ISRC, ISRCSourceSpotify); resolution != nil {
+			storeCachedISRC(query.SpotifyID, resolution)
+			return resolution, nil
+		}
+	}
+
+	if query.TrackName != "" && query.ArtistName != "" && ctx.Err() == nil {
+		track, err := NewQobuzDownloader().SearchByMetadata(query.TrackName, query.ArtistName, query.AlbumName, query.DurationSec)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("qobuz: %v", err))
+		} else if resolution := accept(track.ISRC, ISRCSourceQobuz); resolution != nil {
+			storeCachedISRC(query.SpotifyID, resolution)
+			return resolution, nil
+		}
+	}
+
+	deezerURL, tidalURL := query.DeezerURL, query.TidalURL
+	if (deezerURL == "" || tidalURL == "") && query.SpotifyID != "" && ctx.Err() == nil {
+		links, err := NewSongLinkClient().WithContext(ctx).spotifyTrackLinks(query.SpotifyID, "")
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("song.link: %v", err))
+		} else {
+			if deezerURL == "" {
+				deezerURL = links["deezer"]
+			}
+			if tidalURL == "" {
+				tidalURL = links["tidal"]
+			}
+		}
+	}
+
+	if deezerURL != "" && ctx.Err() == nil {
+		isrc, err := GetDeezerISRC(deezerURL)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("deezer: %v", err))
+		} else if resolution := accept(isrc, ISRCSourceDeezer); resolution != nil {
+			storeCachedISRC(query.SpotifyID, resolution)
+			return resolution, nil
+		}
+	}
+
+	if tidalURL != "" && ctx.Err() == nil {
+		tidal := NewTidalDownloader("")
+		trackID, err := tidal.GetTrackIDFromURL(tidalURL)
+		var isrc string
+		if err == nil {
+			isrc, err = tidal.GetTrackISRC(trackID)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("tidal: %v", err))
+		} else if resolution := accept(isrc, ISRCSourceTidal); resolution != nil {
+			storeCachedISRC(query.SpotifyID, resolution)
+			return resolution, nil
+		}
+	}
+
+	if len(errs) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("no ISRC source available for this track")
+	}
+	return nil, fmt.Errorf("could not resolve ISRC (%s)", strings.Join(errs, "; "))
+}
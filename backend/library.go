@@ -0,0 +1,758 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	id3v2 "github.com/bogem/id3v2/v2"
+	"github.com/go-flac/go-flac"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	libraryTracksBucket = "LibraryTracks"
+	libraryRootsBucket  = "LibraryRoots"
+)
+
+var folderCoverNames = []string{"cover.jpg", "cover.png", "folder.jpg", "folder.png", "front.jpg", "front.png", "album.jpg"}
+
+type LibraryTrack struct {
+	Path           string        `json:"path"`
+	Root           string        `json:"root"`
+	Size           int64         `json:"size"`
+	ModTime        int64         `json:"mod_time"`
+	Title          string        `json:"title"`
+	Artist         string        `json:"artist"`
+	Album          string        `json:"album"`
+	AlbumArtist    string        `json:"album_artist"`
+	TrackNumber    int           `json:"track_number"`
+	DiscNumber     int           `json:"disc_number"`
+	Year           string        `json:"year"`
+	Quality        *AudioQuality `json:"quality,omitempty"`
+	HasCover       bool          `json:"has_cover"`
+	HasFolderCover bool          `json:"has_folder_cover"`
+	HasLyrics      bool          `json:"has_lyrics"`
+	HasLRC         bool          `json:"has_lrc"`
+	IndexedAt      int64         `json:"indexed_at"`
+}
+
+type LibraryRoot struct {
+	Path       string `json:"path"`
+	LastScan   int64  `json:"last_scan"`
+	TrackCount int    `json:"track_count"`
+}
+
+type LibraryScanResult struct {
+	Roots     []string `json:"roots"`
+	Total     int      `json:"total"`
+	Added     int      `json:"added"`
+	Updated   int      `json:"updated"`
+	Unchanged int      `json:"unchanged"`
+	Removed   int      `json:"removed"`
+	Failed    []string `json:"failed,omitempty"`
+}
+
+type LibraryAlbum struct {
+	Directory   string   `json:"directory"`
+	Album       string   `json:"album"`
+	AlbumArtist string   `json:"album_artist"`
+	TrackCount  int      `json:"track_count"`
+	Paths       []string `json:"paths"`
+}
+
+type LibraryUpgradeCandidate struct {
+	Track        LibraryTrack           `json:"track"`
+	Better       *LibraryTrack          `json:"better,omitempty"`
+	SpotifyID    string                 `json:"spotify_id,omitempty"`
+	Availability *AvailabilityMatrixRow `json:"availability,omitempty"`
+}
+
+type LibraryQuery struct {
+	Root          string `json:"root,omitempty"`
+	Text          string `json:"text,omitempty"`
+	Format        string `json:"format,omitempty"`
+	MinBitDepth   int    `json:"min_bit_depth,omitempty"`
+	MaxBitDepth   int    `json:"max_bit_depth,omitempty"`
+	MissingCover  bool   `json:"missing_cover,omitempty"`
+	MissingLyrics bool   `json:"missing_lyrics,omitempty"`
+	Limit         int    `json:"limit,omitempty"`
+}
+
+func ScanLibrary(roots []string, progressCallback func(done, total int), appName string) (*LibraryScanResult, error) {
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("at least one library folder is required")
+	}
+
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &LibraryScanResult{}
+
+	type scanTarget struct {
+		root string
+		path string
+		info os.FileInfo
+	}
+
+	var targets []scanTarget
+	seen := make(map[string]bool)
+
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		if root == "" || root == "." {
+			continue
+		}
+		info, err := os.Stat(root)
+		if err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("library folder not found: %s", root)
+		}
+		result.Roots = append(result.Roots, root)
+
+		filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			switch strings.ToLower(filepath.Ext(path)) {
+			case ".flac", ".mp3", ".m4a":
+				if !seen[path] {
+					seen[path] = true
+					targets = append(targets, scanTarget{root: root, path: path, info: info})
+				}
+			}
+			return nil
+		})
+	}
+
+	existing := make(map[string]LibraryTrack)
+	err := historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(libraryTracksBucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var track LibraryTrack
+			if err := json.Unmarshal(v, &track); err == nil {
+				existing[string(k)] = track
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Total = len(targets)
+	pending := make(map[string][]byte)
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+		err := historyDB.Update(func(tx *bolt.Tx) error {
+			b, err := tx.CreateBucketIfNotExists([]byte(libraryTracksBucket))
+			if err != nil {
+				return err
+			}
+			for k, v := range pending {
+				if err := b.Put([]byte(k), v); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		pending = make(map[string][]byte)
+		return err
+	}
+
+	for i, target := range targets {
+		old, known := existing[target.path]
+		if known && old.Size == target.info.Size() && old.ModTime == target.info.ModTime().UnixNano() {
+			hasFolderCover, hasLRC := probeLibrarySidecars(target.path)
+			if hasFolderCover == old.HasFolderCover && hasLRC == old.HasLRC {
+				result.Unchanged++
+			} else {
+				old.HasFolderCover, old.HasLRC = hasFolderCover, hasLRC
+				buf, err := json.Marshal(old)
+				if err != nil {
+					return nil, err
+				}
+				pending[target.path] = buf
+				result.Updated++
+			}
+		} else {
+			track, err := indexLibraryTrack(target.root, target.path, target.info)
+			if err != nil {
+				fmt.Printf("[Library] Failed to index %s: %v\n", target.path, err)
+				result.Failed = append(result.Failed, target.path)
+			} else {
+				buf, err := json.Marshal(track)
+				if err != nil {
+					return nil, err
+				}
+				pending[target.path] = buf
+				if known {
+					result.Updated++
+				} else {
+					result.Added++
+				}
+			}
+		}
+
+		if len(pending) >= 200 {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+
+		if progressCallback != nil {
+			progressCallback(i+1, len(targets))
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	err = historyDB.Update(func(tx *bolt.Tx) error {
+		tracks, err := tx.CreateBucketIfNotExists([]byte(libraryTracksBucket))
+		if err != nil {
+			return err
+		}
+		rootsBucket, err := tx.CreateBucketIfNotExists([]byte(libraryRootsBucket))
+		if err != nil {
+			return err
+		}
+
+		for path, track := range existing {
+			if seen[path] || !pathWithinRoots(track.Root, result.Roots) {
+				continue
+			}
+			if err := tracks.Delete([]byte(path)); err != nil {
+				return err
+			}
+			result.Removed++
+		}
+
+		for _, root := range result.Roots {
+			count := 0
+			for _, target := range targets {
+				if target.root == root {
+					count++
+				}
+			}
+			buf, err := json.Marshal(LibraryRoot{Path: root, LastScan: time.Now().Unix(), TrackCount: count})
+			if err != nil {
+				return err
+			}
+			if err := rootsBucket.Put([]byte(root), buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func pathWithinRoots(root string, roots []string) bool {
+	for _, r := range roots {
+		if root == r {
+			return true
+		}
+	}
+	return false
+}
+
+func indexLibraryTrack(root, path string, info os.FileInfo) (*LibraryTrack, error) {
+	track := &LibraryTrack{
+		Path:      path,
+		Root:      root,
+		Size:      info.Size(),
+		ModTime:   info.ModTime().UnixNano(),
+		IndexedAt: time.Now().Unix(),
+	}
+
+	if metadata, err := ReadAudioMetadata(path); err == nil {
+		track.Title = metadata.Title
+		track.Artist = metadata.Artist
+		track.Album = metadata.Album
+		track.AlbumArtist = metadata.AlbumArtist
+		track.TrackNumber = metadata.TrackNumber
+		track.DiscNumber = metadata.DiscNumber
+		track.Year = metadata.Year
+	}
+
+	quality, err := ProbeAudioQuality(path)
+	if err != nil {
+		return nil, err
+	}
+	track.Quality = quality
+
+	track.HasCover, track.HasLyrics = probeEmbeddedArtAndLyrics(path)
+
+	track.HasFolderCover, track.HasLRC = probeLibrarySidecars(path)
+
+	return track, nil
+}
+
+func probeLibrarySidecars(path string) (bool, bool) {
+	hasFolderCover := len(findFolderCovers(filepath.Dir(path))) > 0
+	hasLRC := fileExists(strings.TrimSuffix(path, filepath.Ext(path)) + ".lrc")
+	return hasFolderCover, hasLRC
+}
+
+func findFolderCovers(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var covers []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		for _, name := range folderCoverNames {
+			if strings.EqualFold(entry.Name(), name) {
+				covers = append(covers, entry.Name())
+				break
+			}
+		}
+	}
+	return covers
+}
+
+func probeEmbeddedArtAndLyrics(path string) (bool, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".flac":
+		f, err := flac.ParseFile(path)
+		if err != nil {
+			return false, false
+		}
+		hasCover := false
+		for _, block := range f.Meta {
+			if block.Type == flac.Picture {
+				hasCover = true
+				break
+			}
+		}
+		lyrics, _ := extractLyricsFromFlac(path)
+		return hasCover, strings.TrimSpace(lyrics) != ""
+
+	case ".mp3":
+		tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+		if err != nil {
+			return false, false
+		}
+		defer tag.Close()
+		hasCover := len(tag.GetFrames(tag.CommonID("Attached picture"))) > 0
+		hasLyrics := false
+		for _, frame := range tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription")) {
+			if uslt, ok := frame.(id3v2.UnsynchronisedLyricsFrame); ok && strings.TrimSpace(uslt.Lyrics) != "" {
+				hasLyrics = true
+				break
+			}
+		}
+		return hasCover, hasLyrics
+
+	case ".m4a":
+		return probeM4AArtAndLyrics(path)
+	}
+
+	return false, false
+}
+
+func probeM4AArtAndLyrics(path string) (bool, bool) {
+	ffprobePath, err := GetFFprobePath()
+	if err != nil {
+		return false, false
+	}
+
+	if err := ValidateExecutable(ffprobePath); err != nil {
+		return false, false
+	}
+
+	cmd := exec.Command(ffprobePath,
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		path,
+	)
+
+	setHideWindow(cmd)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return false, false
+	}
+
+	var result struct {
+		Format struct {
+			Tags map[string]string `json:"tags"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType   string         `json:"codec_type"`
+			Disposition map[string]int `json:"disposition"`
+		} `json:"streams"`
+	}
+
+	if err := json.Unmarshal(output, &result); err != nil {
+		return false, false
+	}
+
+	hasCover := false
+	for _, stream := range result.Streams {
+		if stream.CodecType == "video" && stream.Disposition["attached_pic"] == 1 {
+			hasCover = true
+			break
+		}
+	}
+
+	hasLyrics := false
+	for key, value := range result.Format.Tags {
+		if strings.HasPrefix(strings.ToLower(key), "lyrics") && strings.TrimSpace(value) != "" {
+			hasLyrics = true
+			break
+		}
+	}
+
+	return hasCover, hasLyrics
+}
+
+func GetLibraryTracks(appName string) ([]LibraryTrack, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	var tracks []LibraryTrack
+	err := historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(libraryTracksBucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var track LibraryTrack
+			if err := json.Unmarshal(v, &track); err == nil {
+				tracks = append(tracks, track)
+			}
+			return nil
+		})
+	})
+
+	return tracks, err
+}
+
+func GetLibraryRoots(appName string) ([]LibraryRoot, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	var roots []LibraryRoot
+	err := historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(libraryRootsBucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var root LibraryRoot
+			if err := json.Unmarshal(v, &root); err == nil {
+				roots = append(roots, root)
+			}
+			return nil
+		})
+	})
+
+	return roots, err
+}
+
+func RemoveLibraryRoot(root string, appName string) error {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return err
+		}
+	}
+
+	root = filepath.Clean(root)
+	return historyDB.Update(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(libraryRootsBucket)); b != nil {
+			if err := b.Delete([]byte(root)); err != nil {
+				return err
+			}
+		}
+
+		b := tx.Bucket([]byte(libraryTracksBucket))
+		if b == nil {
+			return nil
+		}
+
+		var keysToDelete [][]byte
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var track LibraryTrack
+			if err := json.Unmarshal(v, &track); err == nil && track.Root == root {
+				keysToDelete = append(keysToDelete, append([]byte(nil), k...))
+			}
+		}
+
+		for _, k := range keysToDelete {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func QueryLibrary(query LibraryQuery, appName string) ([]LibraryTrack, error) {
+	tracks, err := GetLibraryTracks(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.ToLower(strings.TrimSpace(query.Text))
+	format := strings.ToLower(strings.TrimPrefix(query.Format, "."))
+	root := ""
+	if query.Root != "" {
+		root = filepath.Clean(query.Root)
+	}
+
+	var matches []LibraryTrack
+	for _, track := range tracks {
+		if root != "" && track.Root != root {
+			continue
+		}
+		if format != "" && (track.Quality == nil || track.Quality.Format != format) {
+			continue
+		}
+		if query.MinBitDepth > 0 && (track.Quality == nil || track.Quality.BitDepth < query.MinBitDepth) {
+			continue
+		}
+		if query.MaxBitDepth > 0 && (track.Quality == nil || track.Quality.BitDepth > query.MaxBitDepth) {
+			continue
+		}
+		if query.MissingCover && (track.HasCover || track.HasFolderCover) {
+			continue
+		}
+		if query.MissingLyrics && (track.HasLyrics || track.HasLRC) {
+			continue
+		}
+		if text != "" {
+			haystack := strings.ToLower(strings.Join([]string{track.Title, track.Artist, track.Album, track.AlbumArtist, track.Path}, " "))
+			if !strings.Contains(haystack, text) {
+				continue
+			}
+		}
+		matches = append(matches, track)
+	}
+
+	sortLibraryTracks(matches)
+
+	if query.Limit > 0 && len(matches) > query.Limit {
+		matches = matches[:query.Limit]
+	}
+
+	return matches, nil
+}
+
+func GetLibraryAlbumsMissingCovers(appName string) ([]LibraryAlbum, error) {
+	tracks, err := GetLibraryTracks(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	type albumState struct {
+		album    LibraryAlbum
+		hasCover bool
+	}
+
+	albums := make(map[string]*albumState)
+	var order []string
+
+	for _, track := range tracks {
+		dir := filepath.Dir(track.Path)
+		key := dir + "\x00" + strings.ToLower(track.Album)
+		state, ok := albums[key]
+		if !ok {
+			albumArtist := track.AlbumArtist
+			if albumArtist == "" {
+				albumArtist = track.Artist
+			}
+			state = &albumState{album: LibraryAlbum{
+				Directory:   dir,
+				Album:       track.Album,
+				AlbumArtist: albumArtist,
+			}}
+			albums[key] = state
+			order = append(order, key)
+		}
+		state.album.TrackCount++
+		state.album.Paths = append(state.album.Paths, track.Path)
+		if track.HasCover || track.HasFolderCover {
+			state.hasCover = true
+		}
+	}
+
+	sort.Strings(order)
+
+	var missing []LibraryAlbum
+	for _, key := range order {
+		if state := albums[key]; !state.hasCover {
+			sort.Strings(state.album.Paths)
+			missing = append(missing, state.album)
+		}
+	}
+
+	return missing, nil
+}
+
+func GetLibraryTracksWithoutLyrics(appName string) ([]LibraryTrack, error) {
+	return QueryLibrary(LibraryQuery{MissingLyrics: true}, appName)
+}
+
+func GetLibraryUpgradeCandidates(ctx context.Context, appName string) ([]LibraryUpgradeCandidate, error) {
+	tracks, err := GetLibraryTracks(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	best := make(map[string]LibraryTrack)
+	for _, track := range tracks {
+		if track.Quality == nil || !track.Quality.Lossless || track.Quality.BitDepth < 24 {
+			continue
+		}
+		key := libraryTrackKey(track)
+		if key == "" {
+			continue
+		}
+		if current, ok := best[key]; !ok || compareAudioQuality(track.Quality, current.Quality) > 0 {
+			best[key] = track
+		}
+	}
+
+	historyIDs := make(map[string]string)
+	if items, err := GetHistoryItems(appName); err == nil {
+		for _, item := range items {
+			if item.Path != "" && item.SpotifyID != "" {
+				historyIDs[item.Path] = item.SpotifyID
+			}
+		}
+	}
+
+	var candidates []LibraryUpgradeCandidate
+	var lookups []AlbumTrackMetadata
+	var lookupIndex []int
+	for _, track := range tracks {
+		if track.Quality == nil || track.Quality.BitDepth > 16 {
+			continue
+		}
+		candidate := LibraryUpgradeCandidate{Track: track, SpotifyID: historyIDs[track.Path]}
+		if better, ok := best[libraryTrackKey(track)]; ok && better.Path != track.Path {
+			candidate.Better = &better
+		}
+		if candidate.SpotifyID == "" {
+			if metadata, err := ExtractFullMetadataFromFile(track.Path); err == nil {
+				candidate.SpotifyID = spotifyIDFromURL(metadata.URL)
+			}
+		}
+		if candidate.SpotifyID != "" {
+			lookups = append(lookups, AlbumTrackMetadata{
+				SpotifyID: candidate.SpotifyID,
+				Name:      track.Title,
+				Artists:   track.Artist,
+				AlbumName: track.Album,
+			})
+			lookupIndex = append(lookupIndex, len(candidates))
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	if len(lookups) > 0 {
+		matrix, err := BuildAvailabilityMatrix(ctx, lookups, false, nil, appName)
+		if err != nil {
+			fmt.Printf("[Library] Availability check failed: %v\n", err)
+		} else {
+			for i, row := range matrix.Rows {
+				if row.Error != "" || row.Best == "" {
+					continue
+				}
+				candidate := &candidates[lookupIndex[i]]
+				service := row.bestService()
+				if isQualityUpgrade(candidate.Track.Quality, service.BitDepth, service.SampleRate) {
+					availability := row
+					candidate.Availability = &availability
+				}
+			}
+		}
+	}
+
+	filtered := candidates[:0]
+	for _, candidate := range candidates {
+		if candidate.Better != nil || candidate.Availability != nil {
+			filtered = append(filtered, candidate)
+		}
+	}
+
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Track.Path < filtered[j].Track.Path
+	})
+
+	return filtered, nil
+}
+
+func libraryTrackKey(track LibraryTrack) string {
+	title := normalizeForMatch(track.Title)
+	artist := normalizeForMatch(track.Artist)
+	if title == "" || artist == "" {
+		return ""
+	}
+	return artist + "|" + title
+}
+
+func sortLibraryTracks(tracks []LibraryTrack) {
+	sort.Slice(tracks, func(i, j int) bool {
+		a, b := tracks[i], tracks[j]
+		if filepath.Dir(a.Path) != filepath.Dir(b.Path) {
+			return a.Path < b.Path
+		}
+		if a.DiscNumber != b.DiscNumber {
+			return a.DiscNumber < b.DiscNumber
+		}
+		if a.TrackNumber != b.TrackNumber {
+			return a.TrackNumber < b.TrackNumber
+		}
+		return a.Path < b.Path
+	})
+}
+
+func normalizeForMatch(s string) string {
+	var b strings.Builder
+	lastSpace := true
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			lastSpace = false
+		} else if !lastSpace {
+			b.WriteRune(' ')
+			lastSpace = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,233 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type ExternalLinkResolution struct {
+	InputURL   string            `json:"input_url"`
+	Platform   string            `json:"platform"`
+	Type       string            `json:"type"`
+	Title      string            `json:"title,omitempty"`
+	Artist     string            `json:"artist,omitempty"`
+	SpotifyURL string            `json:"spotify_url,omitempty"`
+	SpotifyID  string            `json:"spotify_id,omitempty"`
+	ISRC       string            `json:"isrc,omitempty"`
+	Links      map[string]string `json:"links"`
+}
+
+var linkPlatformHosts = map[string]string{
+	"tidal.com":         "tidal",
+	"qobuz.com":         "qobuz",
+	"deezer.com":        "deezer",
+	"deezer.page.link":  "deezer",
+	"music.apple.com":   "appleMusic",
+	"itunes.apple.com":  "itunes",
+	"music.youtube.com": "youtubeMusic",
+	"youtube.com":       "youtube",
+	"youtu.be":          "youtube",
+	"music.amazon.com":  "amazonMusic",
+	"soundcloud.com":    "soundcloud",
+	"song.link":         "songlink",
+	"album.link":        "songlink",
+	"odesli.co":         "songlink",
+}
+
+func IsSpotifyURL(input string) bool {
+	_, err := parseSpotifyURI(input)
+	return err == nil
+}
+
+func DetectLinkPlatform(link string) string {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+	for {
+		if platform, ok := linkPlatformHosts[host]; ok {
+			return platform
+		}
+		idx := strings.IndexByte(host, '.')
+		if idx < 0 {
+			return ""
+		}
+		host = host[idx+1:]
+	}
+}
+
+func (s *SongLinkClient) ResolveLink(link string) (*ExternalLinkResolution, error) {
+	fmt.Printf("Resolving link via song.link: %s\n", link)
+
+	songLinkResp, err := s.fetch(strings.TrimSpace(link), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve link: %w", err)
+	}
+
+	resolution := &ExternalLinkResolution{
+		InputURL: link,
+		Platform: DetectLinkPlatform(link),
+		Links:    songLinkResp.links(),
+	}
+
+	if entity, ok := songLinkResp.EntitiesByUniqueID[songLinkResp.EntityUniqueID]; ok {
+		resolution.Type = entity.Type
+		resolution.Title = entity.Title
+		resolution.Artist = entity.ArtistName
+	}
+
+	if spotifyLink, ok := songLinkResp.LinksByPlatform["spotify"]; ok && spotifyLink.URL != "" {
+		if parsed, err := parseSpotifyURI(spotifyLink.URL); err == nil {
+			resolution.SpotifyID = parsed.ID
+			resolution.SpotifyURL = fmt.Sprintf("https://open.spotify.com/%s/%s", parsed.Type, parsed.ID)
+			if resolution.Type == "" {
+				resolution.Type = parsed.Type
+			}
+		} else if idx := strings.LastIndex(spotifyLink.EntityUniqueID, "::"); idx >= 0 {
+			resolution.SpotifyID = spotifyLink.EntityUniqueID[idx+2:]
+		}
+	}
+	if resolution.Type == "song" {
+		resolution.Type = "track"
+	}
+
+	if resolution.Type == "track" && resolution.SpotifyID != "" {
+		storeSongLinkCache(resolution.SpotifyID, "", resolution.Links)
+	}
+
+	if resolution.Type == "track" {
+		if isrcResolution, err := ResolveISRC(s.ctx, ISRCQuery{
+			SpotifyID:  resolution.SpotifyID,
+			TrackName:  resolution.Title,
+			ArtistName: resolution.Artist,
+			DeezerURL:  resolution.Links["deezer"],
+			TidalURL:   resolution.Links["tidal"],
+		}); err == nil {
+			resolution.ISRC = isrcResolution.ISRC
+		} else {
+			fmt.Printf("Could not resolve ISRC for %s: %v\n", link, err)
+		}
+	}
+
+	if resolution.SpotifyID == "" {
+		fmt.Printf("song.link has no Spotify match for %s\n", link)
+	}
+
+	return resolution, nil
+}
+
+func ResolveExternalLink(ctx context.Context, link string, delay time.Duration) (interface{}, *ExternalLinkResolution, error) {
+	catalogLink, isCatalog := ParseCatalogLink(link)
+
+	var resolution *ExternalLinkResolution
+	if !isCatalog || catalogLink.Type != "playlist" {
+		var err error
+		resolution, err = NewSongLinkClient().WithContext(ctx).ResolveLink(link)
+		if err != nil {
+			fmt.Printf("song.link lookup failed: %v\n", err)
+		}
+		if resolution != nil && resolution.SpotifyURL != "" {
+			data, err := GetFilteredSpotifyData(ctx, resolution.SpotifyURL, false, delay)
+			if err != nil {
+				return nil, resolution, fmt.Errorf("failed to fetch Spotify metadata for %s: %w", resolution.SpotifyURL, err)
+			}
+			return data, resolution, nil
+		}
+	}
+
+	if !isCatalog || catalogLink.Type == "track" {
+		return nil, resolution, fmt.Errorf("could not resolve %s to a Spotify link", link)
+	}
+
+	collection, err := GetCatalogCollection(catalogLink.Service, catalogLink.Type, catalogLink.ID)
+	if err != nil {
+		return nil, resolution, err
+	}
+
+	fmt.Printf("[LinkResolver] Expanding %s %s %q (%d tracks)\n", collection.Service, collection.Type, collection.Title, len(collection.Tracks))
+	tracks := matchCatalogTracks(ctx, collection, delay)
+	if len(tracks) == 0 {
+		return nil, resolution, fmt.Errorf("none of the %d tracks in %s could be matched on Spotify", len(collection.Tracks), link)
+	}
+
+	if collection.Type == "album" {
+		return &AlbumResponsePayload{
+			AlbumInfo: AlbumInfoMetadata{
+				TotalTracks: len(collection.Tracks),
+				Name:        collection.Title,
+				ReleaseDate: collection.ReleaseDate,
+				Artists:     collection.Artist,
+				Images:      collection.CoverURL,
+			},
+			TrackList: tracks,
+		}, resolution, nil
+	}
+
+	var info PlaylistInfoMetadata
+	info.Tracks.Total = len(collection.Tracks)
+	info.Owner.DisplayName = collection.Artist
+	info.Owner.Name = collection.Title
+	info.Owner.Images = collection.CoverURL
+	info.Cover = collection.CoverURL
+
+	return PlaylistResponsePayload{
+		PlaylistInfo: info,
+		TrackList:    tracks,
+	}, resolution, nil
+}
+
+func matchCatalogTracks(ctx context.Context, collection *CatalogCollection, delay time.Duration) []AlbumTrackMetadata {
+	client := NewSpotifyMetadataClient()
+	tracks := make([]AlbumTrackMetadata, 0, len(collection.Tracks))
+
+	for i, native := range collection.Tracks {
+		if ctx.Err() != nil {
+			break
+		}
+		if i > 0 && delay > 0 {
+			time.Sleep(delay)
+		}
+
+		match := resolveImportedTrack(ctx, client, ImportedTrack{
+			Artist:     native.Artist,
+			Title:      native.Title,
+			Album:      native.Album,
+			ISRC:       native.ISRC,
+			DurationMS: native.DurationMS,
+		})
+		if match.Match == nil || match.Confidence < 0.8 {
+			fmt.Printf("[LinkResolver] No Spotify match for %s - %s\n", native.Artist, native.Title)
+			continue
+		}
+
+		trackNumber := native.TrackNumber
+		if collection.Type == "playlist" {
+			trackNumber = 0
+		}
+
+		tracks = append(tracks, AlbumTrackMetadata{
+			SpotifyID:   match.Match.ID,
+			Artists:     match.Match.Artists,
+			Name:        match.Match.Name,
+			AlbumName:   match.Match.AlbumName,
+			AlbumArtist: native.AlbumArtist,
+			DurationMS:  match.Match.Duration,
+			Images:      match.Match.Images,
+			ReleaseDate: match.Match.ReleaseDate,
+			TrackNumber: trackNumber,
+			TotalTracks: len(collection.Tracks),
+			DiscNumber:  native.DiscNumber,
+			TotalDiscs:  collection.TotalDiscs,
+			ExternalURL: fmt.Sprintf("https://open.spotify.com/track/%s", match.Match.ID),
+			ISRC:        match.Match.ID,
+			IsExplicit:  native.Explicit || match.Match.IsExplicit,
+		})
+	}
+
+	return tracks
+}
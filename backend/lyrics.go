@@ -9,7 +9,6 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 )
@@ -26,16 +25,24 @@ type LRCLibResponse struct {
 	SyncedLyrics string  `json:"syncedLyrics"`
 }
 
-type LyricsLine struct {
+type LyricsSyllable struct {
 	StartTimeMs string `json:"startTimeMs"`
-	Words       string `json:"words"`
-	EndTimeMs   string `json:"endTimeMs"`
+	EndTimeMs   string `json:"endTimeMs,omitempty"`
+	Text        string `json:"text"`
+}
+
+type LyricsLine struct {
+	StartTimeMs string           `json:"startTimeMs"`
+	Words       string           `json:"words"`
+	EndTimeMs   string           `json:"endTimeMs"`
+	Syllables   []LyricsSyllable `json:"syllables,omitempty"`
 }
 
 type LyricsResponse struct {
-	Error    bool         `json:"error"`
-	SyncType string       `json:"syncType"`
-	Lines    []LyricsLine `json:"lines"`
+	Error    bool            `json:"error"`
+	SyncType string          `json:"syncType"`
+	Lines    []LyricsLine    `json:"lines"`
+	Variants []LyricsVariant `json:"variants,omitempty"`
 }
 
 type LyricsDownloadRequest struct {
@@ -51,6 +58,9 @@ type LyricsDownloadRequest struct {
 	Position            int    `json:"position"`
 	UseAlbumTrackNumber bool   `json:"use_album_track_number"`
 	DiscNumber          int    `json:"disc_number"`
+	Format              string `json:"format,omitempty"`
+	ISRC                string `json:"isrc,omitempty"`
+	Refresh             bool   `json:"refresh,omitempty"`
 }
 
 type LyricsDownloadResponse struct {
@@ -63,6 +73,7 @@ type LyricsDownloadResponse struct {
 
 type LyricsClient struct {
 	httpClient *http.Client
+	cacheApp   string
 }
 
 func NewLyricsClient() *LyricsClient {
@@ -71,7 +82,13 @@ func NewLyricsClient() *LyricsClient {
 	}
 }
 
-func (c *LyricsClient) FetchLyricsWithMetadata(trackName, artistName string, duration int) (*LyricsResponse, error) {
+func NewCachedLyricsClient(appName string) *LyricsClient {
+	client := NewLyricsClient()
+	client.cacheApp = appName
+	return client
+}
+
+func (c *LyricsClient) getLRCLib(trackName, artistName string, duration int) (*LRCLibResponse, error) {
 
 	apiBase, _ := base64.StdEncoding.DecodeString("aHR0cHM6Ly9scmNsaWIubmV0L2FwaS9nZXQ/YXJ0aXN0X25hbWU9")
 	apiURL := fmt.Sprintf("%s%s&track_name=%s",
@@ -89,6 +106,9 @@ func (c *LyricsClient) FetchLyricsWithMetadata(trackName, artistName string, dur
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w on LRCLIB", errLyricsNotFound)
+	}
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("LRCLIB returned status %d", resp.StatusCode)
 	}
@@ -103,69 +123,44 @@ func (c *LyricsClient) FetchLyricsWithMetadata(trackName, artistName string, dur
 		return nil, fmt.Errorf("failed to parse LRCLIB response: %v", err)
 	}
 
-	return c.convertLRCLibToLyricsResponse(&lrcLibResp), nil
+	return &lrcLibResp, nil
 }
 
-func (c *LyricsClient) convertLRCLibToLyricsResponse(lrcLib *LRCLibResponse) *LyricsResponse {
-	resp := &LyricsResponse{
-		Error:    false,
-		SyncType: "LINE_SYNCED",
-		Lines:    []LyricsLine{},
+func (c *LyricsClient) FetchLyricsWithMetadata(trackName, artistName string, duration int) (*LyricsResponse, error) {
+	lrcLibResp, err := c.getLRCLib(trackName, artistName, duration)
+	if err != nil {
+		return nil, err
 	}
+	return c.convertLRCLibToLyricsResponse(lrcLibResp), nil
+}
 
-	lyricsText := lrcLib.SyncedLyrics
-	if lyricsText == "" {
-		lyricsText = lrcLib.PlainLyrics
-		resp.SyncType = "UNSYNCED"
+func (c *LyricsClient) convertLRCLibToLyricsResponse(lrcLib *LRCLibResponse) *LyricsResponse {
+	if lrcLib.SyncedLyrics != "" {
+		if resp := ParseLRC(lrcLib.SyncedLyrics); !resp.Error && len(resp.Lines) > 0 {
+			return resp
+		}
 	}
 
-	if lyricsText == "" {
-		resp.Error = true
-		return resp
+	resp := &LyricsResponse{
+		Error:    false,
+		SyncType: LyricsSyncNone,
+		Lines:    []LyricsLine{},
 	}
-
-	lines := strings.Split(lyricsText, "\n")
-	for _, line := range lines {
+	for _, line := range strings.Split(lrcLib.PlainLyrics, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
-
-		if strings.HasPrefix(line, "[") && len(line) > 10 {
-			closeBracket := strings.Index(line, "]")
-			if closeBracket > 0 {
-				timestamp := line[1:closeBracket]
-				words := strings.TrimSpace(line[closeBracket+1:])
-
-				ms := lrcTimestampToMs(timestamp)
-				resp.Lines = append(resp.Lines, LyricsLine{
-					StartTimeMs: fmt.Sprintf("%d", ms),
-					Words:       words,
-				})
-				continue
-			}
-		}
-
-		resp.Lines = append(resp.Lines, LyricsLine{
-			StartTimeMs: "",
-			Words:       line,
-		})
+		resp.Lines = append(resp.Lines, LyricsLine{Words: line})
+	}
+	if len(resp.Lines) == 0 {
+		resp.Error = true
 	}
 
 	return resp
 }
 
-func lrcTimestampToMs(timestamp string) int64 {
-	var minutes, seconds, centiseconds int64
-
-	n, _ := fmt.Sscanf(timestamp, "%d:%d.%d", &minutes, &seconds, &centiseconds)
-	if n >= 2 {
-		return minutes*60*1000 + seconds*1000 + centiseconds*10
-	}
-	return 0
-}
-
-func (c *LyricsClient) FetchLyricsFromLRCLibSearch(trackName, artistName string) (*LyricsResponse, error) {
+func (c *LyricsClient) searchLRCLib(trackName, artistName string) ([]LRCLibResponse, error) {
 	query := fmt.Sprintf("%s %s", artistName, trackName)
 	apiBase, _ := base64.StdEncoding.DecodeString("aHR0cHM6Ly9scmNsaWIubmV0L2FwaS9zZWFyY2g/cT0=")
 	apiURL := fmt.Sprintf("%s%s", string(apiBase), url.QueryEscape(query))
@@ -191,7 +186,16 @@ func (c *LyricsClient) FetchLyricsFromLRCLibSearch(trackName, artistName string)
 	}
 
 	if len(results) == 0 {
-		return nil, fmt.Errorf("no results found")
+		return nil, fmt.Errorf("%w: no results found", errLyricsNotFound)
+	}
+
+	return results, nil
+}
+
+func (c *LyricsClient) FetchLyricsFromLRCLibSearch(trackName, artistName string) (*LyricsResponse, error) {
+	results, err := c.searchLRCLib(trackName, artistName)
+	if err != nil {
+		return nil, err
 	}
 
 	var best *LRCLibResponse
@@ -225,35 +229,27 @@ func simplifyTrackName(name string) string {
 }
 
 func (c *LyricsClient) FetchLyricsAllSources(spotifyID, trackName, artistName string, duration int) (*LyricsResponse, string, error) {
+	return c.FetchLyricsForQuery(LyricsQuery{
+		SpotifyID:   spotifyID,
+		TrackName:   trackName,
+		ArtistName:  artistName,
+		DurationSec: duration,
+	}, false)
+}
 
-	resp, err := c.FetchLyricsWithMetadata(trackName, artistName, duration)
-	if err == nil && resp != nil && !resp.Error && len(resp.Lines) > 0 {
-		return resp, "LRCLIB", nil
-	}
-	fmt.Printf("   LRCLIB exact: %v\n", err)
-
-	resp, err = c.FetchLyricsFromLRCLibSearch(trackName, artistName)
-	if err == nil && resp != nil && !resp.Error && len(resp.Lines) > 0 {
-		return resp, "LRCLIB Search", nil
+func (c *LyricsClient) FetchLyricsForQuery(query LyricsQuery, refresh bool) (*LyricsResponse, string, error) {
+	var best *LyricsCandidate
+	var err error
+	if c.cacheApp != "" {
+		best, err = c.FetchLyricsCached(query, refresh, c.cacheApp)
+	} else {
+		best, err = c.FetchLyricsChain(query)
 	}
-	fmt.Printf("   LRCLIB search: %v\n", err)
-
-	simplifiedTrack := simplifyTrackName(trackName)
-	if simplifiedTrack != trackName {
-		fmt.Printf("   Trying simplified name: %s\n", simplifiedTrack)
-
-		resp, err = c.FetchLyricsWithMetadata(simplifiedTrack, artistName, duration)
-		if err == nil && resp != nil && !resp.Error && len(resp.Lines) > 0 {
-			return resp, "LRCLIB (simplified)", nil
-		}
-
-		resp, err = c.FetchLyricsFromLRCLibSearch(simplifiedTrack, artistName)
-		if err == nil && resp != nil && !resp.Error && len(resp.Lines) > 0 {
-			return resp, "LRCLIB Search (simplified)", nil
-		}
+	if err != nil {
+		return nil, "", err
 	}
-
-	return nil, "", fmt.Errorf("lyrics not found in any source")
+	ApplyLyricsVariants(best.Lyrics, GetLyricsProviderConfig().Variants)
+	return best.Lyrics, best.Provider, nil
 }
 
 func (c *LyricsClient) ConvertToLRC(lyrics *LyricsResponse, trackName, artistName string) string {
@@ -264,87 +260,43 @@ func (c *LyricsClient) ConvertToLRC(lyrics *LyricsResponse, trackName, artistNam
 	sb.WriteString("[by:SpotiFlac]\n")
 	sb.WriteString("\n")
 
-	for _, line := range lyrics.Lines {
-		if line.Words == "" {
+	for i, line := range lyrics.Lines {
+		if line.Words == "" && len(line.Syllables) == 0 {
 			continue
 		}
 
-		if line.StartTimeMs == "" {
+		start, timed := lyricsMs(line.StartTimeMs)
+		if !timed {
 			sb.WriteString(fmt.Sprintf("%s\n", line.Words))
-		} else {
-
-			timestamp := msToLRCTimestamp(line.StartTimeMs)
-			sb.WriteString(fmt.Sprintf("%s%s\n", timestamp, line.Words))
-		}
-	}
-
-	return sb.String()
-}
-
-func msToLRCTimestamp(msStr string) string {
-	var ms int64
-	fmt.Sscanf(msStr, "%d", &ms)
-
-	totalSeconds := ms / 1000
-	minutes := totalSeconds / 60
-	seconds := totalSeconds % 60
-	centiseconds := (ms % 1000) / 10
-
-	return fmt.Sprintf("[%02d:%02d.%02d]", minutes, seconds, centiseconds)
-}
-
-func buildLyricsFilename(trackName, artistName, albumName, albumArtist, releaseDate, filenameFormat string, includeTrackNumber bool, position, discNumber int) string {
-	safeTitle := sanitizeFilename(trackName)
-	safeArtist := sanitizeFilename(artistName)
-	safeAlbum := sanitizeFilename(albumName)
-	safeAlbumArtist := sanitizeFilename(albumArtist)
-
-	year := ""
-	if len(releaseDate) >= 4 {
-		year = releaseDate[:4]
-	}
-
-	var filename string
-
-	if strings.Contains(filenameFormat, "{") {
-		filename = filenameFormat
-		filename = strings.ReplaceAll(filename, "{title}", safeTitle)
-		filename = strings.ReplaceAll(filename, "{artist}", safeArtist)
-		filename = strings.ReplaceAll(filename, "{album}", safeAlbum)
-		filename = strings.ReplaceAll(filename, "{album_artist}", safeAlbumArtist)
-		filename = strings.ReplaceAll(filename, "{year}", year)
-
-		if discNumber > 0 {
-			filename = strings.ReplaceAll(filename, "{disc}", fmt.Sprintf("%d", discNumber))
-		} else {
-			filename = strings.ReplaceAll(filename, "{disc}", "")
+			continue
 		}
 
-		if position > 0 {
-			filename = strings.ReplaceAll(filename, "{track}", fmt.Sprintf("%02d", position))
+		sb.WriteString(fmt.Sprintf("[%s]", formatLRCTime(start)))
+		if len(line.Syllables) == 0 {
+			sb.WriteString(line.Words)
 		} else {
-
-			filename = regexp.MustCompile(`\{track\}\.\s*`).ReplaceAllString(filename, "")
-			filename = regexp.MustCompile(`\{track\}\s*-\s*`).ReplaceAllString(filename, "")
-			filename = regexp.MustCompile(`\{track\}\s*`).ReplaceAllString(filename, "")
-		}
-	} else {
-
-		switch filenameFormat {
-		case "artist-title":
-			filename = fmt.Sprintf("%s - %s", safeArtist, safeTitle)
-		case "title":
-			filename = safeTitle
-		default:
-			filename = fmt.Sprintf("%s - %s", safeTitle, safeArtist)
+			for _, syllable := range line.Syllables {
+				ms, _ := lyricsMs(syllable.StartTimeMs)
+				sb.WriteString(fmt.Sprintf("<%s>%s", formatLRCTime(ms), syllable.Text))
+			}
+			if end, ok := lyricsMs(line.Syllables[len(line.Syllables)-1].EndTimeMs); ok {
+				sb.WriteString(fmt.Sprintf("<%s>", formatLRCTime(end)))
+			}
 		}
+		sb.WriteString("\n")
 
-		if includeTrackNumber && position > 0 {
-			filename = fmt.Sprintf("%02d. %s", position, filename)
+		if end, ok := lyricsMs(line.EndTimeMs); ok && end > start {
+			next, hasNext := int64(0), false
+			if i+1 < len(lyrics.Lines) {
+				next, hasNext = lyricsMs(lyrics.Lines[i+1].StartTimeMs)
+			}
+			if !hasNext || next > end {
+				sb.WriteString(fmt.Sprintf("[%s]\n", formatLRCTime(end)))
+			}
 		}
 	}
 
-	return filename + ".lrc"
+	return sb.String()
 }
 
 func findAudioFileForLyrics(dir, trackName, artistName string) string {
@@ -433,8 +385,26 @@ func (c *LyricsClient) DownloadLyrics(req LyricsDownloadRequest) (*LyricsDownloa
 	if filenameFormat == "" {
 		filenameFormat = "title-artist"
 	}
-	filename := buildLyricsFilename(req.TrackName, req.ArtistName, req.AlbumName, req.AlbumArtist, req.ReleaseDate, filenameFormat, req.TrackNumber, req.Position, req.DiscNumber)
-	filePath := filepath.Join(outputDir, filename)
+	filename := RenderFilename(filenameFormat, TemplateData{
+		Title:       req.TrackName,
+		Artist:      req.ArtistName,
+		Album:       req.AlbumName,
+		AlbumArtist: req.AlbumArtist,
+		ReleaseDate: req.ReleaseDate,
+		Track:       req.Position,
+		Disc:        req.DiscNumber,
+	}, req.TrackNumber, ".lrc")
+	filePath := FitPath(outputDir, filename)
+	if req.Format == "ttml" {
+		filePath = strings.TrimSuffix(filePath, ".lrc") + ".ttml"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return &LyricsDownloadResponse{
+			Success: false,
+			Error:   fmt.Sprintf("failed to create output directory: %v", err),
+		}, err
+	}
 
 	if fileInfo, err := os.Stat(filePath); err == nil && fileInfo.Size() > 0 {
 		return &LyricsDownloadResponse{
@@ -446,7 +416,7 @@ func (c *LyricsClient) DownloadLyrics(req LyricsDownloadRequest) (*LyricsDownloa
 	}
 
 	audioDuration := 0
-	audioFile := findAudioFileForLyrics(outputDir, req.TrackName, req.ArtistName)
+	audioFile := findAudioFileForLyrics(filepath.Dir(filePath), req.TrackName, req.ArtistName)
 	if audioFile != "" {
 		duration, err := GetAudioDuration(audioFile)
 		if err == nil && duration > 0 {
@@ -455,7 +425,14 @@ func (c *LyricsClient) DownloadLyrics(req LyricsDownloadRequest) (*LyricsDownloa
 		}
 	}
 
-	lyrics, _, err := c.FetchLyricsAllSources(req.SpotifyID, req.TrackName, req.ArtistName, audioDuration)
+	lyrics, _, err := c.FetchLyricsForQuery(LyricsQuery{
+		SpotifyID:   req.SpotifyID,
+		ISRC:        req.ISRC,
+		TrackName:   req.TrackName,
+		ArtistName:  req.ArtistName,
+		AlbumName:   req.AlbumName,
+		DurationSec: audioDuration,
+	}, req.Refresh)
 	if err != nil {
 		return &LyricsDownloadResponse{
 			Success: false,
@@ -463,15 +440,36 @@ func (c *LyricsClient) DownloadLyrics(req LyricsDownloadRequest) (*LyricsDownloa
 		}, err
 	}
 
-	lrcContent := c.ConvertToLRC(lyrics, req.TrackName, req.ArtistName)
+	if audioFile != "" && GetLyricsProviderConfig().AutoAlign {
+		if alignment, err := AlignLyricsToAudio(audioFile, lyrics); err != nil {
+			fmt.Printf("[DownloadLyrics] Alignment skipped: %v\n", err)
+		} else if alignment.Applied {
+			fmt.Printf("[DownloadLyrics] Shifted lyrics by %dms (confidence %.2f)\n", alignment.ShiftMs, alignment.Confidence)
+		}
+	}
 
-	if err := os.WriteFile(filePath, []byte(lrcContent), 0644); err != nil {
+	content := c.ConvertToLRC(lyrics, req.TrackName, req.ArtistName)
+	if req.Format == "ttml" {
+		content = c.ConvertToTTML(lyrics, req.TrackName, req.ArtistName)
+	}
+
+	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
 		return &LyricsDownloadResponse{
 			Success: false,
-			Error:   fmt.Sprintf("failed to write LRC file: %v", err),
+			Error:   fmt.Sprintf("failed to write lyrics file: %v", err),
 		}, err
 	}
 
+	c.WriteLyricsVariantFiles(filePath, lyrics, req.TrackName, req.ArtistName)
+
+	if req.Format == "both" {
+		ttmlPath := strings.TrimSuffix(filePath, ".lrc") + ".ttml"
+		if err := os.WriteFile(ttmlPath, []byte(c.ConvertToTTML(lyrics, req.TrackName, req.ArtistName)), 0644); err != nil {
+			fmt.Printf("[DownloadLyrics] Warning: failed to write TTML file: %v\n", err)
+		}
+		c.WriteLyricsVariantFiles(ttmlPath, lyrics, req.TrackName, req.ArtistName)
+	}
+
 	return &LyricsDownloadResponse{
 		Success: true,
 		Message: "Lyrics downloaded successfully",
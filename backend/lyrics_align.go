@@ -0,0 +1,370 @@
+package backend
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type LyricsAlignment struct {
+	ShiftMs          int64   `json:"shift_ms"`
+	Confidence       float64 `json:"confidence"`
+	Method           string  `json:"method"`
+	LeadingSilenceMs int64   `json:"leading_silence_ms"`
+	FirstLyricMs     int64   `json:"first_lyric_ms"`
+	LinesCompared    int     `json:"lines_compared"`
+	Mode             string  `json:"mode,omitempty"`
+	File             string  `json:"file,omitempty"`
+	Applied          bool    `json:"applied"`
+}
+
+type LyricsAlignRequest struct {
+	AudioPath     string  `json:"audio_path"`
+	LyricsPath    string  `json:"lyrics_path,omitempty"`
+	Mode          string  `json:"mode"`
+	MinConfidence float64 `json:"min_confidence,omitempty"`
+}
+
+const (
+	LyricsAlignReport  = "report"
+	LyricsAlignTag     = "tag"
+	LyricsAlignRewrite = "rewrite"
+
+	alignSampleRate    = 8000
+	alignFrameMs       = 20
+	alignMaxShiftMs    = 15000
+	alignMaxLines      = 40
+	alignToleranceMs   = 200
+	alignMinConfidence = 0.6
+)
+
+var (
+	lrcStampPattern     = regexp.MustCompile(`([\[<])(\d+:\d+(?:[.:]\d+)?)([\]>])`)
+	lrcOffsetTagPattern = regexp.MustCompile(`(?im)^[ \t]*\[offset:[ \t]*([+-]?\d+)[ \t]*\][ \t\r]*$`)
+)
+
+func decodeAlignmentPCM(filePath string, maxSeconds int) ([]float64, error) {
+	if installed, _ := IsFFmpegInstalled(); installed {
+		return decodePCMWithFFmpeg(filePath, alignSampleRate, maxSeconds)
+	}
+
+	if strings.ToLower(filepath.Ext(filePath)) != ".flac" {
+		return nil, fmt.Errorf("ffmpeg is required to decode %s", filepath.Ext(filePath))
+	}
+
+	return decodeFLACResampled(filePath, alignSampleRate, maxSeconds)
+}
+
+func vocalOnsetEnvelope(samples []float64) ([]float64, []float64) {
+	frameSize := alignSampleRate * alignFrameMs / 1000
+	frames := len(samples) / frameSize
+
+	w0 := 2 * math.Pi * 1000 / alignSampleRate
+	alpha := math.Sin(w0) / (2 * 0.7)
+	a0 := 1 + alpha
+	b0, b2 := alpha/a0, -alpha/a0
+	a1, a2 := -2*math.Cos(w0)/a0, (1-alpha)/a0
+
+	rms := make([]float64, frames)
+	bandLog := make([]float64, frames)
+	var x1, x2, y1, y2 float64
+	for f := 0; f < frames; f++ {
+		var total, band float64
+		for _, x := range samples[f*frameSize : (f+1)*frameSize] {
+			y := b0*x + b2*x2 - a1*y1 - a2*y2
+			x2, x1 = x1, x
+			y2, y1 = y1, y
+			total += x * x
+			band += y * y
+		}
+		rms[f] = math.Sqrt(total / float64(frameSize))
+		bandLog[f] = math.Log10(band/float64(frameSize) + 1)
+	}
+
+	onset := make([]float64, frames)
+	for f := 1; f < frames; f++ {
+		from := f - 5
+		if from < 0 {
+			from = 0
+		}
+		var sum float64
+		for _, v := range bandLog[from:f] {
+			sum += v
+		}
+		if rise := bandLog[f] - sum/float64(f-from); rise > 0 {
+			onset[f] = rise
+		}
+	}
+
+	return rms, onset
+}
+
+func EstimateLyricsOffset(audioPath string, lyrics *LyricsResponse) (*LyricsAlignment, error) {
+	if lyrics == nil || lyrics.SyncType == LyricsSyncNone {
+		return nil, fmt.Errorf("lyrics are not synced")
+	}
+
+	var starts []int64
+	for _, line := range lyrics.Lines {
+		if strings.TrimSpace(line.Words) == "" {
+			continue
+		}
+		if ms, ok := lyricsMs(line.StartTimeMs); ok {
+			starts = append(starts, ms)
+		}
+		if len(starts) >= alignMaxLines {
+			break
+		}
+	}
+	if len(starts) < 3 {
+		return nil, fmt.Errorf("not enough timed lines to align")
+	}
+
+	maxSeconds := int((starts[len(starts)-1]+alignMaxShiftMs)/1000) + 5
+	samples, err := decodeAlignmentPCM(audioPath, maxSeconds)
+	if err != nil {
+		return nil, err
+	}
+	return alignSamplesToLines(samples, starts)
+}
+
+func alignSamplesToLines(samples []float64, starts []int64) (*LyricsAlignment, error) {
+	rms, onset := vocalOnsetEnvelope(samples)
+	if len(onset) == 0 {
+		return nil, fmt.Errorf("audio too short to align")
+	}
+
+	result := &LyricsAlignment{
+		Method:        "onset_correlation",
+		FirstLyricMs:  starts[0],
+		LinesCompared: len(starts),
+	}
+
+	silenceLevel := 32768 * math.Pow(10, -50.0/20)
+	for f, level := range rms {
+		if level > silenceLevel {
+			result.LeadingSilenceMs = int64(f * alignFrameMs)
+			break
+		}
+	}
+
+	maxLag := alignMaxShiftMs / alignFrameMs
+	scores := make([]float64, 2*maxLag+1)
+	for i := range scores {
+		lag := i - maxLag
+		for _, start := range starts {
+			center := int(start/alignFrameMs) + lag
+			peak := 0.0
+			for f := center - 3; f <= center+3; f++ {
+				if f >= 0 && f < len(onset) && onset[f] > peak {
+					peak = onset[f]
+				}
+			}
+			scores[i] += peak
+		}
+	}
+
+	bestIdx := maxLag
+	var mean float64
+	for i, score := range scores {
+		mean += score
+		if score > scores[bestIdx] {
+			bestIdx = i
+		}
+	}
+	mean /= float64(len(scores))
+
+	var variance, second float64
+	for i, score := range scores {
+		variance += (score - mean) * (score - mean)
+		if (i < bestIdx-15 || i > bestIdx+15) && score > second {
+			second = score
+		}
+	}
+	std := math.Sqrt(variance / float64(len(scores)))
+
+	best := scores[bestIdx]
+	if std > 0 && best > 0 {
+		z := (best - mean) / std
+		result.Confidence = clamp01((z-2.5)/4) * clamp01((best-second)/(0.2*best))
+	}
+
+	result.ShiftMs = int64((bestIdx - maxLag) * alignFrameMs)
+	if result.ShiftMs > -alignToleranceMs && result.ShiftMs < alignToleranceMs {
+		result.ShiftMs = 0
+	}
+
+	if starts[0]+result.ShiftMs < result.LeadingSilenceMs-500 {
+		result.Confidence *= 0.5
+	}
+
+	if result.Confidence < alignMinConfidence && starts[0]+1000 < result.LeadingSilenceMs {
+		result.Method = "leading_silence"
+		result.ShiftMs = result.LeadingSilenceMs - starts[0]
+		result.Confidence = 0.65
+	}
+
+	result.Confidence = math.Round(result.Confidence*100) / 100
+	return result, nil
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+func shiftMsString(value string, delta int64) string {
+	ms, ok := lyricsMs(value)
+	if !ok {
+		return value
+	}
+	if ms+delta < 0 {
+		return "0"
+	}
+	return msString(ms + delta)
+}
+
+func ShiftLyrics(lyrics *LyricsResponse, deltaMs int64) {
+	if lyrics == nil || deltaMs == 0 {
+		return
+	}
+	shiftLines(lyrics.Lines, deltaMs)
+	for i := range lyrics.Variants {
+		shiftLines(lyrics.Variants[i].Lines, deltaMs)
+	}
+}
+
+func shiftLines(lines []LyricsLine, deltaMs int64) {
+	for i := range lines {
+		line := &lines[i]
+		line.StartTimeMs = shiftMsString(line.StartTimeMs, deltaMs)
+		line.EndTimeMs = shiftMsString(line.EndTimeMs, deltaMs)
+		for j := range line.Syllables {
+			line.Syllables[j].StartTimeMs = shiftMsString(line.Syllables[j].StartTimeMs, deltaMs)
+			line.Syllables[j].EndTimeMs = shiftMsString(line.Syllables[j].EndTimeMs, deltaMs)
+		}
+	}
+}
+
+func lrcOffsetTag(content string) int64 {
+	if m := lrcOffsetTagPattern.FindStringSubmatch(content); m != nil {
+		n, _ := strconv.ParseInt(m[1], 10, 64)
+		return n
+	}
+	return 0
+}
+
+func setLRCOffsetTag(content string, offset int64) string {
+	tag := fmt.Sprintf("[offset:%d]", offset)
+	if lrcOffsetTagPattern.MatchString(content) {
+		return lrcOffsetTagPattern.ReplaceAllString(content, tag)
+	}
+
+	lines := strings.Split(content, "\n")
+	insertAt := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "[") || lrcStampPattern.MatchString(trimmed) {
+			break
+		}
+		insertAt = i + 1
+	}
+
+	lines = append(lines[:insertAt], append([]string{tag}, lines[insertAt:]...)...)
+	return strings.Join(lines, "\n")
+}
+
+func shiftLRCContent(content string, deltaMs int64) string {
+	deltaMs -= lrcOffsetTag(content)
+	content = lrcOffsetTagPattern.ReplaceAllString(content, "")
+
+	return lrcStampPattern.ReplaceAllStringFunc(content, func(match string) string {
+		parts := lrcStampPattern.FindStringSubmatch(match)
+		ms, ok := parseLRCTime(parts[2])
+		if !ok {
+			return match
+		}
+		return parts[1] + formatLRCTime(ms+deltaMs) + parts[3]
+	})
+}
+
+func AlignLyricsToAudio(audioPath string, lyrics *LyricsResponse) (*LyricsAlignment, error) {
+	alignment, err := EstimateLyricsOffset(audioPath, lyrics)
+	if err != nil {
+		return nil, err
+	}
+	if alignment.ShiftMs != 0 && alignment.Confidence >= alignMinConfidence {
+		ShiftLyrics(lyrics, alignment.ShiftMs)
+		alignment.Mode = LyricsAlignRewrite
+		alignment.Applied = true
+	}
+	return alignment, nil
+}
+
+func AlignLyricsFile(req LyricsAlignRequest) (*LyricsAlignment, error) {
+	if req.AudioPath == "" {
+		return nil, fmt.Errorf("audio path is required")
+	}
+
+	mode := strings.ToLower(req.Mode)
+	switch mode {
+	case "":
+		mode = LyricsAlignReport
+	case LyricsAlignReport, LyricsAlignTag, LyricsAlignRewrite:
+	default:
+		return nil, fmt.Errorf("unknown alignment mode: %s", req.Mode)
+	}
+
+	minConfidence := req.MinConfidence
+	if minConfidence <= 0 || minConfidence > 1 {
+		minConfidence = alignMinConfidence
+	}
+
+	lyricsPath := req.LyricsPath
+	if lyricsPath == "" {
+		lyricsPath = strings.TrimSuffix(req.AudioPath, filepath.Ext(req.AudioPath)) + ".lrc"
+	}
+
+	data, err := os.ReadFile(lyricsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lyrics file: %w", err)
+	}
+	content := string(data)
+
+	lyrics, err := ParseLyricsContent(content)
+	if err != nil {
+		return nil, err
+	}
+
+	alignment, err := EstimateLyricsOffset(req.AudioPath, lyrics)
+	if err != nil {
+		return nil, err
+	}
+	alignment.Mode = mode
+	alignment.File = lyricsPath
+
+	fmt.Printf("[LyricsAlign] %s: shift %dms (%s, confidence %.2f)\n", filepath.Base(lyricsPath), alignment.ShiftMs, alignment.Method, alignment.Confidence)
+
+	if mode == LyricsAlignReport || alignment.ShiftMs == 0 || alignment.Confidence < minConfidence {
+		return alignment, nil
+	}
+
+	if strings.ToLower(filepath.Ext(lyricsPath)) == ".ttml" {
+		return alignment, fmt.Errorf("only LRC files can be corrected, TTML was analysed only")
+	}
+
+	if mode == LyricsAlignTag {
+		content = setLRCOffsetTag(content, lrcOffsetTag(content)-alignment.ShiftMs)
+	} else {
+		content = shiftLRCContent(content, alignment.ShiftMs)
+	}
+
+	if err := os.WriteFile(lyricsPath, []byte(content), 0644); err != nil {
+		return alignment, fmt.Errorf("failed to write lyrics file: %w", err)
+	}
+	alignment.Applied = true
+	return alignment, nil
+}
@@ -0,0 +1,194 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type LyricsBackfillRequest struct {
+	Folder string `json:"folder"`
+	Mode   string `json:"mode"`
+	Format string `json:"format,omitempty"`
+	DryRun bool   `json:"dry_run,omitempty"`
+}
+
+type LyricsBackfillItem struct {
+	Path     string `json:"path"`
+	Title    string `json:"title"`
+	Artist   string `json:"artist"`
+	Provider string `json:"provider,omitempty"`
+	Output   string `json:"output,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type LyricsBackfillResult struct {
+	Scanned     int                  `json:"scanned"`
+	AlreadyHave int                  `json:"already_have"`
+	Embedded    int                  `json:"embedded"`
+	Written     int                  `json:"written"`
+	Pending     []LyricsBackfillItem `json:"pending,omitempty"`
+	Updated     []LyricsBackfillItem `json:"updated"`
+	Missing     []LyricsBackfillItem `json:"missing"`
+	Failed      []LyricsBackfillItem `json:"failed"`
+}
+
+const (
+	LyricsBackfillEmbed = "embed"
+	LyricsBackfillFile  = "file"
+	LyricsBackfillBoth  = "both"
+)
+
+func hasLyricsSidecar(path string) bool {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	return fileExists(base+".lrc") || fileExists(base+".ttml")
+}
+
+func backfillQuery(path string, metadata Metadata) LyricsQuery {
+	query := LyricsQuery{
+		ISRC:       metadata.ISRC,
+		TrackName:  metadata.Title,
+		ArtistName: metadata.Artist,
+		AlbumName:  metadata.Album,
+	}
+	if len(metadata.Artists) > 0 {
+		query.ArtistName = metadata.Artists[0]
+	}
+	if parsed, err := parseSpotifyURI(metadata.URL); err == nil && parsed.Type == "track" {
+		query.SpotifyID = parsed.ID
+	}
+	if duration, err := GetAudioDuration(path); err == nil && duration > 0 {
+		query.DurationSec = int(duration)
+	}
+	return query
+}
+
+func BackfillLyrics(req LyricsBackfillRequest, progressCallback func(done, total int), appName string) (*LyricsBackfillResult, error) {
+	if req.Folder == "" {
+		return nil, fmt.Errorf("folder path is required")
+	}
+
+	mode := strings.ToLower(req.Mode)
+	switch mode {
+	case "":
+		mode = LyricsBackfillEmbed
+	case LyricsBackfillEmbed, LyricsBackfillFile, LyricsBackfillBoth:
+	default:
+		return nil, fmt.Errorf("invalid backfill mode: %s", req.Mode)
+	}
+	sidecarExt := ".lrc"
+	if strings.EqualFold(req.Format, "ttml") {
+		sidecarExt = ".ttml"
+	}
+
+	var paths []string
+	err := filepath.Walk(req.Folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if SupportsLyricsEmbedding(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	client := NewCachedLyricsClient(appName)
+	result := &LyricsBackfillResult{
+		Scanned: len(paths),
+		Updated: []LyricsBackfillItem{},
+		Missing: []LyricsBackfillItem{},
+		Failed:  []LyricsBackfillItem{},
+	}
+
+	fetched := 0
+	for i, path := range paths {
+		if progressCallback != nil {
+			progressCallback(i, len(paths))
+		}
+
+		metadata, err := ExtractFullMetadataFromFile(path)
+		item := LyricsBackfillItem{Path: path, Title: metadata.Title, Artist: metadata.Artist}
+		if err != nil {
+			item.Error = fmt.Sprintf("failed to read tags: %v", err)
+			result.Failed = append(result.Failed, item)
+			continue
+		}
+
+		if strings.TrimSpace(metadata.Lyrics) != "" || hasLyricsSidecar(path) {
+			result.AlreadyHave++
+			continue
+		}
+
+		if metadata.Title == "" || metadata.Artist == "" {
+			item.Error = "missing title or artist tag"
+			result.Failed = append(result.Failed, item)
+			continue
+		}
+
+		if req.DryRun {
+			result.Pending = append(result.Pending, item)
+			continue
+		}
+
+		if fetched > 0 {
+			time.Sleep(500 * time.Millisecond)
+		}
+		fetched++
+
+		query := backfillQuery(path, metadata)
+		lyrics, source, err := client.FetchLyricsForQuery(query, false)
+		if err != nil || lyrics == nil || len(lyrics.Lines) == 0 {
+			if err != nil {
+				item.Error = err.Error()
+			}
+			result.Missing = append(result.Missing, item)
+			continue
+		}
+		item.Provider = source
+
+		if GetLyricsProviderConfig().AutoAlign && lyrics.SyncType != LyricsSyncNone {
+			if _, err := AlignLyricsToAudio(path, lyrics); err != nil {
+				fmt.Printf("[LyricsBackfill] Alignment skipped for %s: %v\n", filepath.Base(path), err)
+			}
+		}
+
+		if mode == LyricsBackfillEmbed || mode == LyricsBackfillBoth {
+			if _, err := client.EmbedLyrics(path, lyrics, metadata.Title, metadata.Artist); err != nil {
+				item.Error = err.Error()
+				result.Failed = append(result.Failed, item)
+				continue
+			}
+			result.Embedded++
+		}
+
+		if mode == LyricsBackfillFile || mode == LyricsBackfillBoth {
+			content := client.ConvertToLRC(lyrics, metadata.Title, metadata.Artist)
+			if sidecarExt == ".ttml" {
+				content = client.ConvertToTTML(lyrics, metadata.Title, metadata.Artist)
+			}
+			item.Output = strings.TrimSuffix(path, filepath.Ext(path)) + sidecarExt
+			if err := os.WriteFile(item.Output, []byte(content), 0644); err != nil {
+				item.Error = fmt.Sprintf("failed to write lyrics file: %v", err)
+				result.Failed = append(result.Failed, item)
+				continue
+			}
+			client.WriteLyricsVariantFiles(item.Output, lyrics, metadata.Title, metadata.Artist)
+			result.Written++
+		}
+
+		result.Updated = append(result.Updated, item)
+	}
+
+	if progressCallback != nil {
+		progressCallback(len(paths), len(paths))
+	}
+
+	fmt.Printf("[LyricsBackfill] %d files scanned, %d already had lyrics, %d updated, %d still missing, %d failed\n",
+		result.Scanned, result.AlreadyHave, len(result.Updated), len(result.Missing), len(result.Failed))
+	return result, nil
+}
@@ -0,0 +1,342 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type LyricsCacheEntry struct {
+	Keys        []string        `json:"keys"`
+	SpotifyID   string          `json:"spotify_id,omitempty"`
+	ISRC        string          `json:"isrc,omitempty"`
+	TrackName   string          `json:"track_name"`
+	ArtistName  string          `json:"artist_name"`
+	DurationSec int             `json:"duration,omitempty"`
+	Provider    string          `json:"provider,omitempty"`
+	Score       float64         `json:"score,omitempty"`
+	NotFound    bool            `json:"not_found"`
+	Lyrics      *LyricsResponse `json:"lyrics,omitempty"`
+	Timestamp   int64           `json:"timestamp"`
+	ExpiresAt   int64           `json:"expires_at,omitempty"`
+}
+
+const (
+	lyricsCacheBucket    = "LyricsCache"
+	lyricsCacheHitTTL    = 90 * 24 * time.Hour
+	lyricsCacheMissTTL   = 24 * time.Hour
+	LyricsProviderManual = "manual"
+)
+
+func (e *LyricsCacheEntry) Expired() bool {
+	return e.ExpiresAt > 0 && time.Now().Unix() >= e.ExpiresAt
+}
+
+func lyricsCacheKeys(query LyricsQuery) []string {
+	var keys []string
+	if query.SpotifyID != "" {
+		keys = append(keys, "spotify:"+query.SpotifyID)
+	}
+	if isrc := strings.ToUpper(strings.TrimSpace(query.ISRC)); IsValidISRC(isrc) {
+		keys = append(keys, "isrc:"+isrc)
+	}
+
+	title := normalizeForMatch(query.TrackName)
+	artist := normalizeForMatch(query.ArtistName)
+	if title != "" && artist != "" {
+		duration := 0
+		if query.DurationSec > 0 {
+			duration = (query.DurationSec + 2) / 5 * 5
+		}
+		keys = append(keys, fmt.Sprintf("meta:%s|%s|%d", title, artist, duration))
+	}
+	return keys
+}
+
+func GetCachedLyrics(query LyricsQuery, appName string) (*LyricsCacheEntry, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := lyricsCacheKeys(query)
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	var found *LyricsCacheEntry
+	err := historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(lyricsCacheBucket))
+		if b == nil {
+			return nil
+		}
+		for _, key := range keys {
+			v := b.Get([]byte(key))
+			if v == nil {
+				continue
+			}
+			var entry LyricsCacheEntry
+			if err := json.Unmarshal(v, &entry); err != nil || entry.Expired() {
+				continue
+			}
+			found = &entry
+			return nil
+		}
+		return nil
+	})
+	return found, err
+}
+
+func putLyricsCacheEntry(entry *LyricsCacheEntry) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	return historyDB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(lyricsCacheBucket))
+		if err != nil {
+			return err
+		}
+		for _, key := range entry.Keys {
+			if err := b.Put([]byte(key), buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func newLyricsCacheEntry(query LyricsQuery) *LyricsCacheEntry {
+	return &LyricsCacheEntry{
+		Keys:        lyricsCacheKeys(query),
+		SpotifyID:   query.SpotifyID,
+		ISRC:        strings.ToUpper(strings.TrimSpace(query.ISRC)),
+		TrackName:   query.TrackName,
+		ArtistName:  query.ArtistName,
+		DurationSec: query.DurationSec,
+		Timestamp:   time.Now().Unix(),
+	}
+}
+
+func StoreCachedLyrics(query LyricsQuery, candidate *LyricsCandidate, appName string) error {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return err
+		}
+	}
+
+	entry := newLyricsCacheEntry(query)
+	if len(entry.Keys) == 0 {
+		return nil
+	}
+
+	if candidate == nil || candidate.Lyrics == nil || len(candidate.Lyrics.Lines) == 0 {
+		entry.Keys = entry.Keys[:1]
+		entry.NotFound = true
+		entry.ExpiresAt = time.Now().Add(lyricsCacheMissTTL).Unix()
+	} else {
+		entry.Provider = candidate.Provider
+		entry.Score = candidate.Score
+		entry.Lyrics = candidate.Lyrics
+		entry.ExpiresAt = time.Now().Add(lyricsCacheHitTTL).Unix()
+	}
+
+	return putLyricsCacheEntry(entry)
+}
+
+func UpdateCachedLyrics(query LyricsQuery, content string, appName string) (*LyricsCacheEntry, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	lyrics, err := ParseLyricsContent(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lyrics: %w", err)
+	}
+	if lyrics == nil || len(lyrics.Lines) == 0 {
+		return nil, fmt.Errorf("lyrics content is empty")
+	}
+
+	entry := newLyricsCacheEntry(query)
+	if len(entry.Keys) == 0 {
+		return nil, fmt.Errorf("spotify ID, ISRC or title and artist are required")
+	}
+	entry.Provider = LyricsProviderManual
+	entry.Lyrics = lyrics
+
+	if err := putLyricsCacheEntry(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func DeleteCachedLyrics(query LyricsQuery, appName string) error {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return err
+		}
+	}
+
+	keys := lyricsCacheKeys(query)
+	return historyDB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(lyricsCacheBucket))
+		if b == nil {
+			return nil
+		}
+
+		primaryOf := func(v []byte) string {
+			var entry LyricsCacheEntry
+			if v == nil || json.Unmarshal(v, &entry) != nil || len(entry.Keys) == 0 {
+				return ""
+			}
+			return entry.Keys[0]
+		}
+
+		for _, key := range keys {
+			v := b.Get([]byte(key))
+			if v == nil {
+				continue
+			}
+			var entry LyricsCacheEntry
+			if err := json.Unmarshal(v, &entry); err == nil && len(entry.Keys) > 0 {
+				primary := entry.Keys[0]
+				for _, alias := range entry.Keys {
+					if alias == key || primaryOf(b.Get([]byte(alias))) != primary {
+						continue
+					}
+					if err := b.Delete([]byte(alias)); err != nil {
+						return err
+					}
+				}
+			}
+			return b.Delete([]byte(key))
+		}
+		return nil
+	})
+}
+
+func ListCachedLyrics(appName string) ([]LyricsCacheEntry, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []LyricsCacheEntry
+	seen := make(map[string]bool)
+	err := historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(lyricsCacheBucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var entry LyricsCacheEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return nil
+			}
+			if len(entry.Keys) > 0 {
+				if seen[entry.Keys[0]] {
+					return nil
+				}
+				seen[entry.Keys[0]] = true
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Timestamp > entries[j].Timestamp
+	})
+	return entries, err
+}
+
+func PruneLyricsCache(appName string) (int, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return 0, err
+		}
+	}
+
+	removed := 0
+	err := historyDB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(lyricsCacheBucket))
+		if b == nil {
+			return nil
+		}
+
+		var stale [][]byte
+		b.ForEach(func(k, v []byte) error {
+			var entry LyricsCacheEntry
+			if err := json.Unmarshal(v, &entry); err != nil || entry.Expired() {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		})
+
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		removed = len(stale)
+		return nil
+	})
+	return removed, err
+}
+
+func ClearLyricsCache(appName string) error {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return err
+		}
+	}
+
+	return historyDB.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(lyricsCacheBucket)) == nil {
+			return nil
+		}
+		return tx.DeleteBucket([]byte(lyricsCacheBucket))
+	})
+}
+
+func (c *LyricsClient) FetchLyricsCached(query LyricsQuery, refresh bool, appName string) (*LyricsCandidate, error) {
+	if !refresh {
+		entry, err := GetCachedLyrics(query, appName)
+		if err != nil {
+			fmt.Printf("[LyricsCache] Lookup failed: %v\n", err)
+		} else if entry != nil {
+			if entry.NotFound {
+				fmt.Printf("[LyricsCache] Cached miss for %s - %s\n", query.ArtistName, query.TrackName)
+				return nil, fmt.Errorf("lyrics not found in any source (cached)")
+			}
+			fmt.Printf("[LyricsCache] Using cached lyrics from %s\n", entry.Provider)
+			return &LyricsCandidate{
+				Provider:    entry.Provider,
+				TrackName:   entry.TrackName,
+				ArtistName:  entry.ArtistName,
+				DurationSec: entry.DurationSec,
+				Lyrics:      entry.Lyrics,
+				Score:       entry.Score,
+			}, nil
+		}
+	}
+
+	best, err := c.FetchLyricsChain(query)
+	if best == nil && !errors.Is(err, errLyricsNotFound) {
+		return best, err
+	}
+	if storeErr := StoreCachedLyrics(query, best, appName); storeErr != nil {
+		fmt.Printf("[LyricsCache] Failed to store result: %v\n", storeErr)
+	}
+	return best, err
+}
@@ -0,0 +1,395 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/bogem/id3v2/v2"
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+)
+
+type SyncedLyricsEntry struct {
+	Text        string
+	TimestampMs uint32
+}
+
+type SynchronisedLyricsFrame struct {
+	Encoding          id3v2.Encoding
+	Language          string
+	ContentDescriptor string
+	Entries           []SyncedLyricsEntry
+}
+
+func (sf SynchronisedLyricsFrame) Size() int {
+	var buf bytes.Buffer
+	sf.WriteTo(&buf)
+	return buf.Len()
+}
+
+func (sf SynchronisedLyricsFrame) UniqueIdentifier() string {
+	return sf.Language + sf.ContentDescriptor
+}
+
+func (sf SynchronisedLyricsFrame) WriteTo(w io.Writer) (int64, error) {
+	if len(sf.Language) != 3 {
+		return 0, id3v2.ErrInvalidLanguageLength
+	}
+
+	encoding := sf.Encoding
+	if !encoding.Equals(id3v2.EncodingUTF16) {
+		encoding = id3v2.EncodingUTF8
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(encoding.Key)
+	buf.WriteString(sf.Language)
+	buf.WriteByte(2)
+	buf.WriteByte(1)
+	writeSyltText(&buf, sf.ContentDescriptor, encoding)
+
+	stamp := make([]byte, 4)
+	for _, entry := range sf.Entries {
+		writeSyltText(&buf, entry.Text, encoding)
+		binary.BigEndian.PutUint32(stamp, entry.TimestampMs)
+		buf.Write(stamp)
+	}
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+func writeSyltText(buf *bytes.Buffer, text string, encoding id3v2.Encoding) {
+	if !encoding.Equals(id3v2.EncodingUTF16) {
+		buf.WriteString(text)
+		buf.WriteByte(0)
+		return
+	}
+
+	unit := make([]byte, 2)
+	buf.Write([]byte{0xFF, 0xFE})
+	for _, u := range utf16.Encode([]rune(text)) {
+		binary.LittleEndian.PutUint16(unit, u)
+		buf.Write(unit)
+	}
+	buf.Write([]byte{0, 0})
+}
+
+type LyricsEmbedResult struct {
+	File     string   `json:"file"`
+	Format   string   `json:"format"`
+	SyncType string   `json:"sync_type"`
+	Lines    int      `json:"lines"`
+	Fields   []string `json:"fields"`
+	Files    []string `json:"files,omitempty"`
+}
+
+const (
+	LyricsStatusPending  = "pending"
+	LyricsStatusEmbedded = "embedded"
+	LyricsStatusNotFound = "not_found"
+	LyricsStatusFailed   = "failed"
+)
+
+func SupportsLyricsEmbedding(filePath string) bool {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".flac", ".mp3", ".m4a":
+		return true
+	}
+	return false
+}
+
+func plainLyricsText(lyrics *LyricsResponse) string {
+	lines := make([]string, 0, len(lyrics.Lines))
+	for _, line := range lyrics.Lines {
+		lines = append(lines, line.Words)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func syncedLyricsEntries(lyrics *LyricsResponse) []SyncedLyricsEntry {
+	var entries []SyncedLyricsEntry
+	for _, line := range lyrics.Lines {
+		prefix := ""
+		if len(entries) > 0 {
+			prefix = "\n"
+		}
+		if len(line.Syllables) > 0 {
+			added := false
+			for _, syllable := range line.Syllables {
+				ms, ok := lyricsMs(syllable.StartTimeMs)
+				if !ok || ms < 0 || syllable.Text == "" {
+					continue
+				}
+				text := syllable.Text
+				if !added {
+					text = prefix + text
+				}
+				entries = append(entries, SyncedLyricsEntry{Text: text, TimestampMs: uint32(ms)})
+				added = true
+			}
+			if added {
+				continue
+			}
+		}
+		ms, ok := lyricsMs(line.StartTimeMs)
+		if !ok || ms < 0 {
+			continue
+		}
+		entries = append(entries, SyncedLyricsEntry{Text: prefix + line.Words, TimestampMs: uint32(ms)})
+	}
+	return entries
+}
+
+func embedFLACLyricsFields(filePath string, fields map[string]string) error {
+	f, err := flac.ParseFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse FLAC file: %w", err)
+	}
+
+	cmtIdx := -1
+	var existingCmt *flacvorbis.MetaDataBlockVorbisComment
+	for idx, block := range f.Meta {
+		if block.Type == flac.VorbisComment {
+			cmtIdx = idx
+			existingCmt, err = flacvorbis.ParseFromMetaDataBlock(*block)
+			if err != nil {
+				existingCmt = nil
+			}
+			break
+		}
+	}
+
+	cmt := flacvorbis.New()
+	if existingCmt != nil {
+		cmt.Vendor = existingCmt.Vendor
+		for _, comment := range existingCmt.Comments {
+			parts := strings.SplitN(comment, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			name := strings.ToUpper(parts[0])
+			switch {
+			case name == "LYRICS", name == "UNSYNCEDLYRICS", name == "SYNCEDLYRICS", strings.HasPrefix(name, "LYRICS-"):
+				continue
+			}
+			_ = cmt.Add(parts[0], parts[1])
+		}
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "LYRICS") != (names[j] == "LYRICS") {
+			return names[i] == "LYRICS"
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		if value := fields[name]; value != "" {
+			_ = cmt.Add(name, value)
+		}
+	}
+
+	cmtBlock := cmt.Marshal()
+	if cmtIdx < 0 {
+		f.Meta = append(f.Meta, &cmtBlock)
+	} else {
+		f.Meta[cmtIdx] = &cmtBlock
+	}
+
+	if err := f.Save(filePath); err != nil {
+		return fmt.Errorf("failed to save FLAC file: %w", err)
+	}
+	return nil
+}
+
+func embedMP3LyricsFrames(filePath, lyricsText string, entries []SyncedLyricsEntry, extra []id3v2.UnsynchronisedLyricsFrame) error {
+	tag, err := id3v2.Open(filePath, id3v2.Options{Parse: true})
+	if err != nil {
+		return fmt.Errorf("failed to open MP3 file: %w", err)
+	}
+	defer tag.Close()
+
+	tag.DeleteFrames(tag.CommonID("Unsynchronised lyrics/text transcription"))
+	tag.DeleteFrames("SYLT")
+
+	encoding := id3v2.EncodingUTF8
+	if tag.Version() < 4 {
+		encoding = id3v2.EncodingUTF16
+	}
+
+	tag.AddUnsynchronisedLyricsFrame(id3v2.UnsynchronisedLyricsFrame{
+		Encoding: encoding,
+		Language: "eng",
+		Lyrics:   lyricsText,
+	})
+	for _, frame := range extra {
+		frame.Encoding = encoding
+		tag.AddUnsynchronisedLyricsFrame(frame)
+	}
+	if len(entries) > 0 {
+		tag.AddFrame("SYLT", SynchronisedLyricsFrame{
+			Encoding: encoding,
+			Language: "eng",
+			Entries:  entries,
+		})
+	}
+
+	if err := tag.Save(); err != nil {
+		return fmt.Errorf("failed to save MP3 tags: %w", err)
+	}
+	return nil
+}
+
+func (c *LyricsClient) EmbedLyrics(filePath string, lyrics *LyricsResponse, trackName, artistName string) (*LyricsEmbedResult, error) {
+	if lyrics == nil || len(lyrics.Lines) == 0 {
+		return nil, fmt.Errorf("no lyrics to embed")
+	}
+
+	synced := lyrics.SyncType != LyricsSyncNone
+	plain := plainLyricsText(lyrics)
+	lrc := plain
+	if synced {
+		lrc = c.ConvertToLRC(lyrics, trackName, artistName)
+	}
+
+	ext := strings.ToLower(filepath.Ext(filePath))
+	result := &LyricsEmbedResult{
+		File:     filePath,
+		Format:   strings.TrimPrefix(ext, "."),
+		SyncType: lyrics.SyncType,
+		Lines:    len(lyrics.Lines),
+	}
+
+	var err error
+	switch ext {
+	case ".flac":
+		if validated, verr := validateLyricsDuration(lrc, filePath); verr == nil {
+			lrc = validated
+		}
+		fields := map[string]string{
+			"LYRICS":         lrc,
+			"UNSYNCEDLYRICS": plain,
+		}
+		result.Fields = []string{"LYRICS", "UNSYNCEDLYRICS"}
+		for i := range lyrics.Variants {
+			variant := &lyrics.Variants[i]
+			content := plainLyricsText(variant.Response(lyrics))
+			if synced {
+				content = c.ConvertToLRC(variant.Response(lyrics), trackName, artistName)
+			}
+			fields[variant.TagName()] = content
+			result.Fields = append(result.Fields, variant.TagName())
+		}
+		err = embedFLACLyricsFields(filePath, fields)
+	case ".mp3":
+		if validated, verr := validateLyricsDuration(lrc, filePath); verr == nil {
+			lrc = validated
+		}
+		var entries []SyncedLyricsEntry
+		if synced {
+			entries = syncedLyricsEntries(lyrics)
+		}
+		var extra []id3v2.UnsynchronisedLyricsFrame
+		for i := range lyrics.Variants {
+			variant := &lyrics.Variants[i]
+			content := plainLyricsText(variant.Response(lyrics))
+			if synced {
+				content = c.ConvertToLRC(variant.Response(lyrics), trackName, artistName)
+			}
+			extra = append(extra, id3v2.UnsynchronisedLyricsFrame{
+				Encoding:          id3v2.EncodingUTF8,
+				Language:          variant.languageCode(),
+				ContentDescriptor: variant.Name,
+				Lyrics:            content,
+			})
+		}
+		err = embedMP3LyricsFrames(filePath, lrc, entries, extra)
+		result.Fields = []string{"USLT"}
+		if len(entries) > 0 {
+			result.Fields = append(result.Fields, "SYLT")
+		}
+		for _, frame := range extra {
+			result.Fields = append(result.Fields, "USLT:"+frame.ContentDescriptor)
+		}
+	case ".m4a":
+		err = embedLyricsToM4A(filePath, lrc)
+		result.Fields = []string{"©lyr"}
+		if err == nil && len(lyrics.Variants) > 0 {
+			stem := strings.TrimSuffix(filePath, filepath.Ext(filePath))
+			result.Files = c.WriteLyricsVariantFiles(stem+".lrc", lyrics, trackName, artistName)
+			fmt.Printf("[Lyrics] M4A has no field for lyric variants, wrote %d of %d as sidecar files\n", len(result.Files), len(lyrics.Variants))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported file format for lyrics embedding: %s", ext)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *LyricsClient) EmbedLyricsForDownload(itemID, filePath string, query LyricsQuery) (*LyricsEmbedResult, error) {
+	fmt.Printf("\n========== LYRICS FETCH START ==========\n")
+	fmt.Printf("Spotify ID: %s\n", query.SpotifyID)
+	fmt.Printf("Track: %s\n", query.TrackName)
+	fmt.Printf("Artist: %s\n", query.ArtistName)
+	fmt.Println("Searching all sources...")
+
+	if itemID != "" {
+		SetDownloadItemLyrics(itemID, LyricsStatusPending, "", "")
+	}
+
+	lyrics, source, err := c.FetchLyricsForQuery(query, false)
+	if err == nil && (lyrics == nil || len(lyrics.Lines) == 0) {
+		err = fmt.Errorf("no lyrics content found")
+	}
+	if err != nil {
+		fmt.Printf("All sources failed: %v\n", err)
+		fmt.Printf("========== LYRICS FETCH END (FAILED) ==========\n\n")
+		if itemID != "" {
+			SetDownloadItemLyrics(itemID, LyricsStatusNotFound, "", err.Error())
+		}
+		return nil, err
+	}
+
+	fmt.Printf("Lyrics found from: %s\n", source)
+	fmt.Printf("Sync type: %s\n", lyrics.SyncType)
+	fmt.Printf("Total lines: %d\n", len(lyrics.Lines))
+
+	if GetLyricsProviderConfig().AutoAlign {
+		if alignment, err := AlignLyricsToAudio(filePath, lyrics); err != nil {
+			fmt.Printf("Alignment skipped: %v\n", err)
+		} else {
+			fmt.Printf("Alignment: shift %dms, confidence %.2f, applied: %v\n", alignment.ShiftMs, alignment.Confidence, alignment.Applied)
+		}
+	}
+
+	fmt.Printf("Embedding into: %s\n", filePath)
+	result, err := c.EmbedLyrics(filePath, lyrics, query.TrackName, query.ArtistName)
+	if err != nil {
+		fmt.Printf("Failed to embed lyrics: %v\n", err)
+		fmt.Printf("========== LYRICS FETCH END (FAILED) ==========\n\n")
+		if itemID != "" {
+			SetDownloadItemLyrics(itemID, LyricsStatusFailed, source, err.Error())
+		}
+		return nil, err
+	}
+
+	fmt.Printf("Lyrics embedded successfully (%s)\n", strings.Join(result.Fields, ", "))
+	fmt.Printf("========== LYRICS FETCH END (SUCCESS) ==========\n\n")
+	if itemID != "" {
+		SetDownloadItemLyrics(itemID, LyricsStatusEmbedded, source, fmt.Sprintf("%d lines, %s", result.Lines, strings.ToLower(strings.ReplaceAll(result.SyncType, "_", " "))))
+	}
+	return result, nil
+}
@@ -0,0 +1,931 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type LyricsQuery struct {
+	SpotifyID   string `json:"spotify_id,omitempty"`
+	ISRC        string `json:"isrc,omitempty"`
+	TrackName   string `json:"track_name"`
+	ArtistName  string `json:"artist_name"`
+	AlbumName   string `json:"album_name,omitempty"`
+	DurationSec int    `json:"duration,omitempty"`
+}
+
+type LyricsCandidate struct {
+	Provider    string          `json:"provider"`
+	TrackName   string          `json:"track_name"`
+	ArtistName  string          `json:"artist_name"`
+	DurationSec int             `json:"duration,omitempty"`
+	Lyrics      *LyricsResponse `json:"lyrics"`
+	Score       float64         `json:"score"`
+}
+
+type LyricsProvider interface {
+	Name() string
+	Fetch(query LyricsQuery) ([]LyricsCandidate, error)
+}
+
+type LyricsProviderConfig struct {
+	Providers         []string `json:"providers"`
+	Threshold         float64  `json:"threshold"`
+	FallbackThreshold float64  `json:"fallback_threshold"`
+	AllowUnofficial   bool     `json:"allow_unofficial"`
+	LocalDirs         []string `json:"local_dirs,omitempty"`
+	AutoAlign         bool     `json:"auto_align"`
+	Variants          []string `json:"variants,omitempty"`
+}
+
+const (
+	LyricsProviderLocal      = "local"
+	LyricsProviderLRCLib     = "lrclib"
+	LyricsProviderNetEase    = "netease"
+	LyricsProviderMusixmatch = "musixmatch"
+	LyricsProviderGenius     = "genius"
+)
+
+var defaultLyricsProviders = []string{
+	LyricsProviderLocal,
+	LyricsProviderLRCLib,
+	LyricsProviderNetEase,
+	LyricsProviderMusixmatch,
+	LyricsProviderGenius,
+}
+
+var errLyricsNotFound = errors.New("no lyrics found")
+
+var unofficialLyricsProviders = map[string]bool{
+	LyricsProviderNetEase:    true,
+	LyricsProviderMusixmatch: true,
+	LyricsProviderGenius:     true,
+}
+
+var (
+	lyricsProviderConfig = LyricsProviderConfig{
+		Providers:         defaultLyricsProviders,
+		Threshold:         0.7,
+		FallbackThreshold: 0.4,
+	}
+	lyricsProviderConfigLock sync.RWMutex
+)
+
+func GetLyricsProviderConfig() LyricsProviderConfig {
+	lyricsProviderConfigLock.RLock()
+	defer lyricsProviderConfigLock.RUnlock()
+	return lyricsProviderConfig
+}
+
+func SetLyricsProviderConfig(config LyricsProviderConfig) error {
+	if len(config.Providers) == 0 {
+		config.Providers = defaultLyricsProviders
+	}
+	seen := make(map[string]bool)
+	providers := make([]string, 0, len(config.Providers))
+	for _, name := range config.Providers {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case LyricsProviderLocal, LyricsProviderLRCLib, LyricsProviderNetEase, LyricsProviderMusixmatch, LyricsProviderGenius:
+		default:
+			return fmt.Errorf("unknown lyrics provider: %s", name)
+		}
+		if !seen[name] {
+			seen[name] = true
+			providers = append(providers, name)
+		}
+	}
+	config.Providers = providers
+
+	variants := make([]string, 0, len(config.Variants))
+	for _, kind := range config.Variants {
+		kind = strings.ToLower(strings.TrimSpace(kind))
+		switch kind {
+		case LyricsVariantRomanized, LyricsVariantTranslated:
+			variants = append(variants, kind)
+		default:
+			return fmt.Errorf("unknown lyrics variant: %s", kind)
+		}
+	}
+	config.Variants = variants
+
+	if config.Threshold <= 0 || config.Threshold > 1 {
+		config.Threshold = 0.7
+	}
+	if config.FallbackThreshold <= 0 {
+		config.FallbackThreshold = 0.4
+	}
+	if config.FallbackThreshold > config.Threshold {
+		config.FallbackThreshold = config.Threshold
+	}
+
+	lyricsProviderConfigLock.Lock()
+	lyricsProviderConfig = config
+	lyricsProviderConfigLock.Unlock()
+	return nil
+}
+
+func (c *LyricsClient) providerChain(config LyricsProviderConfig) []LyricsProvider {
+	chain := make([]LyricsProvider, 0, len(config.Providers))
+	for _, name := range config.Providers {
+		if unofficialLyricsProviders[name] && !config.AllowUnofficial {
+			continue
+		}
+		switch name {
+		case LyricsProviderLocal:
+			if len(config.LocalDirs) > 0 {
+				chain = append(chain, &localLyricsProvider{dirs: config.LocalDirs})
+			}
+		case LyricsProviderLRCLib:
+			chain = append(chain, &lrclibProvider{client: c})
+		case LyricsProviderNetEase:
+			chain = append(chain, &neteaseProvider{client: c.httpClient})
+		case LyricsProviderMusixmatch:
+			chain = append(chain, sharedMusixmatchProvider(c.httpClient))
+		case LyricsProviderGenius:
+			chain = append(chain, &geniusProvider{client: c.httpClient})
+		}
+	}
+	return chain
+}
+
+func ScoreLyricsCandidate(query LyricsQuery, candidate LyricsCandidate) float64 {
+	if candidate.Lyrics == nil || candidate.Lyrics.Error || len(candidate.Lyrics.Lines) == 0 {
+		return 0
+	}
+
+	score := 0.0
+	switch candidate.Lyrics.SyncType {
+	case LyricsSyncSyllable:
+		score += 0.35
+	case LyricsSyncLine:
+		score += 0.3
+	}
+
+	wantTitle := normalizeForMatch(query.TrackName)
+	gotTitle := normalizeForMatch(candidate.TrackName)
+	switch {
+	case gotTitle == "":
+		score += 0.15
+	case wantTitle == gotTitle:
+		score += 0.35
+	case normalizeForMatch(simplifyTrackName(query.TrackName)) == gotTitle:
+		score += 0.3
+	default:
+		score += 0.25 * tokenSimilarity(query.TrackName, candidate.TrackName)
+	}
+
+	if candidate.ArtistName == "" {
+		score += 0.07
+	} else {
+		score += 0.15 * tokenSimilarity(query.ArtistName, candidate.ArtistName)
+	}
+
+	if query.DurationSec > 0 && candidate.DurationSec > 0 {
+		diff := query.DurationSec - candidate.DurationSec
+		if diff < 0 {
+			diff = -diff
+		}
+		switch {
+		case diff <= 2:
+			score += 0.15
+		case diff <= 5:
+			score += 0.1
+		case diff <= 10:
+			score += 0.03
+		default:
+			score -= 0.2
+		}
+	} else {
+		score += 0.05
+	}
+
+	if score < 0 {
+		score = 0
+	}
+	if score > 1 {
+		score = 1
+	}
+	return float64(int(score*100+0.5)) / 100
+}
+
+func (c *LyricsClient) SearchLyricsCandidates(query LyricsQuery) []LyricsCandidate {
+	config := GetLyricsProviderConfig()
+	var all []LyricsCandidate
+	for _, provider := range c.providerChain(config) {
+		candidates, err := provider.Fetch(query)
+		if err != nil {
+			fmt.Printf("   %s: %v\n", provider.Name(), err)
+			continue
+		}
+		for _, candidate := range candidates {
+			candidate.Score = ScoreLyricsCandidate(query, candidate)
+			all = append(all, candidate)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Score > all[j].Score })
+	return all
+}
+
+func (c *LyricsClient) FetchLyricsChain(query LyricsQuery) (*LyricsCandidate, error) {
+	config := GetLyricsProviderConfig()
+
+	queries := []LyricsQuery{query}
+	if simplified := simplifyTrackName(query.TrackName); simplified != query.TrackName && simplified != "" {
+		variant := query
+		variant.TrackName = simplified
+		queries = append(queries, variant)
+	}
+
+	var best *LyricsCandidate
+	var seen []LyricsCandidate
+	chain := c.providerChain(config)
+	failed := 0
+	for _, provider := range chain {
+		for i, variant := range queries {
+			if i > 0 {
+				fmt.Printf("   Trying simplified name with %s: %s\n", provider.Name(), variant.TrackName)
+			}
+
+			candidates, err := provider.Fetch(variant)
+			if err != nil {
+				fmt.Printf("   %s: %v\n", provider.Name(), err)
+				if !errors.Is(err, errLyricsNotFound) {
+					failed++
+				}
+				continue
+			}
+
+			for j := range candidates {
+				candidate := candidates[j]
+				candidate.Score = ScoreLyricsCandidate(query, candidate)
+				seen = append(seen, candidate)
+				if best == nil || candidate.Score > best.Score {
+					best = &candidate
+				}
+			}
+
+			if best != nil && best.Score >= config.Threshold {
+				borrowRomanizedVariant(best.Lyrics, seen)
+				return best, nil
+			}
+		}
+	}
+
+	if best != nil && best.Score >= config.FallbackThreshold {
+		fmt.Printf("   No result above threshold %.2f, using best match from %s (%.2f)\n", config.Threshold, best.Provider, best.Score)
+		borrowRomanizedVariant(best.Lyrics, seen)
+		return best, nil
+	}
+	if failed > 0 || len(chain) == 0 {
+		return nil, fmt.Errorf("lyrics not found in any source (%d lookup(s) failed)", failed)
+	}
+	return nil, fmt.Errorf("%w in any source", errLyricsNotFound)
+}
+
+type lrclibProvider struct {
+	client *LyricsClient
+}
+
+func (p *lrclibProvider) Name() string { return "LRCLIB" }
+
+func (p *lrclibProvider) Fetch(query LyricsQuery) ([]LyricsCandidate, error) {
+	var candidates []LyricsCandidate
+	add := func(result *LRCLibResponse) {
+		if result.SyncedLyrics == "" && result.PlainLyrics == "" {
+			return
+		}
+		candidates = append(candidates, LyricsCandidate{
+			Provider:    p.Name(),
+			TrackName:   result.TrackName,
+			ArtistName:  result.ArtistName,
+			DurationSec: int(result.Duration + 0.5),
+			Lyrics:      p.client.convertLRCLibToLyricsResponse(result),
+		})
+	}
+
+	exact, exactErr := p.client.getLRCLib(query.TrackName, query.ArtistName, query.DurationSec)
+	if exactErr == nil {
+		add(exact)
+		if exact.SyncedLyrics != "" {
+			return candidates, nil
+		}
+	}
+
+	results, err := p.client.searchLRCLib(query.TrackName, query.ArtistName)
+	if err == nil {
+		for i := range results {
+			if i >= 5 {
+				break
+			}
+			add(&results[i])
+		}
+	}
+
+	if len(candidates) == 0 {
+		if exactErr != nil && !errors.Is(exactErr, errLyricsNotFound) {
+			return nil, exactErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		return nil, errLyricsNotFound
+	}
+	return candidates, nil
+}
+
+type neteaseProvider struct {
+	client *http.Client
+}
+
+func (p *neteaseProvider) Name() string { return "NetEase" }
+
+func (p *neteaseProvider) getJSON(apiURL string, target interface{}) error {
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Referer", "https://music.163.com/")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(target)
+}
+
+func (p *neteaseProvider) Fetch(query LyricsQuery) ([]LyricsCandidate, error) {
+	var search struct {
+		Result struct {
+			Songs []struct {
+				ID       int64  `json:"id"`
+				Name     string `json:"name"`
+				Duration int    `json:"duration"`
+				Artists  []struct {
+					Name string `json:"name"`
+				} `json:"artists"`
+			} `json:"songs"`
+		} `json:"result"`
+	}
+	searchURL := fmt.Sprintf("https://music.163.com/api/search/get?s=%s&type=1&limit=5", url.QueryEscape(query.ArtistName+" "+query.TrackName))
+	if err := p.getJSON(searchURL, &search); err != nil {
+		return nil, err
+	}
+	if len(search.Result.Songs) == 0 {
+		return nil, fmt.Errorf("%w: no results found", errLyricsNotFound)
+	}
+
+	var candidates []LyricsCandidate
+	var fetchErr error
+	for i, song := range search.Result.Songs {
+		if i >= 3 {
+			break
+		}
+
+		var lyric struct {
+			Lrc struct {
+				Lyric string `json:"lyric"`
+			} `json:"lrc"`
+			Tlyric struct {
+				Lyric string `json:"lyric"`
+			} `json:"tlyric"`
+			Romalrc struct {
+				Lyric string `json:"lyric"`
+			} `json:"romalrc"`
+		}
+		lyricURL := fmt.Sprintf("https://music.163.com/api/song/lyric?id=%d&lv=1&kv=1&tv=-1&rv=-1", song.ID)
+		if err := p.getJSON(lyricURL, &lyric); err != nil {
+			fetchErr = err
+			continue
+		}
+		if strings.TrimSpace(lyric.Lrc.Lyric) == "" {
+			continue
+		}
+
+		lyrics := ParseLRC(lyric.Lrc.Lyric)
+		if lyrics.Error || len(lyrics.Lines) == 0 {
+			continue
+		}
+		if variant := lyricsVariantFromLRC(lyrics, lyric.Tlyric.Lyric, LyricsVariantTranslated, "zh-Hans", "zh", p.Name()); variant != nil {
+			lyrics.Variants = append(lyrics.Variants, *variant)
+		}
+		if script := DetectLyricsScript(lyrics); script != "" {
+			if variant := lyricsVariantFromLRC(lyrics, lyric.Romalrc.Lyric, LyricsVariantRomanized, script+"-Latn", romanizedVariantNames[script], p.Name()); variant != nil {
+				lyrics.Variants = append(lyrics.Variants, *variant)
+			}
+		}
+
+		var artists []string
+		for _, artist := range song.Artists {
+			artists = append(artists, artist.Name)
+		}
+		candidates = append(candidates, LyricsCandidate{
+			Provider:    p.Name(),
+			TrackName:   song.Name,
+			ArtistName:  strings.Join(artists, ", "),
+			DurationSec: song.Duration / 1000,
+			Lyrics:      lyrics,
+		})
+	}
+
+	if len(candidates) == 0 {
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		return nil, errLyricsNotFound
+	}
+	return candidates, nil
+}
+
+type musixmatchProvider struct {
+	client     *http.Client
+	token      string
+	tokenTime  time.Time
+	tokenMutex sync.Mutex
+}
+
+var (
+	musixmatchInstance *musixmatchProvider
+	musixmatchOnce     sync.Once
+)
+
+func sharedMusixmatchProvider(client *http.Client) *musixmatchProvider {
+	musixmatchOnce.Do(func() {
+		musixmatchInstance = &musixmatchProvider{client: client}
+	})
+	return musixmatchInstance
+}
+
+func (p *musixmatchProvider) Name() string { return "Musixmatch" }
+
+func (p *musixmatchProvider) request(endpoint string, params url.Values) ([]byte, error) {
+	params.Set("app_id", "web-desktop-app-v1.0")
+	params.Set("format", "json")
+	apiURL := fmt.Sprintf("https://apic-desktop.musixmatch.com/ws/1.1/%s?%s", endpoint, params.Encode())
+
+	req, err := http.NewRequest("GET", apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authority", "apic-desktop.musixmatch.com")
+	req.Header.Set("Cookie", "AWSELBCORS=0; AWSELB=0")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (p *musixmatchProvider) userToken() (string, error) {
+	p.tokenMutex.Lock()
+	defer p.tokenMutex.Unlock()
+
+	if p.token != "" && time.Since(p.tokenTime) < 10*time.Minute {
+		return p.token, nil
+	}
+
+	body, err := p.request("token.get", url.Values{"user_language": {"en"}})
+	if err != nil {
+		return "", err
+	}
+
+	var tokenResp struct {
+		Message struct {
+			Header struct {
+				StatusCode int `json:"status_code"`
+			} `json:"header"`
+			Body json.RawMessage `json:"body"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("failed to parse token response: %v", err)
+	}
+
+	var tokenBody struct {
+		UserToken string `json:"user_token"`
+	}
+	if tokenResp.Message.Header.StatusCode != 200 || json.Unmarshal(tokenResp.Message.Body, &tokenBody) != nil || tokenBody.UserToken == "" {
+		return "", fmt.Errorf("token request rejected (status %d)", tokenResp.Message.Header.StatusCode)
+	}
+
+	p.token = tokenBody.UserToken
+	p.tokenTime = time.Now()
+	return p.token, nil
+}
+
+func (p *musixmatchProvider) Fetch(query LyricsQuery) ([]LyricsCandidate, error) {
+	token, err := p.userToken()
+	if err != nil {
+		return nil, err
+	}
+
+	params := url.Values{
+		"namespace":       {"lyrics_richsynched"},
+		"subtitle_format": {"lrc"},
+		"q_track":         {query.TrackName},
+		"q_artist":        {query.ArtistName},
+		"usertoken":       {token},
+	}
+	if query.SpotifyID != "" {
+		params.Set("track_spotify_id", "spotify:track:"+query.SpotifyID)
+	}
+	if query.DurationSec > 0 {
+		params.Set("q_duration", strconv.Itoa(query.DurationSec))
+		params.Set("f_subtitle_length", strconv.Itoa(query.DurationSec))
+	}
+
+	body, err := p.request("macro.subtitles.get", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var macro struct {
+		Message struct {
+			Body struct {
+				MacroCalls map[string]struct {
+					Message struct {
+						Header struct {
+							StatusCode int `json:"status_code"`
+						} `json:"header"`
+						Body json.RawMessage `json:"body"`
+					} `json:"message"`
+				} `json:"macro_calls"`
+			} `json:"body"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &macro); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %v", err)
+	}
+
+	call := func(name string, target interface{}) bool {
+		entry, ok := macro.Message.Body.MacroCalls[name]
+		if !ok || entry.Message.Header.StatusCode != 200 {
+			return false
+		}
+		return json.Unmarshal(entry.Message.Body, target) == nil
+	}
+
+	var matcher struct {
+		Track struct {
+			TrackName    string `json:"track_name"`
+			ArtistName   string `json:"artist_name"`
+			TrackLength  int    `json:"track_length"`
+			Instrumental int    `json:"instrumental"`
+		} `json:"track"`
+	}
+	if !call("matcher.track.get", &matcher) {
+		if entry, ok := macro.Message.Body.MacroCalls["matcher.track.get"]; ok && entry.Message.Header.StatusCode == 404 {
+			return nil, fmt.Errorf("%w: no matching track", errLyricsNotFound)
+		}
+		return nil, fmt.Errorf("track matcher request failed")
+	}
+	if matcher.Track.Instrumental == 1 {
+		return nil, fmt.Errorf("%w: track is instrumental", errLyricsNotFound)
+	}
+
+	candidate := LyricsCandidate{
+		Provider:    p.Name(),
+		TrackName:   matcher.Track.TrackName,
+		ArtistName:  matcher.Track.ArtistName,
+		DurationSec: matcher.Track.TrackLength,
+	}
+
+	var subtitles struct {
+		SubtitleList []struct {
+			Subtitle struct {
+				SubtitleBody string `json:"subtitle_body"`
+			} `json:"subtitle"`
+		} `json:"subtitle_list"`
+	}
+	if call("track.subtitles.get", &subtitles) && len(subtitles.SubtitleList) > 0 {
+		if lyrics := ParseLRC(subtitles.SubtitleList[0].Subtitle.SubtitleBody); !lyrics.Error && len(lyrics.Lines) > 0 {
+			candidate.Lyrics = lyrics
+			return []LyricsCandidate{candidate}, nil
+		}
+	}
+
+	var plain struct {
+		Lyrics struct {
+			LyricsBody string `json:"lyrics_body"`
+		} `json:"lyrics"`
+	}
+	if call("track.lyrics.get", &plain) && strings.TrimSpace(plain.Lyrics.LyricsBody) != "" {
+		candidate.Lyrics = plainLyricsResponse(plain.Lyrics.LyricsBody)
+		return []LyricsCandidate{candidate}, nil
+	}
+
+	return nil, errLyricsNotFound
+}
+
+func plainLyricsResponse(text string) *LyricsResponse {
+	resp := &LyricsResponse{SyncType: LyricsSyncNone, Lines: []LyricsLine{}}
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "******* This Lyrics is NOT for Commercial use") {
+			continue
+		}
+		resp.Lines = append(resp.Lines, LyricsLine{Words: line})
+	}
+	if len(resp.Lines) == 0 {
+		resp.Error = true
+	}
+	return resp
+}
+
+type geniusProvider struct {
+	client *http.Client
+}
+
+var geniusContainerPattern = regexp.MustCompile(`(?s)<div[^>]*data-lyrics-container="true"[^>]*>(.*?)</div>`)
+
+var geniusBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>`)
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]+>`)
+
+func (p *geniusProvider) Name() string { return "Genius" }
+
+func (p *geniusProvider) Fetch(query LyricsQuery) ([]LyricsCandidate, error) {
+	searchURL := "https://genius.com/api/search/song?per_page=5&q=" + url.QueryEscape(query.ArtistName+" "+query.TrackName)
+	resp, err := p.client.Get(searchURL)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	var search struct {
+		Response struct {
+			Sections []struct {
+				Hits []struct {
+					Result struct {
+						Title         string `json:"title"`
+						URL           string `json:"url"`
+						PrimaryArtist struct {
+							Name string `json:"name"`
+						} `json:"primary_artist"`
+					} `json:"result"`
+				} `json:"hits"`
+			} `json:"sections"`
+		} `json:"response"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&search); err != nil {
+		return nil, fmt.Errorf("parse failed: %v", err)
+	}
+
+	var pageErr error
+	for _, section := range search.Response.Sections {
+		for _, hit := range section.Hits {
+			if hit.Result.URL == "" || tokenSimilarity(query.TrackName, hit.Result.Title) < 0.5 {
+				continue
+			}
+
+			text, err := p.fetchPage(hit.Result.URL)
+			if err != nil {
+				pageErr = err
+				continue
+			}
+			if text == "" {
+				continue
+			}
+
+			return []LyricsCandidate{{
+				Provider:   p.Name(),
+				TrackName:  hit.Result.Title,
+				ArtistName: hit.Result.PrimaryArtist.Name,
+				Lyrics:     plainLyricsResponse(text),
+			}}, nil
+		}
+	}
+
+	if pageErr != nil {
+		return nil, pageErr
+	}
+	return nil, errLyricsNotFound
+}
+
+func (p *geniusProvider) fetchPage(pageURL string) (string, error) {
+	resp, err := p.client.Get(pageURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, match := range geniusContainerPattern.FindAllStringSubmatch(string(body), -1) {
+		block := geniusBreakPattern.ReplaceAllString(match[1], "\n")
+		block = htmlTagPattern.ReplaceAllString(block, "")
+		sb.WriteString(html.UnescapeString(block))
+		sb.WriteString("\n")
+	}
+
+	var lines []string
+	for _, line := range strings.Split(sb.String(), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+type localLyricsFile struct {
+	path   string
+	parts  []string
+	artist string
+	title  string
+}
+
+type localLyricsProvider struct {
+	dirs  []string
+	once  sync.Once
+	files []localLyricsFile
+}
+
+var localLyricsTrackPrefix = regexp.MustCompile(`^\d{1,3}(?:\s*[-._]\s*|\s+)`)
+
+func (p *localLyricsProvider) Name() string { return "Local" }
+
+func (p *localLyricsProvider) index() []localLyricsFile {
+	p.once.Do(func() {
+		for _, dir := range p.dirs {
+			_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+				if err != nil || d.IsDir() {
+					return nil
+				}
+				ext := strings.ToLower(filepath.Ext(path))
+				if ext != ".lrc" && ext != ".ttml" {
+					return nil
+				}
+
+				stem := localLyricsTrackPrefix.ReplaceAllString(strings.TrimSuffix(d.Name(), filepath.Ext(d.Name())), "")
+				file := localLyricsFile{path: path}
+				for _, part := range strings.Split(stem, " - ") {
+					if normalized := normalizeForMatch(part); normalized != "" {
+						file.parts = append(file.parts, normalized)
+					}
+				}
+				if parts := strings.SplitN(stem, " - ", 2); len(parts) == 2 {
+					file.artist, file.title = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+				} else {
+					file.title = strings.TrimSpace(stem)
+				}
+				p.files = append(p.files, file)
+				return nil
+			})
+		}
+	})
+	return p.files
+}
+
+func (f *localLyricsFile) matches(title, artist string) bool {
+	titleAt := -1
+	for i, part := range f.parts {
+		if part == title {
+			titleAt = i
+			break
+		}
+	}
+	if titleAt < 0 {
+		return false
+	}
+	if len(f.parts) == 1 {
+		return true
+	}
+	if artist == "" {
+		return false
+	}
+	for i, part := range f.parts {
+		if i != titleAt && (part == artist || strings.Contains(artist, part) || strings.Contains(part, artist)) {
+			return true
+		}
+	}
+	return false
+}
+
+func lrcHeaderTags(content string) map[string]string {
+	tags := make(map[string]string)
+	for _, raw := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+			continue
+		}
+		key, value, ok := strings.Cut(line[1:len(line)-1], ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		switch key {
+		case "ti", "ar", "length":
+			if _, seen := tags[key]; !seen {
+				tags[key] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return tags
+}
+
+func (p *localLyricsProvider) Fetch(query LyricsQuery) ([]LyricsCandidate, error) {
+	title := normalizeForMatch(query.TrackName)
+	artist := normalizeForMatch(query.ArtistName)
+	if title == "" {
+		return nil, fmt.Errorf("track name is required")
+	}
+
+	var candidates []LyricsCandidate
+	for _, file := range p.index() {
+		if !file.matches(title, artist) {
+			continue
+		}
+
+		data, err := os.ReadFile(file.path)
+		if err != nil {
+			continue
+		}
+		lyrics, err := ParseLyricsContent(string(data))
+		if err != nil {
+			continue
+		}
+
+		candidate := LyricsCandidate{
+			Provider:   p.Name(),
+			TrackName:  file.title,
+			ArtistName: file.artist,
+			Lyrics:     lyrics,
+		}
+		tags := lrcHeaderTags(string(data))
+		if tags["ti"] != "" {
+			candidate.TrackName = tags["ti"]
+		}
+		if tags["ar"] != "" {
+			candidate.ArtistName = tags["ar"]
+		}
+		if candidate.ArtistName == "" {
+			dir := filepath.Dir(file.path)
+			for i := 0; i < 2 && candidate.ArtistName == ""; i++ {
+				if name := normalizeForMatch(filepath.Base(dir)); artist != "" && name != "" && strings.Contains(name, artist) {
+					candidate.ArtistName = filepath.Base(dir)
+				}
+				dir = filepath.Dir(dir)
+			}
+			if candidate.ArtistName == "" {
+				continue
+			}
+		}
+		if artist != "" && tokenSimilarity(query.ArtistName, candidate.ArtistName) < 0.5 {
+			continue
+		}
+		if length := tags["length"]; length != "" {
+			if minutes, seconds, ok := strings.Cut(length, ":"); ok {
+				m, errM := strconv.Atoi(strings.TrimSpace(minutes))
+				sec, errS := strconv.ParseFloat(strings.TrimSpace(seconds), 64)
+				if errM == nil && errS == nil {
+					candidate.DurationSec = m*60 + int(sec+0.5)
+				}
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: no local lyrics file", errLyricsNotFound)
+	}
+	return candidates, nil
+}
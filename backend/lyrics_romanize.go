@@ -0,0 +1,393 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var kanaRomaji = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o",
+	'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo", 'ゎ': "wa", 'ゔ': "vu",
+}
+
+var hangulInitials = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+
+var hangulVowels = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+
+var hangulFinals = []struct {
+	coda, keep, carry string
+}{
+	{"", "", ""}, {"k", "", "g"}, {"k", "", "kk"}, {"k", "k", "s"}, {"n", "", "n"},
+	{"n", "n", "j"}, {"n", "n", ""}, {"t", "", "d"}, {"l", "", "r"}, {"k", "l", "g"},
+	{"m", "l", "m"}, {"l", "l", "b"}, {"l", "l", "s"}, {"l", "l", "t"}, {"p", "l", "p"},
+	{"l", "l", ""}, {"m", "", "m"}, {"p", "", "b"}, {"p", "p", "s"}, {"t", "", "s"},
+	{"t", "", "ss"}, {"ng", "ng", ""}, {"t", "", "j"}, {"t", "", "ch"}, {"k", "", "k"},
+	{"t", "", "t"}, {"p", "", "p"}, {"t", "", ""},
+}
+
+var pinyinGroups = []string{
+	"a:啊阿", "ai:爱哀唉矮碍", "an:安暗岸按", "ang:昂", "ao:傲奥",
+	"ba:把吧八爸拔巴", "bai:白百败摆", "ban:半伴办般板班", "bang:帮", "bao:抱保宝包报饱",
+	"bei:被北背悲杯备", "ben:本奔笨", "beng:崩", "bi:比笔必闭避逼鼻毕壁", "bian:边变遍便",
+	"biao:表", "bie:别", "bin:滨", "bing:冰并病", "bo:波博拨播", "bu:不步部布补",
+	"ca:擦", "cai:才彩猜菜采", "can:残灿惨", "cang:藏苍", "cao:草", "ce:侧", "ceng:曾层",
+	"cha:差茶查", "chan:缠", "chang:长唱常场肠尝", "chao:朝超潮吵", "che:车彻",
+	"chen:沉尘晨陈", "cheng:成城承程称诚乘", "chi:吃迟持池尺", "chong:冲虫宠", "chou:愁丑",
+	"chu:出初处除楚触", "chuan:穿船传川", "chuang:窗床闯创", "chui:吹垂", "chun:春纯唇",
+	"ci:次此词刺辞", "cong:从匆聪", "cu:粗", "cui:催脆", "cun:存村", "cuo:错",
+	"da:大打答达", "dai:带待代戴袋呆", "dan:但单淡蛋担", "dang:当挡荡", "dao:到道倒刀岛导",
+	"de:的得德", "deng:等灯登", "di:地底第低滴敌弟帝", "dian:点电典店", "diao:掉",
+	"die:跌蝶", "ding:定顶", "diu:丢", "dong:懂动东冬冻洞", "dou:都豆斗", "du:度独读毒肚",
+	"duan:断短段", "dui:对队", "dun:顿", "duo:多朵躲夺",
+	"e:饿恶", "en:恩", "er:而儿耳二",
+	"fa:发法", "fan:反翻烦犯饭凡", "fang:方放房芳防", "fei:飞非费肥", "fen:分份纷粉",
+	"feng:风疯封峰逢", "fo:佛", "fou:否", "fu:父福服付复夫浮负富",
+	"gai:该改盖", "gan:感干敢甘", "gang:刚钢", "gao:告高搞", "ge:个歌哥各格隔", "gei:给",
+	"gen:跟根", "geng:更", "gong:公工共功", "gou:够狗", "gu:故孤古顾鼓", "gua:挂",
+	"guai:怪乖", "guan:关管惯观", "guang:光广", "gui:归贵鬼", "gun:滚", "guo:过国果",
+	"ha:哈", "hai:还海孩害", "han:寒喊汗", "hang:航", "hao:好号", "he:和河喝合何",
+	"hei:黑", "hen:很恨", "heng:横", "hong:红", "hou:后候厚", "hu:呼忽湖护胡虎",
+	"hua:花话画化华", "huai:怀坏", "huan:换欢环幻", "huang:黄慌荒", "hui:会回灰挥毁",
+	"hun:魂", "huo:或火活获",
+	"ji:几记机己急寂极即积计继迹级集", "jia:家加假价", "jian:见间简坚尖渐键建",
+	"jiang:将讲江", "jiao:叫交脚教角", "jie:姐接节结街解界借", "jin:进今近尽紧金",
+	"jing:经静睛竟境惊", "jiu:就久九旧酒", "ju:句举局剧聚", "juan:卷", "jue:觉决绝", "jun:君",
+	"ka:卡", "kai:开", "kan:看", "kang:抗", "kao:靠考", "ke:可刻渴客颗", "ken:肯",
+	"kong:空恐控", "kou:口", "ku:哭苦酷", "kuai:快", "kuan:宽", "kuang:狂", "kun:困", "kuo:阔",
+	"la:拉啦", "lai:来", "lan:蓝烂懒", "lang:浪狼", "lao:老", "le:了乐", "lei:泪累雷",
+	"leng:冷", "li:里离理力立丽礼历", "lian:脸恋连", "liang:两亮凉量", "liao:聊", "lie:烈",
+	"lin:林临", "ling:另灵零", "liu:留流六", "long:龙", "lou:楼", "lu:路露", "lü:旅绿",
+	"luan:乱", "lun:论", "luo:落",
+	"ma:吗妈马嘛", "mai:买卖", "man:满慢", "mang:忙盲", "mao:毛", "me:么", "mei:没美每妹",
+	"men:们门", "meng:梦", "mi:迷密米", "mian:面", "miao:秒", "mie:灭", "min:民",
+	"ming:明名命", "mo:莫默模摸", "mu:目木母",
+	"na:那拿哪", "nai:奈", "nan:难男南", "nao:脑", "ne:呢", "nei:内", "neng:能", "ni:你",
+	"nian:年念", "niang:娘", "niao:鸟", "nin:您", "ning:宁", "niu:牛", "nong:浓", "nu:努",
+	"nü:女", "nuan:暖",
+	"o:哦",
+	"pa:怕", "pai:排", "pan:盼", "pang:旁", "pao:跑", "pei:陪配", "peng:朋", "pi:皮",
+	"pian:片骗", "piao:飘", "pin:拼", "ping:平", "po:破",
+	"qi:起其气期奇七骑", "qian:前千", "qiang:墙强", "qiao:悄", "qie:切", "qin:亲",
+	"qing:情请清轻", "qiu:秋求", "qu:去取曲", "quan:全", "que:却缺", "qun:群",
+	"ran:然燃", "rang:让", "re:热", "ren:人认忍", "reng:仍", "ri:日", "rong:容", "rou:柔",
+	"ru:如", "ruan:软", "ruo:若弱",
+	"sa:洒", "san:三散", "sang:桑", "se:色", "sen:森", "sha:杀沙", "shan:山闪",
+	"shang:上伤", "shao:少烧", "she:舍", "shei:谁", "shen:身深什神", "sheng:生声胜",
+	"shi:是时事世十使失实始视", "shou:手受守首", "shu:书属树数", "shuang:双", "shui:水睡",
+	"shun:顺", "shuo:说", "si:死思四", "song:送", "su:诉", "suan:算", "sui:虽碎随岁",
+	"suo:所锁",
+	"ta:他她它", "tai:太", "tan:谈", "tang:躺", "tao:逃", "te:特", "teng:疼", "ti:体提",
+	"tian:天甜", "tiao:跳", "ting:听停", "tong:同痛", "tou:头", "tu:突", "tui:退", "tuo:脱",
+	"wa:哇", "wai:外", "wan:完晚万玩", "wang:忘望往王", "wei:为未微位", "wen:问温",
+	"wo:我", "wu:无物",
+	"xi:喜西希息", "xia:下", "xian:现先", "xiang:想像向", "xiao:笑小", "xie:些谢",
+	"xin:心新", "xing:行星醒", "xiong:胸", "xiu:修", "xu:需许", "xuan:选", "xue:雪学",
+	"xun:寻",
+	"ya:呀", "yan:眼言", "yang:样阳", "yao:要", "ye:也夜", "yi:一以已意", "yin:因音",
+	"ying:应", "yong:永用", "you:有又", "yu:雨与遇", "yuan:远愿", "yue:月", "yun:云",
+	"zai:在再", "zan:咱", "zao:早", "ze:则", "zen:怎", "zha:炸", "zhan:站", "zhang:张",
+	"zhao:找", "zhe:这着", "zhen:真", "zheng:正", "zhi:只知", "zhong:中重", "zhou:周",
+	"zhu:住", "zhuan:转", "zhui:追", "zhun:准", "zhuo:捉", "zi:自子", "zong:总", "zou:走",
+	"zui:最", "zuo:做",
+}
+
+var romanizedVariantNames = map[string]string{
+	"ja": "romaji",
+	"ko": "romaja",
+	"zh": "pinyin",
+}
+
+var hanziPinyin = func() map[rune]string {
+	table := make(map[rune]string)
+	for _, group := range pinyinGroups {
+		syllable, chars, _ := strings.Cut(group, ":")
+		for _, r := range chars {
+			if _, exists := table[r]; !exists {
+				table[r] = syllable
+			}
+		}
+	}
+	return table
+}()
+
+func isKana(r rune) bool {
+	return unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r)
+}
+
+func isHangulSyllable(r rune) bool {
+	return r >= 0xAC00 && r <= 0xD7A3
+}
+
+func DetectLyricsScript(lyrics *LyricsResponse) string {
+	var kana, hangul, han int
+	for _, line := range lyrics.Lines {
+		for _, r := range line.Words {
+			switch {
+			case isKana(r):
+				kana++
+			case isHangulSyllable(r):
+				hangul++
+			case unicode.Is(unicode.Han, r):
+				han++
+			}
+		}
+	}
+
+	switch {
+	case kana > 0 && kana*4 >= han:
+		return "ja"
+	case hangul > 0 && hangul >= han:
+		return "ko"
+	case kana > 0:
+		return "ja"
+	case han > 0:
+		return "zh"
+	}
+	return ""
+}
+
+func toHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - 0x60
+	}
+	return r
+}
+
+func RomanizeKana(text string) string {
+	var sb strings.Builder
+	runes := []rune(text)
+	prevKana := false
+	doubleNext := false
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == 'ー' {
+			out := sb.String()
+			if last := strings.LastIndexAny(out, "aeiou"); last >= 0 && last == len(out)-1 {
+				sb.WriteByte(out[last])
+			}
+			continue
+		}
+
+		h := toHiragana(r)
+		romaji, ok := kanaRomaji[h]
+		if !ok {
+			if h == 'っ' {
+				if !prevKana && sb.Len() > 0 && !strings.HasSuffix(sb.String(), " ") {
+					sb.WriteByte(' ')
+				}
+				doubleNext = true
+				prevKana = true
+				continue
+			}
+			if prevKana && !unicode.IsSpace(r) && !unicode.IsPunct(r) {
+				sb.WriteByte(' ')
+			}
+			sb.WriteRune(r)
+			prevKana = false
+			continue
+		}
+
+		if !prevKana && sb.Len() > 0 {
+			if out := sb.String(); !strings.HasSuffix(out, " ") {
+				sb.WriteByte(' ')
+			}
+		}
+
+		if i+1 < len(runes) {
+			next := toHiragana(runes[i+1])
+			switch next {
+			case 'ゃ', 'ゅ', 'ょ':
+				if strings.HasSuffix(romaji, "i") && len(romaji) > 1 {
+					small := kanaRomaji[next]
+					if romaji == "shi" || romaji == "chi" || romaji == "ji" {
+						romaji = romaji[:len(romaji)-1] + small[1:]
+					} else {
+						romaji = romaji[:len(romaji)-1] + small
+					}
+					i++
+				}
+			case 'ぁ', 'ぃ', 'ぅ', 'ぇ', 'ぉ':
+				if len(romaji) > 1 {
+					romaji = romaji[:len(romaji)-1] + kanaRomaji[next]
+				} else if romaji == "u" {
+					romaji = "w" + kanaRomaji[next]
+				}
+				i++
+			}
+		}
+
+		if doubleNext {
+			if strings.HasPrefix(romaji, "ch") {
+				sb.WriteByte('t')
+			} else if romaji != "" && !strings.ContainsRune("aeiou", rune(romaji[0])) {
+				sb.WriteByte(romaji[0])
+			}
+			doubleNext = false
+		}
+
+		sb.WriteString(romaji)
+		prevKana = true
+	}
+
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func RomanizeHangul(text string) string {
+	var sb strings.Builder
+	runes := []rune(text)
+
+	for i, r := range runes {
+		if !isHangulSyllable(r) {
+			sb.WriteRune(r)
+			continue
+		}
+
+		index := int(r - 0xAC00)
+		initial, vowel, final := index/(21*28), (index/28)%21, index%28
+
+		if i > 0 && isHangulSyllable(runes[i-1]) {
+			prevFinal := int(runes[i-1]-0xAC00) % 28
+			if initial == 11 {
+				sb.WriteString(hangulFinals[prevFinal].carry)
+			} else if (initial == 5 && (prevFinal == 8 || prevFinal == 4)) || (initial == 2 && prevFinal == 8) {
+				sb.WriteString("l")
+			} else {
+				sb.WriteString(hangulInitials[initial])
+			}
+		} else {
+			sb.WriteString(hangulInitials[initial])
+		}
+
+		sb.WriteString(hangulVowels[vowel])
+
+		if final == 0 {
+			continue
+		}
+		nextIsVowel := false
+		if i+1 < len(runes) && isHangulSyllable(runes[i+1]) {
+			nextIndex := int(runes[i+1] - 0xAC00)
+			nextInitial := nextIndex / (21 * 28)
+			nextIsVowel = nextInitial == 11
+			if (nextInitial == 5 && (final == 8 || final == 4)) || (nextInitial == 2 && final == 8) {
+				sb.WriteString("l")
+				continue
+			}
+			if nextInitial == 2 || nextInitial == 6 {
+				switch hangulFinals[final].coda {
+				case "k":
+					sb.WriteString("ng")
+					continue
+				case "t":
+					sb.WriteString("n")
+					continue
+				case "p":
+					sb.WriteString("m")
+					continue
+				}
+			}
+		}
+		if nextIsVowel {
+			sb.WriteString(hangulFinals[final].keep)
+		} else {
+			sb.WriteString(hangulFinals[final].coda)
+		}
+	}
+
+	return sb.String()
+}
+
+func RomanizePinyin(text string) string {
+	var sb strings.Builder
+	prevHan := false
+	for _, r := range text {
+		syllable, ok := hanziPinyin[r]
+		if !ok && unicode.Is(unicode.Han, r) {
+			syllable, ok = string(r), true
+		}
+		if ok {
+			if sb.Len() > 0 && !strings.HasSuffix(sb.String(), " ") {
+				sb.WriteByte(' ')
+			}
+			sb.WriteString(syllable)
+			prevHan = true
+			continue
+		}
+		if prevHan && !unicode.IsSpace(r) && !unicode.IsPunct(r) {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(r)
+		prevHan = false
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func RomanizeLyrics(lyrics *LyricsResponse) *LyricsVariant {
+	if lyrics == nil {
+		return nil
+	}
+
+	var romanize func(string) string
+	script := DetectLyricsScript(lyrics)
+	switch script {
+	case "ja":
+		romanize = RomanizeKana
+	case "ko":
+		romanize = RomanizeHangul
+	case "zh":
+		romanize = RomanizePinyin
+	default:
+		return nil
+	}
+
+	variant := &LyricsVariant{
+		Kind:     LyricsVariantRomanized,
+		Language: script + "-Latn",
+		Name:     romanizedVariantNames[script],
+		Source:   "built-in",
+	}
+
+	letters, leftover := 0, 0
+	variant.Lines = make([]LyricsLine, len(lyrics.Lines))
+	for i, line := range lyrics.Lines {
+		words := romanize(line.Words)
+		for _, r := range words {
+			if unicode.IsLetter(r) {
+				letters++
+			}
+			if unicode.Is(unicode.Han, r) {
+				leftover++
+			}
+		}
+		variant.Lines[i] = LyricsLine{
+			StartTimeMs: line.StartTimeMs,
+			EndTimeMs:   line.EndTimeMs,
+			Words:       words,
+		}
+	}
+
+	if leftover > 0 {
+		if leftover*4 > letters {
+			fmt.Printf("[LyricsVariants] Skipping %s romanization: %d characters could not be romanized\n", script, leftover)
+			return nil
+		}
+		variant.Partial = true
+	}
+	return variant
+}
@@ -0,0 +1,497 @@
+package backend
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	LyricsSyncSyllable = "SYLLABLE_SYNCED"
+	LyricsSyncLine     = "LINE_SYNCED"
+	LyricsSyncNone     = "UNSYNCED"
+)
+
+var lrcWordTagPattern = regexp.MustCompile(`<(\d+:\d+(?:[.:]\d+)?)>`)
+
+func parseLRCTime(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	fraction := ""
+	if idx := strings.LastIndexAny(value, ".,"); idx >= 0 {
+		fraction = value[idx+1:]
+		value = value[:idx]
+	}
+
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, false
+	}
+	if fraction == "" && len(parts) == 3 && len(parts[2]) == 2 && len(parts[0]) <= 2 {
+		fraction = parts[2]
+		parts = parts[:2]
+	}
+
+	var total int64
+	for _, part := range parts {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		total = total*60 + n
+	}
+	ms := total * 1000
+
+	if fraction != "" {
+		if len(fraction) > 3 {
+			fraction = fraction[:3]
+		}
+		n, err := strconv.ParseInt(fraction, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		for i := len(fraction); i < 3; i++ {
+			n *= 10
+		}
+		ms += n
+	}
+
+	return ms, true
+}
+
+func formatLRCTime(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60000, (ms/1000)%60, (ms%1000)/10)
+}
+
+func lyricsMs(value string) (int64, bool) {
+	if value == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	return n, err == nil
+}
+
+func msString(ms int64) string {
+	return strconv.FormatInt(ms, 10)
+}
+
+func ParseLRC(content string) *LyricsResponse {
+	resp := &LyricsResponse{SyncType: LyricsSyncNone, Lines: []LyricsLine{}}
+
+	type timedLine struct {
+		start int64
+		line  LyricsLine
+	}
+	var timed []timedLine
+	var endMarkers []int64
+	var offset int64
+
+	for _, raw := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		text := strings.TrimSpace(raw)
+		if text == "" {
+			continue
+		}
+
+		var stamps []int64
+		metadata := false
+		for strings.HasPrefix(text, "[") {
+			closeBracket := strings.Index(text, "]")
+			if closeBracket < 0 {
+				break
+			}
+			tag := text[1:closeBracket]
+			if ms, ok := parseLRCTime(tag); ok {
+				stamps = append(stamps, ms)
+				text = strings.TrimSpace(text[closeBracket+1:])
+				continue
+			}
+			if len(stamps) == 0 && strings.Contains(tag, ":") {
+				metadata = true
+				if value, ok := strings.CutPrefix(strings.ToLower(tag), "offset:"); ok {
+					if n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+						offset = n
+					}
+				}
+			}
+			break
+		}
+		if metadata {
+			continue
+		}
+
+		if len(stamps) == 0 {
+			resp.Lines = append(resp.Lines, LyricsLine{Words: text})
+			continue
+		}
+
+		words, syllables, wordsEnd := parseLRCWordTags(text, stamps[0])
+		if words == "" && len(syllables) == 0 {
+			endMarkers = append(endMarkers, stamps...)
+			continue
+		}
+
+		for i, start := range stamps {
+			line := LyricsLine{StartTimeMs: msString(start), Words: words}
+			if i == 0 {
+				line.Syllables = syllables
+				if wordsEnd > 0 {
+					line.EndTimeMs = msString(wordsEnd)
+				}
+			}
+			timed = append(timed, timedLine{start: start, line: line})
+		}
+	}
+
+	if len(timed) == 0 {
+		if len(resp.Lines) == 0 {
+			resp.Error = true
+		}
+		return resp
+	}
+
+	sort.SliceStable(timed, func(i, j int) bool { return timed[i].start < timed[j].start })
+	sort.Slice(endMarkers, func(i, j int) bool { return endMarkers[i] < endMarkers[j] })
+
+	resp.SyncType = LyricsSyncLine
+	resp.Lines = resp.Lines[:0]
+	for i := range timed {
+		line := timed[i].line
+
+		next := int64(-1)
+		if i+1 < len(timed) {
+			next = timed[i+1].start
+		}
+		if line.EndTimeMs == "" {
+			for _, marker := range endMarkers {
+				if marker > timed[i].start && (next < 0 || marker <= next) {
+					line.EndTimeMs = msString(marker)
+					break
+				}
+			}
+		}
+
+		if len(line.Syllables) > 0 {
+			resp.SyncType = LyricsSyncSyllable
+			last := &line.Syllables[len(line.Syllables)-1]
+			if last.EndTimeMs == "" {
+				if line.EndTimeMs != "" {
+					last.EndTimeMs = line.EndTimeMs
+				} else if next >= 0 {
+					last.EndTimeMs = msString(next)
+				}
+			}
+		}
+
+		resp.Lines = append(resp.Lines, line)
+	}
+
+	if offset != 0 {
+		ShiftLyrics(resp, -offset)
+	}
+	return resp
+}
+
+func parseLRCWordTags(text string, lineStart int64) (string, []LyricsSyllable, int64) {
+	matches := lrcWordTagPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text, nil, 0
+	}
+
+	var syllables []LyricsSyllable
+	if prefix := text[:matches[0][0]]; strings.TrimSpace(prefix) != "" {
+		syllables = append(syllables, LyricsSyllable{StartTimeMs: msString(lineStart), Text: prefix})
+	}
+
+	var trailingEnd int64
+	for i, m := range matches {
+		ms, _ := parseLRCTime(text[m[2]:m[3]])
+		segmentEnd := len(text)
+		if i+1 < len(matches) {
+			segmentEnd = matches[i+1][0]
+		}
+		segment := text[m[1]:segmentEnd]
+
+		if len(syllables) > 0 && syllables[len(syllables)-1].EndTimeMs == "" {
+			syllables[len(syllables)-1].EndTimeMs = msString(ms)
+		}
+		if strings.TrimSpace(segment) == "" {
+			if i == len(matches)-1 {
+				trailingEnd = ms
+			} else if len(syllables) > 0 {
+				syllables[len(syllables)-1].Text += segment
+			}
+			continue
+		}
+		syllables = append(syllables, LyricsSyllable{StartTimeMs: msString(ms), Text: segment})
+	}
+
+	return joinSyllables(syllables), syllables, trailingEnd
+}
+
+func joinSyllables(syllables []LyricsSyllable) string {
+	var sb strings.Builder
+	for _, syllable := range syllables {
+		sb.WriteString(syllable.Text)
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+func parseTTMLTime(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return 0, false
+	case strings.HasSuffix(value, "ms"):
+		n, err := strconv.ParseFloat(strings.TrimSuffix(value, "ms"), 64)
+		return int64(n), err == nil
+	case strings.HasSuffix(value, "s") && !strings.Contains(value, ":"):
+		n, err := strconv.ParseFloat(strings.TrimSuffix(value, "s"), 64)
+		return int64(n*1000 + 0.5), err == nil
+	case strings.HasSuffix(value, "m") && !strings.Contains(value, ":"):
+		n, err := strconv.ParseFloat(strings.TrimSuffix(value, "m"), 64)
+		return int64(n*60000 + 0.5), err == nil
+	case !strings.Contains(value, ":"):
+		n, err := strconv.ParseFloat(value, 64)
+		return int64(n*1000 + 0.5), err == nil
+	}
+	return parseLRCTime(value)
+}
+
+func ttmlAttr(attrs []xml.Attr, name string) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func ttmlTiming(attrs []xml.Attr) (int64, int64, bool) {
+	begin, ok := parseTTMLTime(ttmlAttr(attrs, "begin"))
+	if !ok {
+		return 0, 0, false
+	}
+	end, hasEnd := parseTTMLTime(ttmlAttr(attrs, "end"))
+	if !hasEnd {
+		if dur, ok := parseTTMLTime(ttmlAttr(attrs, "dur")); ok {
+			end = begin + dur
+		}
+	}
+	return begin, end, true
+}
+
+func ParseTTML(content string) (*LyricsResponse, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	decoder.Strict = false
+
+	resp := &LyricsResponse{SyncType: LyricsSyncNone, Lines: []LyricsLine{}}
+
+	type spanState struct {
+		timed bool
+		begin int64
+		end   int64
+		text  strings.Builder
+	}
+
+	var (
+		inParagraph bool
+		line        LyricsLine
+		plain       strings.Builder
+		spans       []*spanState
+		hasLineTime bool
+	)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse TTML: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				inParagraph = true
+				line = LyricsLine{}
+				plain.Reset()
+				spans = spans[:0]
+				if begin, end, ok := ttmlTiming(t.Attr); ok {
+					line.StartTimeMs = msString(begin)
+					if end > begin {
+						line.EndTimeMs = msString(end)
+					}
+					hasLineTime = true
+				}
+			case "span":
+				if !inParagraph {
+					continue
+				}
+				span := &spanState{}
+				span.begin, span.end, span.timed = ttmlTiming(t.Attr)
+				spans = append(spans, span)
+			case "br":
+				if inParagraph {
+					plain.WriteString(" ")
+				}
+			}
+
+		case xml.CharData:
+			if !inParagraph {
+				continue
+			}
+			text := string(t)
+			plain.WriteString(text)
+			if len(spans) > 0 && spans[len(spans)-1].timed {
+				spans[len(spans)-1].text.WriteString(text)
+			} else if n := len(line.Syllables); n > 0 && strings.TrimSpace(text) == "" {
+				line.Syllables[n-1].Text += " "
+			}
+
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "span":
+				if len(spans) == 0 {
+					continue
+				}
+				span := spans[len(spans)-1]
+				spans = spans[:len(spans)-1]
+				if span.timed && span.text.Len() > 0 {
+					syllable := LyricsSyllable{StartTimeMs: msString(span.begin), Text: span.text.String()}
+					if span.end > span.begin {
+						syllable.EndTimeMs = msString(span.end)
+					}
+					line.Syllables = append(line.Syllables, syllable)
+				}
+			case "p":
+				inParagraph = false
+				if len(line.Syllables) > 0 {
+					line.Words = joinSyllables(line.Syllables)
+					if line.StartTimeMs == "" {
+						line.StartTimeMs = line.Syllables[0].StartTimeMs
+						hasLineTime = true
+					}
+					if line.EndTimeMs == "" {
+						line.EndTimeMs = line.Syllables[len(line.Syllables)-1].EndTimeMs
+					}
+					resp.SyncType = LyricsSyncSyllable
+				} else {
+					line.Words = strings.Join(strings.Fields(plain.String()), " ")
+				}
+				if line.Words != "" {
+					resp.Lines = append(resp.Lines, line)
+				}
+			}
+		}
+	}
+
+	if len(resp.Lines) == 0 {
+		return nil, fmt.Errorf("no lyrics found in TTML")
+	}
+	if hasLineTime && resp.SyncType == LyricsSyncNone {
+		resp.SyncType = LyricsSyncLine
+	}
+	return resp, nil
+}
+
+func (c *LyricsClient) ConvertToTTML(lyrics *LyricsResponse, trackName, artistName string) string {
+	timing := "None"
+	switch lyrics.SyncType {
+	case LyricsSyncSyllable:
+		timing = "Word"
+	case LyricsSyncLine:
+		timing = "Line"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(fmt.Sprintf(`<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" xmlns:itunes="http://music.apple.com/lyric-ttml-internal" itunes:timing="%s" xml:lang="und">`+"\n", timing))
+	sb.WriteString("  <head>\n    <metadata>\n")
+	sb.WriteString(fmt.Sprintf("      <ttm:title>%s</ttm:title>\n", html.EscapeString(trackName)))
+	sb.WriteString(fmt.Sprintf("      <ttm:agent type=\"person\" xml:id=\"v1\"><ttm:name type=\"full\">%s</ttm:name></ttm:agent>\n", html.EscapeString(artistName)))
+	sb.WriteString("    </metadata>\n  </head>\n")
+	sb.WriteString("  <body>\n    <div>\n")
+
+	for i, line := range lyrics.Lines {
+		if line.Words == "" && len(line.Syllables) == 0 {
+			continue
+		}
+
+		start, timed := lyricsMs(line.StartTimeMs)
+		if !timed || timing == "None" {
+			sb.WriteString(fmt.Sprintf("      <p>%s</p>\n", html.EscapeString(line.Words)))
+			continue
+		}
+
+		end, hasEnd := lyricsMs(line.EndTimeMs)
+		if !hasEnd {
+			end = start + 5000
+			if i+1 < len(lyrics.Lines) {
+				if next, ok := lyricsMs(lyrics.Lines[i+1].StartTimeMs); ok && next > start {
+					end = next
+				}
+			}
+		}
+
+		sb.WriteString(fmt.Sprintf(`      <p begin="%s" end="%s" ttm:agent="v1">`, formatTTMLTime(start), formatTTMLTime(end)))
+		if len(line.Syllables) == 0 {
+			sb.WriteString(html.EscapeString(line.Words))
+		} else {
+			for j, syllable := range line.Syllables {
+				sStart, _ := lyricsMs(syllable.StartTimeMs)
+				sEnd, ok := lyricsMs(syllable.EndTimeMs)
+				if !ok {
+					sEnd = end
+					if j+1 < len(line.Syllables) {
+						if next, ok := lyricsMs(line.Syllables[j+1].StartTimeMs); ok {
+							sEnd = next
+						}
+					}
+				}
+				text := syllable.Text
+				trailing := strings.HasSuffix(text, " ")
+				sb.WriteString(fmt.Sprintf(`<span begin="%s" end="%s">%s</span>`, formatTTMLTime(sStart), formatTTMLTime(sEnd), html.EscapeString(strings.TrimSpace(text))))
+				if trailing && j < len(line.Syllables)-1 {
+					sb.WriteString(" ")
+				}
+			}
+		}
+		sb.WriteString("</p>\n")
+	}
+
+	sb.WriteString("    </div>\n  </body>\n</tt>\n")
+	return sb.String()
+}
+
+func formatTTMLTime(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	return fmt.Sprintf("%02d:%02d.%03d", ms/60000, (ms/1000)%60, ms%1000)
+}
+
+func ParseLyricsContent(content string) (*LyricsResponse, error) {
+	trimmed := strings.TrimSpace(strings.TrimPrefix(content, "\ufeff"))
+	if strings.HasPrefix(trimmed, "<") && strings.Contains(trimmed, "<tt") {
+		return ParseTTML(trimmed)
+	}
+
+	resp := ParseLRC(trimmed)
+	if resp.Error || len(resp.Lines) == 0 {
+		return nil, fmt.Errorf("no lyrics found")
+	}
+	return resp, nil
+}
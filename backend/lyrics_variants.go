@@ -0,0 +1,201 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type LyricsVariant struct {
+	Kind     string       `json:"kind"`
+	Language string       `json:"language"`
+	Name     string       `json:"name"`
+	Source   string       `json:"source,omitempty"`
+	Partial  bool         `json:"partial,omitempty"`
+	Lines    []LyricsLine `json:"lines"`
+}
+
+const (
+	LyricsVariantRomanized  = "romanized"
+	LyricsVariantTranslated = "translated"
+)
+
+var lyricsLanguageCodes = map[string]string{
+	"ja": "jpn",
+	"ko": "kor",
+	"zh": "zho",
+	"en": "eng",
+}
+
+func (v *LyricsVariant) TagName() string {
+	return "LYRICS-" + strings.ToUpper(v.Name)
+}
+
+func (v *LyricsVariant) FileSuffix() string {
+	return "." + strings.ToLower(v.Name)
+}
+
+func (v *LyricsVariant) languageCode() string {
+	base, _, _ := strings.Cut(v.Language, "-")
+	if code, ok := lyricsLanguageCodes[strings.ToLower(base)]; ok {
+		return code
+	}
+	return "und"
+}
+
+func (v *LyricsVariant) Response(original *LyricsResponse) *LyricsResponse {
+	syncType := LyricsSyncLine
+	if original != nil && original.SyncType == LyricsSyncNone {
+		syncType = LyricsSyncNone
+	}
+	return &LyricsResponse{SyncType: syncType, Lines: v.Lines}
+}
+
+func lyricsVariantFromLRC(original *LyricsResponse, content, kind, language, name, source string) *LyricsVariant {
+	if strings.TrimSpace(content) == "" || original == nil {
+		return nil
+	}
+
+	parsed := ParseLRC(content)
+	if parsed.Error || len(parsed.Lines) == 0 {
+		return nil
+	}
+
+	byStart := make(map[string]string)
+	for _, line := range parsed.Lines {
+		if line.StartTimeMs != "" && strings.TrimSpace(line.Words) != "" {
+			byStart[line.StartTimeMs] = line.Words
+		}
+	}
+
+	variant := &LyricsVariant{Kind: kind, Language: language, Name: name, Source: source}
+	matched, expected := 0, 0
+	for _, line := range original.Lines {
+		if strings.TrimSpace(line.Words) != "" {
+			expected++
+		}
+		words, ok := byStart[line.StartTimeMs]
+		if ok {
+			matched++
+		}
+		variant.Lines = append(variant.Lines, LyricsLine{
+			StartTimeMs: line.StartTimeMs,
+			EndTimeMs:   line.EndTimeMs,
+			Words:       words,
+		})
+	}
+	if matched == 0 {
+		return nil
+	}
+	variant.Partial = matched < expected
+	return variant
+}
+
+func borrowRomanizedVariant(lyrics *LyricsResponse, others []LyricsCandidate) {
+	if lyrics == nil {
+		return
+	}
+	for _, variant := range lyrics.Variants {
+		if variant.Kind == LyricsVariantRomanized {
+			return
+		}
+	}
+
+	for _, other := range others {
+		if other.Lyrics == nil || other.Lyrics == lyrics {
+			continue
+		}
+		for _, variant := range other.Lyrics.Variants {
+			if variant.Kind != LyricsVariantRomanized {
+				continue
+			}
+			byStart := make(map[string]string)
+			for _, line := range variant.Lines {
+				if line.StartTimeMs != "" && strings.TrimSpace(line.Words) != "" {
+					byStart[line.StartTimeMs] = line.Words
+				}
+			}
+			borrowed := variant
+			borrowed.Lines = nil
+			matched, expected := 0, 0
+			for _, line := range lyrics.Lines {
+				if strings.TrimSpace(line.Words) != "" {
+					expected++
+				}
+				words, ok := byStart[line.StartTimeMs]
+				if ok {
+					matched++
+				}
+				borrowed.Lines = append(borrowed.Lines, LyricsLine{
+					StartTimeMs: line.StartTimeMs,
+					EndTimeMs:   line.EndTimeMs,
+					Words:       words,
+				})
+			}
+			if matched*2 < expected {
+				continue
+			}
+			borrowed.Partial = variant.Partial || matched < expected
+			lyrics.Variants = append(lyrics.Variants, borrowed)
+			return
+		}
+	}
+}
+
+func ApplyLyricsVariants(lyrics *LyricsResponse, kinds []string) {
+	if lyrics == nil {
+		return
+	}
+
+	wanted := make(map[string]bool)
+	for _, kind := range kinds {
+		wanted[kind] = true
+	}
+
+	kept := lyrics.Variants[:0]
+	hasRomanized := false
+	for _, variant := range lyrics.Variants {
+		if !wanted[variant.Kind] {
+			continue
+		}
+		if variant.Kind == LyricsVariantRomanized {
+			hasRomanized = true
+		}
+		kept = append(kept, variant)
+	}
+	lyrics.Variants = kept
+
+	if wanted[LyricsVariantRomanized] && !hasRomanized {
+		if variant := RomanizeLyrics(lyrics); variant != nil {
+			lyrics.Variants = append(lyrics.Variants, *variant)
+		}
+	}
+}
+
+func (c *LyricsClient) WriteLyricsVariantFiles(basePath string, lyrics *LyricsResponse, trackName, artistName string) []string {
+	var written []string
+	if lyrics == nil {
+		return written
+	}
+
+	ext := filepath.Ext(basePath)
+	stem := strings.TrimSuffix(basePath, ext)
+	for i := range lyrics.Variants {
+		variant := &lyrics.Variants[i]
+		response := variant.Response(lyrics)
+
+		content := c.ConvertToLRC(response, trackName, artistName)
+		if ext == ".ttml" {
+			content = c.ConvertToTTML(response, trackName, artistName)
+		}
+
+		path := stem + variant.FileSuffix() + ext
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			fmt.Printf("[LyricsVariants] Failed to write %s: %v\n", path, err)
+			continue
+		}
+		written = append(written, path)
+	}
+	return written
+}
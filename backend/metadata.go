@@ -16,21 +16,29 @@ import (
 )
 
 type Metadata struct {
-	Title       string
-	Artist      string
-	Album       string
-	AlbumArtist string
-	Date        string
-	ReleaseDate string
-	TrackNumber int
-	TotalTracks int
-	DiscNumber  int
-	TotalDiscs  int
-	URL         string
-	Copyright   string
-	Publisher   string
-	Lyrics      string
-	Description string
+	Title        string
+	Artist       string
+	Album        string
+	AlbumArtist  string
+	Date         string
+	ReleaseDate  string
+	TrackNumber  int
+	TotalTracks  int
+	DiscNumber   int
+	TotalDiscs   int
+	URL          string
+	Copyright    string
+	Publisher    string
+	Lyrics       string
+	Description  string
+	ISRC         string
+	Artists      []string
+	AlbumArtists []string
+	Genres       []string
+	Composers    []string
+	Lyricists    []string
+	Producers    []string
+	Performers   []string
 }
 
 func EmbedMetadata(filepath string, metadata Metadata, coverPath string) error {
@@ -52,14 +60,20 @@ func EmbedMetadata(filepath string, metadata Metadata, coverPath string) error {
 	if metadata.Title != "" {
 		_ = cmt.Add(flacvorbis.FIELD_TITLE, metadata.Title)
 	}
-	if metadata.Artist != "" {
-		_ = cmt.Add(flacvorbis.FIELD_ARTIST, metadata.Artist)
+	if artist := displayArtist(metadata.Artists, metadata.Artist); artist != "" {
+		_ = cmt.Add(flacvorbis.FIELD_ARTIST, artist)
+	}
+	for _, artist := range artistValues(metadata.Artists, "") {
+		_ = cmt.Add("ARTISTS", artist)
 	}
 	if metadata.Album != "" {
 		_ = cmt.Add(flacvorbis.FIELD_ALBUM, metadata.Album)
 	}
-	if metadata.AlbumArtist != "" {
-		_ = cmt.Add("ALBUMARTIST", metadata.AlbumArtist)
+	if albumArtist := displayArtist(metadata.AlbumArtists, metadata.AlbumArtist); albumArtist != "" {
+		_ = cmt.Add("ALBUMARTIST", albumArtist)
+	}
+	for _, artist := range artistValues(metadata.AlbumArtists, "") {
+		_ = cmt.Add("ALBUMARTISTS", artist)
 	}
 	if metadata.Date != "" {
 		_ = cmt.Add(flacvorbis.FIELD_DATE, metadata.Date)
@@ -85,6 +99,24 @@ func EmbedMetadata(filepath string, metadata Metadata, coverPath string) error {
 	if metadata.Description != "" {
 		_ = cmt.Add("DESCRIPTION", metadata.Description)
 	}
+	if metadata.ISRC != "" {
+		_ = cmt.Add("ISRC", metadata.ISRC)
+	}
+	for _, genre := range metadata.Genres {
+		_ = cmt.Add("GENRE", genre)
+	}
+	for _, composer := range metadata.Composers {
+		_ = cmt.Add("COMPOSER", composer)
+	}
+	for _, lyricist := range metadata.Lyricists {
+		_ = cmt.Add("LYRICIST", lyricist)
+	}
+	for _, producer := range metadata.Producers {
+		_ = cmt.Add("PRODUCER", producer)
+	}
+	for _, performer := range metadata.Performers {
+		_ = cmt.Add("PERFORMER", performer)
+	}
 
 	if metadata.Lyrics != "" {
 		_ = cmt.Add("LYRICS", metadata.Lyrics)
@@ -110,6 +142,83 @@ func EmbedMetadata(filepath string, metadata Metadata, coverPath string) error {
 	return nil
 }
 
+func artistValues(list []string, joined string) []string {
+	values := make([]string, 0, len(list))
+	for _, artist := range list {
+		if artist = strings.TrimSpace(artist); artist != "" {
+			values = append(values, artist)
+		}
+	}
+	if len(values) == 0 && joined != "" {
+		values = append(values, joined)
+	}
+	return values
+}
+
+func displayArtist(list []string, joined string) string {
+	if joined = strings.TrimSpace(joined); joined != "" {
+		return joined
+	}
+	return strings.Join(artistValues(list, ""), ", ")
+}
+
+func JoinArtists(artists []string, separator string) string {
+	if separator == "" {
+		separator = ", "
+	}
+	return strings.Join(artistValues(artists, ""), separator)
+}
+
+func EmbedArtistTags(filePath, artist, albumArtist string, artists, albumArtists []string) error {
+	artist = displayArtist(artists, artist)
+	albumArtist = displayArtist(albumArtists, albumArtist)
+	artists = artistValues(artists, "")
+	albumArtists = artistValues(albumArtists, "")
+	if artist == "" && albumArtist == "" {
+		return nil
+	}
+
+	tags, err := ReadAllTags(filePath)
+	if err != nil {
+		return err
+	}
+
+	if artist != "" {
+		tags["ARTIST"] = []string{artist}
+	}
+	if len(artists) > 0 {
+		tags["ARTISTS"] = artists
+	}
+	if albumArtist != "" {
+		tags["ALBUMARTIST"] = []string{albumArtist}
+	}
+	if len(albumArtists) > 0 {
+		tags["ALBUMARTISTS"] = albumArtists
+	}
+
+	return WriteAllTags(filePath, tags)
+}
+
+func EmbedCatalogTags(filePath, isrc, upc string) error {
+	if isrc == "" && upc == "" {
+		return nil
+	}
+
+	tags, err := ReadAllTags(filePath)
+	if err != nil {
+		return err
+	}
+
+	if isrc != "" {
+		tags["ISRC"] = []string{isrc}
+	}
+	if upc != "" {
+		tags["BARCODE"] = []string{upc}
+	}
+
+	return WriteAllTags(filePath, tags)
+}
+
 func embedCoverArt(f *flac.File, coverPath string) error {
 	imgData, err := os.ReadFile(coverPath)
 	if err != nil {
@@ -159,52 +268,7 @@ func EmbedLyricsOnly(filepath string, lyrics string) error {
 	if lyrics == "" {
 		return nil
 	}
-	f, err := flac.ParseFile(filepath)
-	if err != nil {
-		return fmt.Errorf("failed to parse FLAC file: %w", err)
-	}
-
-	var cmtIdx = -1
-	var existingCmt *flacvorbis.MetaDataBlockVorbisComment
-	for idx, block := range f.Meta {
-		if block.Type == flac.VorbisComment {
-			cmtIdx = idx
-			existingCmt, err = flacvorbis.ParseFromMetaDataBlock(*block)
-			if err != nil {
-				existingCmt = nil
-			}
-			break
-		}
-	}
-
-	cmt := flacvorbis.New()
-
-	if existingCmt != nil {
-		for _, comment := range existingCmt.Comments {
-			parts := strings.SplitN(comment, "=", 2)
-			if len(parts) == 2 {
-				fieldName := strings.ToUpper(parts[0])
-				if fieldName != "LYRICS" && fieldName != "UNSYNCEDLYRICS" && fieldName != "SYNCEDLYRICS" {
-					_ = cmt.Add(parts[0], parts[1])
-				}
-			}
-		}
-	}
-
-	_ = cmt.Add("LYRICS", lyrics)
-
-	cmtBlock := cmt.Marshal()
-	if cmtIdx < 0 {
-		f.Meta = append(f.Meta, &cmtBlock)
-	} else {
-		f.Meta[cmtIdx] = &cmtBlock
-	}
-
-	if err := f.Save(filepath); err != nil {
-		return fmt.Errorf("failed to save FLAC file: %w", err)
-	}
-
-	return nil
+	return embedFLACLyricsFields(filepath, map[string]string{"LYRICS": lyrics})
 }
 
 func ExtractCoverArt(filePath string) (string, error) {
@@ -667,10 +731,8 @@ func validateLyricsDuration(lyrics string, filepath string) (string, error) {
 }
 
 func parseLRCTimestamp(timestamp string) int64 {
-	var minutes, seconds, centiseconds int64
-	n, _ := fmt.Sscanf(timestamp, "%d:%d.%d", &minutes, &seconds, &centiseconds)
-	if n >= 2 {
-		return minutes*60*1000 + seconds*1000 + centiseconds*10
+	if ms, ok := parseLRCTime(timestamp); ok {
+		return ms
 	}
 	return -1
 }
@@ -733,10 +795,15 @@ func ExtractFullMetadataFromFile(filePath string) (Metadata, error) {
 			metadata.Title = value
 		case "artist":
 			metadata.Artist = value
+		case "artists":
+			metadata.Artists = splitMultiValueTag(value)
 		case "album":
 			metadata.Album = value
 		case "album_artist", "albumartist":
 			metadata.AlbumArtist = value
+			if strings.Contains(value, ";") {
+				metadata.AlbumArtists = splitMultiValueTag(value)
+			}
 		case "date", "year":
 			if metadata.Date == "" || len(value) > len(metadata.Date) {
 				metadata.Date = value
@@ -773,16 +840,46 @@ func ExtractFullMetadataFromFile(filePath string) (Metadata, error) {
 			metadata.Publisher = value
 		case "url":
 			metadata.URL = value
+		case "isrc", "tsrc":
+			metadata.ISRC = strings.ToUpper(strings.ReplaceAll(value, "-", ""))
+		case "genre":
+			metadata.Genres = splitMultiValueTag(value)
+		case "composer":
+			metadata.Composers = splitMultiValueTag(value)
+		case "lyricist", "text":
+			metadata.Lyricists = splitMultiValueTag(value)
+		case "producer":
+			metadata.Producers = splitMultiValueTag(value)
+		case "performer":
+			metadata.Performers = splitMultiValueTag(value)
 		case "description", "comment":
 			if metadata.Description == "" {
 				metadata.Description = value
 			}
+		case "lyrics", "unsyncedlyrics", "syncedlyrics", "uslt":
+			if metadata.Lyrics == "" {
+				metadata.Lyrics = value
+			}
+		default:
+			if strings.HasPrefix(key, "lyrics-") && metadata.Lyrics == "" {
+				metadata.Lyrics = value
+			}
 		}
 	}
 
 	return metadata, nil
 }
 
+func splitMultiValueTag(value string) []string {
+	var artists []string
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == 0 }) {
+		if part = strings.TrimSpace(part); part != "" {
+			artists = append(artists, part)
+		}
+	}
+	return artists
+}
+
 func EmbedMetadataToConvertedFile(filePath string, metadata Metadata, coverPath string) error {
 	ext := strings.ToLower(pathfilepath.Ext(filePath))
 
@@ -811,8 +908,20 @@ func embedMetadataToMP3(filePath string, metadata Metadata, coverPath string) er
 	if metadata.Title != "" {
 		tag.SetTitle(metadata.Title)
 	}
-	if metadata.Artist != "" {
-		tag.SetArtist(metadata.Artist)
+	if len(metadata.Artists) > 0 || len(metadata.AlbumArtists) > 0 || len(metadata.Genres) > 1 || len(metadata.Composers) > 1 || len(metadata.Lyricists) > 1 {
+		tag.SetVersion(4)
+	}
+
+	if artist := displayArtist(metadata.Artists, metadata.Artist); artist != "" {
+		tag.DeleteFrames("TPE1")
+		tag.AddTextFrame("TPE1", id3v2.EncodingUTF8, artist)
+	}
+	if len(metadata.Artists) > 0 {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: "ARTISTS",
+			Value:       strings.Join(artistValues(metadata.Artists, ""), "\x00"),
+		})
 	}
 	if metadata.Album != "" {
 		tag.SetAlbum(metadata.Album)
@@ -825,9 +934,16 @@ func embedMetadataToMP3(filePath string, metadata Metadata, coverPath string) er
 		tag.SetYear(year)
 	}
 
-	if metadata.AlbumArtist != "" {
+	if albumArtist := displayArtist(metadata.AlbumArtists, metadata.AlbumArtist); albumArtist != "" {
 		tag.DeleteFrames("TPE2")
-		tag.AddTextFrame("TPE2", id3v2.EncodingUTF8, metadata.AlbumArtist)
+		tag.AddTextFrame("TPE2", id3v2.EncodingUTF8, albumArtist)
+	}
+	if len(metadata.AlbumArtists) > 0 {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: "ALBUMARTISTS",
+			Value:       strings.Join(artistValues(metadata.AlbumArtists, ""), "\x00"),
+		})
 	}
 
 	if metadata.TrackNumber > 0 {
@@ -858,6 +974,26 @@ func embedMetadataToMP3(filePath string, metadata Metadata, coverPath string) er
 		tag.AddTextFrame("TPUB", id3v2.EncodingUTF8, metadata.Publisher)
 	}
 
+	if metadata.ISRC != "" {
+		tag.DeleteFrames("TSRC")
+		tag.AddTextFrame("TSRC", id3v2.EncodingUTF8, metadata.ISRC)
+	}
+	for frameID, values := range map[string][]string{"TCON": metadata.Genres, "TCOM": metadata.Composers, "TEXT": metadata.Lyricists} {
+		if len(values) > 0 {
+			tag.DeleteFrames(frameID)
+			tag.AddTextFrame(frameID, id3v2.EncodingUTF8, strings.Join(values, "\x00"))
+		}
+	}
+	for description, values := range map[string][]string{"PRODUCER": metadata.Producers, "PERFORMER": metadata.Performers} {
+		if len(values) > 0 {
+			tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+				Encoding:    id3v2.EncodingUTF8,
+				Description: description,
+				Value:       strings.Join(values, "\x00"),
+			})
+		}
+	}
+
 	if coverPath != "" && fileExists(coverPath) {
 
 		tag.DeleteFrames(tag.CommonID("Attached picture"))
@@ -909,14 +1045,14 @@ func embedMetadataToM4A(filePath string, metadata Metadata, coverPath string) er
 	if metadata.Title != "" {
 		args = append(args, "-metadata", "title="+metadata.Title)
 	}
-	if metadata.Artist != "" {
-		args = append(args, "-metadata", "artist="+metadata.Artist)
+	if artist := displayArtist(metadata.Artists, metadata.Artist); artist != "" {
+		args = append(args, "-metadata", "artist="+artist)
 	}
 	if metadata.Album != "" {
 		args = append(args, "-metadata", "album="+metadata.Album)
 	}
-	if metadata.AlbumArtist != "" {
-		args = append(args, "-metadata", "album_artist="+metadata.AlbumArtist)
+	if albumArtist := displayArtist(metadata.AlbumArtists, metadata.AlbumArtist); albumArtist != "" {
+		args = append(args, "-metadata", "album_artist="+albumArtist)
 	}
 	if metadata.Date != "" {
 		args = append(args, "-metadata", "date="+metadata.Date)
@@ -941,6 +1077,12 @@ func embedMetadataToM4A(filePath string, metadata Metadata, coverPath string) er
 	if metadata.Publisher != "" {
 		args = append(args, "-metadata", "publisher="+metadata.Publisher)
 	}
+	if len(metadata.Genres) > 0 {
+		args = append(args, "-metadata", "genre="+strings.Join(metadata.Genres, "; "))
+	}
+	if len(metadata.Composers) > 0 {
+		args = append(args, "-metadata", "composer="+strings.Join(metadata.Composers, "; "))
+	}
 
 	tmpOutputFile := strings.TrimSuffix(filePath, pathfilepath.Ext(filePath)) + ".tmp" + pathfilepath.Ext(filePath)
 	defer func() {
@@ -0,0 +1,406 @@
+package backend
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	PlaylistFormatM3U8 = "m3u8"
+	PlaylistFormatPLS  = "pls"
+	PlaylistFormatXSPF = "xspf"
+	PlaylistFormatJSPF = "jspf"
+)
+
+type PlaylistEntry struct {
+	Path       string `json:"path"`
+	Title      string `json:"title,omitempty"`
+	Artist     string `json:"artist,omitempty"`
+	Album      string `json:"album,omitempty"`
+	DurationMS int    `json:"duration_ms,omitempty"`
+	SpotifyID  string `json:"spotify_id,omitempty"`
+}
+
+type PlaylistTarget struct {
+	Format      string `json:"format"`
+	Absolute    bool   `json:"absolute"`
+	StripPrefix string `json:"strip_prefix,omitempty"`
+	PathPrefix  string `json:"path_prefix,omitempty"`
+	Backslashes bool   `json:"backslashes,omitempty"`
+	Suffix      string `json:"suffix,omitempty"`
+}
+
+type PlaylistExportRequest struct {
+	Name      string           `json:"name"`
+	OutputDir string           `json:"output_dir"`
+	Entries   []PlaylistEntry  `json:"entries"`
+	Targets   []PlaylistTarget `json:"targets"`
+}
+
+type xspfPlaylist struct {
+	XMLName   xml.Name    `xml:"playlist"`
+	Version   string      `xml:"version,attr"`
+	Namespace string      `xml:"xmlns,attr"`
+	Title     string      `xml:"title,omitempty"`
+	Tracks    []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location   string `xml:"location"`
+	Title      string `xml:"title,omitempty"`
+	Creator    string `xml:"creator,omitempty"`
+	Album      string `xml:"album,omitempty"`
+	Duration   int    `xml:"duration,omitempty"`
+	Identifier string `xml:"identifier,omitempty"`
+}
+
+type jspfTrack struct {
+	Location   []string `json:"location"`
+	Title      string   `json:"title,omitempty"`
+	Creator    string   `json:"creator,omitempty"`
+	Album      string   `json:"album,omitempty"`
+	Duration   int      `json:"duration,omitempty"`
+	Identifier []string `json:"identifier,omitempty"`
+}
+
+type jspfDocument struct {
+	Playlist struct {
+		Title string      `json:"title,omitempty"`
+		Track []jspfTrack `json:"track"`
+	} `json:"playlist"`
+}
+
+func ExportPlaylist(req PlaylistExportRequest) ([]string, error) {
+	if req.OutputDir == "" {
+		return nil, fmt.Errorf("output directory is required")
+	}
+
+	var entries []PlaylistEntry
+	for _, entry := range req.Entries {
+		if entry.Path != "" {
+			entries = append(entries, completePlaylistEntry(entry))
+		}
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("playlist has no entries")
+	}
+
+	targets := req.Targets
+	if len(targets) == 0 {
+		targets = []PlaylistTarget{{Format: PlaylistFormatM3U8}}
+	}
+
+	if err := os.MkdirAll(req.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	name := SanitizeFilename(req.Name)
+	if name == "" || (name == "Unknown" && !strings.EqualFold(strings.TrimSpace(req.Name), "Unknown")) {
+		name = "playlist"
+	}
+
+	var written []string
+	for _, target := range targets {
+		format := strings.ToLower(strings.TrimPrefix(target.Format, "."))
+
+		var data []byte
+		var err error
+		switch format {
+		case PlaylistFormatM3U8, "m3u":
+			data = buildM3U8(entries, req.OutputDir, target)
+		case PlaylistFormatPLS:
+			data = buildPLS(entries, req.OutputDir, target)
+		case PlaylistFormatXSPF:
+			data, err = buildXSPF(req.Name, entries, req.OutputDir, target)
+		case PlaylistFormatJSPF:
+			data, err = buildJSPF(req.Name, entries, req.OutputDir, target)
+		default:
+			return written, fmt.Errorf("unsupported playlist format: %s", target.Format)
+		}
+		if err != nil {
+			return written, fmt.Errorf("failed to encode %s playlist: %w", format, err)
+		}
+
+		path := FitPath(req.OutputDir, name+target.Suffix+"."+format)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return written, fmt.Errorf("failed to write playlist: %w", err)
+		}
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+func completePlaylistEntry(entry PlaylistEntry) PlaylistEntry {
+	if entry.Title == "" || entry.Artist == "" {
+		if metadata, err := ReadAudioMetadata(entry.Path); err == nil {
+			if entry.Title == "" {
+				entry.Title = metadata.Title
+			}
+			if entry.Artist == "" {
+				entry.Artist = metadata.Artist
+			}
+			if entry.Album == "" {
+				entry.Album = metadata.Album
+			}
+		}
+	}
+	if entry.DurationMS == 0 {
+		if quality, err := ProbeAudioQuality(entry.Path); err == nil && quality.Duration > 0 {
+			entry.DurationMS = int(quality.Duration * 1000)
+		}
+	}
+	return entry
+}
+
+func playlistLocation(path, playlistDir string, target PlaylistTarget) string {
+	location := path
+	if !target.Absolute {
+		if rel, err := filepath.Rel(playlistDir, path); err == nil {
+			location = rel
+		}
+	}
+
+	location = filepath.ToSlash(location)
+	if target.StripPrefix != "" {
+		location = strings.TrimPrefix(location, filepath.ToSlash(target.StripPrefix))
+	}
+	if target.PathPrefix != "" {
+		location = strings.TrimRight(target.PathPrefix, "/\\") + "/" + strings.TrimLeft(location, "/")
+	}
+	if target.Backslashes {
+		location = strings.ReplaceAll(location, "/", "\\")
+	}
+	return location
+}
+
+func playlistURI(path, playlistDir string, target PlaylistTarget) string {
+	location := playlistLocation(path, playlistDir, target)
+	if target.Backslashes {
+		return location
+	}
+	if len(location) >= 2 && location[1] == ':' {
+		return (&url.URL{Scheme: "file", Path: "/" + location}).String()
+	}
+	if strings.HasPrefix(location, "/") {
+		return (&url.URL{Scheme: "file", Path: location}).String()
+	}
+	return (&url.URL{Path: location}).String()
+}
+
+func playlistDisplayTitle(entry PlaylistEntry) string {
+	title := entry.Title
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(entry.Path), filepath.Ext(entry.Path))
+	}
+	if entry.Artist != "" {
+		return entry.Artist + " - " + title
+	}
+	return title
+}
+
+func playlistSeconds(entry PlaylistEntry) int {
+	if entry.DurationMS <= 0 {
+		return -1
+	}
+	return (entry.DurationMS + 500) / 1000
+}
+
+func buildM3U8(entries []PlaylistEntry, playlistDir string, target PlaylistTarget) []byte {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "#EXTINF:%d,%s\n", playlistSeconds(entry), playlistDisplayTitle(entry))
+		b.WriteString(playlistLocation(entry.Path, playlistDir, target) + "\n")
+	}
+	return []byte(b.String())
+}
+
+func buildPLS(entries []PlaylistEntry, playlistDir string, target PlaylistTarget) []byte {
+	var b strings.Builder
+	b.WriteString("[playlist]\n")
+	for i, entry := range entries {
+		n := i + 1
+		fmt.Fprintf(&b, "File%d=%s\n", n, playlistLocation(entry.Path, playlistDir, target))
+		fmt.Fprintf(&b, "Title%d=%s\n", n, playlistDisplayTitle(entry))
+		fmt.Fprintf(&b, "Length%d=%d\n", n, playlistSeconds(entry))
+	}
+	fmt.Fprintf(&b, "NumberOfEntries=%d\nVersion=2\n", len(entries))
+	return []byte(b.String())
+}
+
+func spotifyTrackIdentifier(spotifyID string) string {
+	if spotifyID == "" {
+		return ""
+	}
+	return "https://open.spotify.com/track/" + spotifyID
+}
+
+func buildXSPF(title string, entries []PlaylistEntry, playlistDir string, target PlaylistTarget) ([]byte, error) {
+	playlist := xspfPlaylist{Version: "1", Namespace: "http://xspf.org/ns/0/", Title: title}
+	for _, entry := range entries {
+		playlist.Tracks = append(playlist.Tracks, xspfTrack{
+			Location:   playlistURI(entry.Path, playlistDir, target),
+			Title:      entry.Title,
+			Creator:    entry.Artist,
+			Album:      entry.Album,
+			Duration:   entry.DurationMS,
+			Identifier: spotifyTrackIdentifier(entry.SpotifyID),
+		})
+	}
+
+	data, err := xml.MarshalIndent(playlist, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func buildJSPF(title string, entries []PlaylistEntry, playlistDir string, target PlaylistTarget) ([]byte, error) {
+	var doc jspfDocument
+	doc.Playlist.Title = title
+	for _, entry := range entries {
+		track := jspfTrack{
+			Location: []string{playlistURI(entry.Path, playlistDir, target)},
+			Title:    entry.Title,
+			Creator:  entry.Artist,
+			Album:    entry.Album,
+			Duration: entry.DurationMS,
+		}
+		if id := spotifyTrackIdentifier(entry.SpotifyID); id != "" {
+			track.Identifier = []string{id}
+		}
+		doc.Playlist.Track = append(doc.Playlist.Track, track)
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+func PlaylistEntriesFromHistory(spotifyIDs []string, appName string) ([]PlaylistEntry, error) {
+	items, err := GetHistoryItems(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]HistoryItem)
+	var ordered []string
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		if item.Path == "" || !fileExists(item.Path) {
+			continue
+		}
+		key := item.SpotifyID
+		if key == "" {
+			key = item.Path
+		}
+		if _, ok := byID[key]; !ok {
+			ordered = append(ordered, key)
+		}
+		byID[key] = item
+	}
+
+	var entries []PlaylistEntry
+	if len(spotifyIDs) == 0 {
+		for _, key := range ordered {
+			entries = append(entries, historyPlaylistEntry(byID[key]))
+		}
+		return entries, nil
+	}
+
+	for _, id := range spotifyIDs {
+		if item, ok := byID[id]; ok {
+			entries = append(entries, historyPlaylistEntry(item))
+		}
+	}
+	return entries, nil
+}
+
+func historyPlaylistEntry(item HistoryItem) PlaylistEntry {
+	return PlaylistEntry{
+		Path:       item.Path,
+		Title:      item.Title,
+		Artist:     item.Artists,
+		Album:      item.Album,
+		DurationMS: parseDuration(item.DurationStr),
+		SpotifyID:  item.SpotifyID,
+	}
+}
+
+func MatchPlaylistTracks(tracks []AlbumTrackMetadata, searchDirs []string, appName string) ([]PlaylistEntry, []AlbumTrackMetadata) {
+	ids := make([]string, 0, len(tracks))
+	for _, track := range tracks {
+		ids = append(ids, track.SpotifyID)
+	}
+
+	fromHistory, _ := PlaylistEntriesFromHistory(ids, appName)
+	byID := make(map[string]PlaylistEntry)
+	for _, entry := range fromHistory {
+		byID[entry.SpotifyID] = entry
+	}
+
+	type localFile struct {
+		artist string
+		path   string
+	}
+	byTitle := make(map[string][]localFile)
+	for _, dir := range searchDirs {
+		files, err := ListAudioFiles(dir)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			metadata, err := ReadAudioMetadata(file.Path)
+			if err != nil || metadata.Title == "" {
+				continue
+			}
+			title := normalizeForMatch(metadata.Title)
+			byTitle[title] = append(byTitle[title], localFile{artist: normalizeForMatch(metadata.Artist), path: file.Path})
+		}
+	}
+
+	findLocal := func(track AlbumTrackMetadata) string {
+		artist := track.Artists
+		if len(track.ArtistsList) > 0 {
+			artist = track.ArtistsList[0]
+		}
+		artist = normalizeForMatch(artist)
+		for _, candidate := range byTitle[normalizeForMatch(track.Name)] {
+			if strings.Contains(candidate.artist, artist) || strings.Contains(artist, candidate.artist) {
+				return candidate.path
+			}
+		}
+		return ""
+	}
+
+	var entries []PlaylistEntry
+	var missing []AlbumTrackMetadata
+	for _, track := range tracks {
+		entry, ok := byID[track.SpotifyID]
+		if !ok {
+			path := findLocal(track)
+			if path == "" {
+				missing = append(missing, track)
+				continue
+			}
+			entry = PlaylistEntry{Path: path, SpotifyID: track.SpotifyID}
+		}
+		entry.Title = track.Name
+		entry.Artist = track.Artists
+		entry.Album = track.AlbumName
+		if track.DurationMS > 0 {
+			entry.DurationMS = track.DurationMS
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, missing
+}
@@ -0,0 +1,436 @@
+package backend
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ImportedTrack struct {
+	Line       int    `json:"line"`
+	Raw        string `json:"raw"`
+	Artist     string `json:"artist,omitempty"`
+	Title      string `json:"title,omitempty"`
+	Album      string `json:"album,omitempty"`
+	ISRC       string `json:"isrc,omitempty"`
+	SpotifyID  string `json:"spotify_id,omitempty"`
+	DurationMS int    `json:"duration_ms,omitempty"`
+}
+
+type ImportMatch struct {
+	Track      ImportedTrack `json:"track"`
+	Match      *SearchResult `json:"match,omitempty"`
+	Method     string        `json:"method,omitempty"`
+	Confidence float64       `json:"confidence"`
+	Confident  bool          `json:"confident"`
+	Error      string        `json:"error,omitempty"`
+}
+
+type ImportReport struct {
+	Format    string        `json:"format"`
+	Matches   []ImportMatch `json:"matches"`
+	Confident int           `json:"confident"`
+	Uncertain int           `json:"uncertain"`
+	Unmatched int           `json:"unmatched"`
+}
+
+var importSpotifyTrackPattern = regexp.MustCompile(`(?:open\.spotify\.com/(?:intl-[a-z]+/)?track/|spotify:track:)([A-Za-z0-9]{22})`)
+
+var importLineNumberPattern = regexp.MustCompile(`^\s*\d{1,3}\s*[.)\-:]\s+`)
+
+var importTitleNoisePattern = regexp.MustCompile(`(?i)\s*[\(\[](?:feat\.?|ft\.?|with|remaster(?:ed)?|\d{4} remaster(?:ed)?)[^\)\]]*[\)\]]`)
+
+var importColumnAliases = map[string][]string{
+	"title":    {"track name", "title", "track", "name", "song", "song name", "track title"},
+	"artist":   {"artist name(s)", "artist name", "artists", "artist", "performer"},
+	"album":    {"album name", "album", "album title"},
+	"isrc":     {"isrc"},
+	"spotify":  {"track uri", "spotify uri", "uri", "spotify id", "spotify url", "url", "link"},
+	"duration": {"duration (ms)", "duration_ms", "duration", "length", "time"},
+}
+
+func DetectImportFormat(content, fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".m3u", ".m3u8":
+		return "m3u"
+	case ".csv", ".tsv":
+		return "csv"
+	}
+
+	trimmed := strings.TrimSpace(strings.TrimPrefix(content, "\ufeff"))
+	if strings.HasPrefix(trimmed, "#EXTM3U") || strings.HasPrefix(trimmed, "#EXTINF") {
+		return "m3u"
+	}
+
+	firstLine := strings.ToLower(strings.SplitN(trimmed, "\n", 2)[0])
+	if strings.ContainsAny(firstLine, ",;\t") {
+		for _, alias := range importColumnAliases["title"] {
+			if strings.Contains(firstLine, alias) {
+				return "csv"
+			}
+		}
+	}
+	return "text"
+}
+
+func ParsePlaylistImport(content, format, baseDir string) ([]ImportedTrack, error) {
+	content = strings.TrimPrefix(strings.ReplaceAll(content, "\r\n", "\n"), "\ufeff")
+	switch format {
+	case "m3u":
+		return parseM3UImport(content, baseDir), nil
+	case "csv":
+		return parseCSVImport(content)
+	case "text", "":
+		return parseTextImport(content), nil
+	default:
+		return nil, fmt.Errorf("unsupported import format: %s", format)
+	}
+}
+
+func parseM3UImport(content, baseDir string) []ImportedTrack {
+	var tracks []ImportedTrack
+	var pending *ImportedTrack
+
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#EXTM3U"):
+			continue
+		case strings.HasPrefix(line, "#EXTINF:"):
+			info := strings.TrimPrefix(line, "#EXTINF:")
+			track := ImportedTrack{Line: i + 1, Raw: line}
+			if comma := strings.IndexByte(info, ','); comma >= 0 {
+				if seconds, err := strconv.Atoi(strings.Fields(info[:comma] + " ")[0]); err == nil && seconds > 0 {
+					track.DurationMS = seconds * 1000
+				}
+				track.Artist, track.Title = splitArtistTitle(info[comma+1:])
+			}
+			pending = &track
+		case strings.HasPrefix(line, "#"):
+			continue
+		default:
+			track := ImportedTrack{Line: i + 1, Raw: line}
+			if pending != nil {
+				track = *pending
+				track.Raw = line
+			}
+			pending = nil
+
+			if m := importSpotifyTrackPattern.FindStringSubmatch(line); m != nil {
+				track.SpotifyID = m[1]
+			} else if path := resolveImportPath(line, baseDir); fileExists(path) {
+				if metadata, err := ReadAudioMetadata(path); err == nil && metadata.Title != "" {
+					track.Title = metadata.Title
+					track.Artist = metadata.Artist
+					track.Album = metadata.Album
+				}
+				if tags, err := ReadAllTags(path); err == nil && len(tags["ISRC"]) > 0 {
+					track.ISRC = tags["ISRC"][0]
+				}
+			}
+
+			if track.Title == "" && track.SpotifyID == "" {
+				base := filepath.Base(filepath.FromSlash(strings.ReplaceAll(line, "\\", "/")))
+				track.Artist, track.Title = splitArtistTitle(strings.TrimSuffix(base, filepath.Ext(base)))
+			}
+			tracks = append(tracks, track)
+		}
+	}
+
+	return tracks
+}
+
+func resolveImportPath(line, baseDir string) string {
+	path := filepath.FromSlash(strings.TrimPrefix(line, "file://"))
+	if !filepath.IsAbs(path) && baseDir != "" {
+		path = filepath.Join(baseDir, path)
+	}
+	return path
+}
+
+func parseCSVImport(content string) ([]ImportedTrack, error) {
+	firstLine := strings.SplitN(content, "\n", 2)[0]
+	delimiter := ','
+	for _, candidate := range []rune{'\t', ';'} {
+		if strings.Count(firstLine, string(candidate)) > strings.Count(firstLine, string(delimiter)) {
+			delimiter = candidate
+		}
+	}
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %w", err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("CSV file has no track rows")
+	}
+
+	columns := make(map[string]int)
+	for field, aliases := range importColumnAliases {
+		for _, alias := range aliases {
+			for i, header := range records[0] {
+				if _, taken := columns[field]; !taken && strings.EqualFold(strings.TrimSpace(header), alias) {
+					columns[field] = i
+				}
+			}
+		}
+	}
+	if _, ok := columns["title"]; !ok {
+		if _, ok := columns["spotify"]; !ok {
+			return nil, fmt.Errorf("CSV file has no title or Spotify URI column")
+		}
+	}
+
+	value := func(record []string, field string) string {
+		if i, ok := columns[field]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var tracks []ImportedTrack
+	for i, record := range records[1:] {
+		track := ImportedTrack{
+			Line:   i + 2,
+			Raw:    strings.Join(record, string(delimiter)),
+			Title:  value(record, "title"),
+			Artist: value(record, "artist"),
+			Album:  value(record, "album"),
+			ISRC:   strings.ToUpper(value(record, "isrc")),
+		}
+		if track.Title == "" && value(record, "spotify") == "" {
+			continue
+		}
+		if m := importSpotifyTrackPattern.FindStringSubmatch(value(record, "spotify")); m != nil {
+			track.SpotifyID = m[1]
+		}
+		track.DurationMS = parseImportDuration(value(record, "duration"))
+		tracks = append(tracks, track)
+	}
+
+	return tracks, nil
+}
+
+func parseImportDuration(value string) int {
+	if value == "" {
+		return 0
+	}
+	if strings.Contains(value, ":") {
+		return parseDuration(value)
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	if n < 10000 {
+		return n * 1000
+	}
+	return n
+}
+
+func parseTextImport(content string) []ImportedTrack {
+	var tracks []ImportedTrack
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		track := ImportedTrack{Line: i + 1, Raw: line}
+		if m := importSpotifyTrackPattern.FindStringSubmatch(line); m != nil {
+			track.SpotifyID = m[1]
+		} else {
+			track.Artist, track.Title = splitArtistTitle(importLineNumberPattern.ReplaceAllString(line, ""))
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks
+}
+
+func splitArtistTitle(value string) (string, string) {
+	value = strings.TrimSpace(value)
+	for _, separator := range []string{" - ", " – ", " — ", " -- "} {
+		if idx := strings.Index(value, separator); idx > 0 {
+			return strings.TrimSpace(value[:idx]), strings.TrimSpace(value[idx+len(separator):])
+		}
+	}
+	return "", value
+}
+
+func ResolveImportedTracks(ctx context.Context, tracks []ImportedTrack, threshold float64, delay time.Duration, progressCallback func(done, total int)) *ImportReport {
+	if threshold <= 0 {
+		threshold = 0.8
+	}
+
+	client := NewSpotifyMetadataClient()
+	report := &ImportReport{}
+
+	for i, track := range tracks {
+		if ctx.Err() != nil {
+			break
+		}
+		if i > 0 && delay > 0 {
+			time.Sleep(delay)
+		}
+
+		match := resolveImportedTrack(ctx, client, track)
+		match.Confident = match.Match != nil && match.Confidence >= threshold
+
+		switch {
+		case match.Match == nil:
+			report.Unmatched++
+		case match.Confident:
+			report.Confident++
+		default:
+			report.Uncertain++
+		}
+		report.Matches = append(report.Matches, match)
+
+		if progressCallback != nil {
+			progressCallback(i+1, len(tracks))
+		}
+	}
+
+	return report
+}
+
+func resolveImportedTrack(ctx context.Context, client *SpotifyMetadataClient, track ImportedTrack) ImportMatch {
+	match := ImportMatch{Track: track}
+
+	if track.SpotifyID != "" {
+		raw, err := client.fetchTrack(ctx, track.SpotifyID)
+		if err == nil {
+			formatted := client.formatTrackData(raw).Track
+			match.Match = &SearchResult{
+				ID:          formatted.SpotifyID,
+				Name:        formatted.Name,
+				Type:        "track",
+				Artists:     formatted.Artists,
+				AlbumName:   formatted.AlbumName,
+				Images:      formatted.Images,
+				ReleaseDate: formatted.ReleaseDate,
+				ExternalURL: fmt.Sprintf("https://open.spotify.com/track/%s", formatted.SpotifyID),
+				Duration:    formatted.DurationMS,
+			}
+			match.Method = "spotify_id"
+			match.Confidence = 1
+			return match
+		}
+		if track.Title == "" {
+			match.Error = fmt.Sprintf("failed to fetch Spotify track: %v", err)
+			return match
+		}
+	}
+
+	if IsValidISRC(track.ISRC) {
+		results, err := client.SearchByType(ctx, "isrc:"+track.ISRC, "track", 5, 0)
+		if err == nil && len(results) > 0 {
+			best, score := bestImportCandidate(track, results)
+			if track.Title == "" || score >= 0.5 {
+				match.Match = best
+				match.Method = "isrc"
+				match.Confidence = math.Max(score, 0.95)
+				return match
+			}
+		}
+	}
+
+	if track.Title == "" {
+		match.Error = "no title to search for"
+		return match
+	}
+
+	query := strings.TrimSpace(track.Artist + " " + importTitleNoisePattern.ReplaceAllString(track.Title, ""))
+	results, err := client.SearchByType(ctx, query, "track", 10, 0)
+	if err != nil {
+		match.Error = err.Error()
+		return match
+	}
+	if len(results) == 0 {
+		match.Error = "no search results"
+		return match
+	}
+
+	match.Match, match.Confidence = bestImportCandidate(track, results)
+	match.Method = "search"
+	return match
+}
+
+func bestImportCandidate(track ImportedTrack, results []SearchResult) (*SearchResult, float64) {
+	var best *SearchResult
+	bestScore := -1.0
+	for i := range results {
+		score := scoreImportCandidate(track, results[i])
+		if score > bestScore {
+			best, bestScore = &results[i], score
+		}
+	}
+	return best, math.Round(bestScore*100) / 100
+}
+
+func scoreImportCandidate(track ImportedTrack, result SearchResult) float64 {
+	if track.Title == "" {
+		return 1
+	}
+
+	titleScore := tokenSimilarity(importTitleNoisePattern.ReplaceAllString(track.Title, ""), importTitleNoisePattern.ReplaceAllString(result.Name, ""))
+
+	weights := 0.6
+	score := 0.6 * titleScore
+
+	if track.Artist != "" {
+		artistScore := tokenSimilarity(track.Artist, result.Artists)
+		if a, b := normalizeForMatch(track.Artist), normalizeForMatch(result.Artists); a != "" && (strings.Contains(b, a) || strings.Contains(a, b)) {
+			artistScore = 1
+		}
+		score += 0.3 * artistScore
+		weights += 0.3
+	}
+
+	if track.DurationMS > 0 && result.Duration > 0 {
+		diff := math.Abs(float64(track.DurationMS-result.Duration)) / 1000
+		durationScore := math.Max(0, 1-diff/15)
+		score += 0.1 * durationScore
+		weights += 0.1
+	}
+
+	if track.Album != "" && strings.EqualFold(normalizeForMatch(track.Album), normalizeForMatch(result.AlbumName)) {
+		score += 0.05
+	}
+
+	return math.Min(1, score/weights)
+}
+
+func tokenSimilarity(a, b string) float64 {
+	left := strings.Fields(normalizeForMatch(a))
+	right := strings.Fields(normalizeForMatch(b))
+	if len(left) == 0 || len(right) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(right))
+	for _, token := range right {
+		set[token] = true
+	}
+
+	common := 0
+	for _, token := range left {
+		if set[token] {
+			common++
+			delete(set, token)
+		}
+	}
+
+	return 2 * float64(common) / float64(len(left)+len(right))
+}
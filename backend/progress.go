@@ -31,6 +31,9 @@ type DownloadItem struct {
 	EndTime      int64          `json:"end_time"`
 	ErrorMessage string         `json:"error_message"`
 	FilePath     string         `json:"file_path"`
+	LyricsStatus string         `json:"lyrics_status,omitempty"`
+	LyricsSource string         `json:"lyrics_source,omitempty"`
+	LyricsInfo   string         `json:"lyrics_info,omitempty"`
 }
 
 var (
@@ -296,6 +299,20 @@ func SkipDownloadItem(id, filePath string) {
 	}
 }
 
+func SetDownloadItemLyrics(id, status, source, info string) {
+	downloadQueueLock.Lock()
+	defer downloadQueueLock.Unlock()
+
+	for i := range downloadQueue {
+		if downloadQueue[i].ID == id {
+			downloadQueue[i].LyricsStatus = status
+			downloadQueue[i].LyricsSource = source
+			downloadQueue[i].LyricsInfo = info
+			break
+		}
+	}
+}
+
 func GetDownloadQueue() DownloadQueueInfo {
 
 	ResetSessionIfComplete()
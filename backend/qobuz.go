@@ -4,11 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 )
@@ -46,7 +47,12 @@ type QobuzTrack struct {
 		Name string `json:"name"`
 		ID   int64  `json:"id"`
 	} `json:"performer"`
-	Album struct {
+	Composer struct {
+		Name string `json:"name"`
+		ID   int64  `json:"id"`
+	} `json:"composer"`
+	Performers string `json:"performers"`
+	Album      struct {
 		Title string `json:"title"`
 		ID    string `json:"id"`
 		Image struct {
@@ -61,6 +67,10 @@ type QobuzTrack struct {
 		Label struct {
 			Name string `json:"name"`
 		} `json:"label"`
+		Genre struct {
+			Name string `json:"name"`
+		} `json:"genre"`
+		UPC string `json:"upc"`
 	} `json:"album"`
 }
 
@@ -77,11 +87,11 @@ func NewQobuzDownloader() *QobuzDownloader {
 	}
 }
 
-func (q *QobuzDownloader) SearchByISRC(isrc string) (*QobuzTrack, error) {
+func (q *QobuzDownloader) searchTracks(query string, limit int) ([]QobuzTrack, error) {
 	apiBase := "https://www.qobuz.com/api.json/0.2/track/search?query="
-	url := fmt.Sprintf("%s%s&limit=1&app_id=%s", apiBase, isrc, q.appID)
+	searchURL := fmt.Sprintf("%s%s&limit=%d&app_id=%s", apiBase, url.QueryEscape(query), limit, q.appID)
 
-	resp, err := q.client.Get(url)
+	resp, err := q.client.Get(searchURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search track: %w", err)
 	}
@@ -111,11 +121,54 @@ func (q *QobuzDownloader) SearchByISRC(isrc string) (*QobuzTrack, error) {
 		return nil, fmt.Errorf("failed to decode response: %w (response: %s)", err, bodyStr)
 	}
 
-	if len(searchResp.Tracks.Items) == 0 {
+	return searchResp.Tracks.Items, nil
+}
+
+func (q *QobuzDownloader) SearchByISRC(isrc string) (*QobuzTrack, error) {
+	tracks, err := q.searchTracks(isrc, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tracks) == 0 {
 		return nil, fmt.Errorf("track not found for ISRC: %s", isrc)
 	}
 
-	return &searchResp.Tracks.Items[0], nil
+	return &tracks[0], nil
+}
+
+func (q *QobuzDownloader) SearchByMetadata(trackName, artistName, albumName string, durationSec int) (*QobuzTrack, error) {
+	tracks, err := q.searchTracks(trackName+" "+artistName, 10)
+	if err != nil {
+		return nil, err
+	}
+
+	title := normalizeForMatch(trackName)
+	artist := normalizeForMatch(artistName)
+	album := normalizeForMatch(albumName)
+	for i := range tracks {
+		track := &tracks[i]
+		candidate := normalizeForMatch(track.Title)
+		if candidate != title && normalizeForMatch(track.Title+" "+track.Version) != title {
+			continue
+		}
+		performer := normalizeForMatch(track.Performer.Name)
+		if performer == "" || (!strings.Contains(performer, artist) && !strings.Contains(artist, performer)) {
+			continue
+		}
+		durationKnown := durationSec > 0 && track.Duration > 0
+		if durationKnown && math.Abs(float64(track.Duration-durationSec)) > 3 {
+			continue
+		}
+		candidateAlbum := normalizeForMatch(track.Album.Title)
+		albumMatches := album != "" && candidateAlbum != "" && (strings.Contains(candidateAlbum, album) || strings.Contains(album, candidateAlbum))
+		if !durationKnown && !albumMatches {
+			continue
+		}
+		return track, nil
+	}
+
+	return nil, fmt.Errorf("no Qobuz track matches %s - %s", artistName, trackName)
 }
 
 func decodeXOR(data []byte) string {
@@ -379,62 +432,25 @@ func (q *QobuzDownloader) DownloadCoverArt(coverURL, filepath string) error {
 	return err
 }
 
-func buildQobuzFilename(title, artist, album, albumArtist, releaseDate string, trackNumber, discNumber int, format string, includeTrackNumber bool, position int, useAlbumTrackNumber bool) string {
-	var filename string
-
-	numberToUse := position
-	if useAlbumTrackNumber && trackNumber > 0 {
-		numberToUse = trackNumber
-	}
-
-	year := ""
-	if len(releaseDate) >= 4 {
-		year = releaseDate[:4]
-	}
-
-	if strings.Contains(format, "{") {
-		filename = format
-		filename = strings.ReplaceAll(filename, "{title}", title)
-		filename = strings.ReplaceAll(filename, "{artist}", artist)
-		filename = strings.ReplaceAll(filename, "{album}", album)
-		filename = strings.ReplaceAll(filename, "{album_artist}", albumArtist)
-		filename = strings.ReplaceAll(filename, "{year}", year)
-
-		if discNumber > 0 {
-			filename = strings.ReplaceAll(filename, "{disc}", fmt.Sprintf("%d", discNumber))
-		} else {
-			filename = strings.ReplaceAll(filename, "{disc}", "")
-		}
-
-		if numberToUse > 0 {
-			filename = strings.ReplaceAll(filename, "{track}", fmt.Sprintf("%02d", numberToUse))
-		} else {
+func (q *QobuzDownloader) DownloadByISRC(deezerISRC, outputDir, quality, filenameFormat string, includeTrackNumber bool, position int, spotifyTrackName, spotifyArtistName, spotifyAlbumName, spotifyAlbumArtist, spotifyReleaseDate string, useAlbumTrackNumber bool, spotifyCoverURL string, embedMaxQualityCover bool, spotifyTrackNumber, spotifyDiscNumber, spotifyTotalTracks int, spotifyTotalDiscs int, spotifyCopyright, spotifyPublisher, spotifyURL string, allowFallback bool) (string, error) {
+	fmt.Printf("Fetching track info for ISRC: %s\n", deezerISRC)
 
-			filename = regexp.MustCompile(`\{track\}\.\s*`).ReplaceAllString(filename, "")
-			filename = regexp.MustCompile(`\{track\}\s*-\s*`).ReplaceAllString(filename, "")
-			filename = regexp.MustCompile(`\{track\}\s*`).ReplaceAllString(filename, "")
-		}
-	} else {
-
-		switch format {
-		case "artist-title":
-			filename = fmt.Sprintf("%s - %s", artist, title)
-		case "title":
-			filename = title
-		default:
-			filename = fmt.Sprintf("%s - %s", title, artist)
+	if outputDir != "." {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create output directory: %w", err)
 		}
+	}
 
-		if includeTrackNumber && position > 0 {
-			filename = fmt.Sprintf("%02d. %s", numberToUse, filename)
-		}
+	track, err := q.SearchByISRC(deezerISRC)
+	if err != nil {
+		return "", err
 	}
 
-	return filename + ".flac"
+	return q.downloadTrack(track, deezerISRC, outputDir, quality, filenameFormat, includeTrackNumber, position, spotifyTrackName, spotifyArtistName, spotifyAlbumName, spotifyAlbumArtist, spotifyReleaseDate, useAlbumTrackNumber, spotifyCoverURL, embedMaxQualityCover, spotifyTrackNumber, spotifyDiscNumber, spotifyTotalTracks, spotifyTotalDiscs, spotifyCopyright, spotifyPublisher, spotifyURL, allowFallback)
 }
 
-func (q *QobuzDownloader) DownloadByISRC(deezerISRC, outputDir, quality, filenameFormat string, includeTrackNumber bool, position int, spotifyTrackName, spotifyArtistName, spotifyAlbumName, spotifyAlbumArtist, spotifyReleaseDate string, useAlbumTrackNumber bool, spotifyCoverURL string, embedMaxQualityCover bool, spotifyTrackNumber, spotifyDiscNumber, spotifyTotalTracks int, spotifyTotalDiscs int, spotifyCopyright, spotifyPublisher, spotifyURL string, allowFallback bool) (string, error) {
-	fmt.Printf("Fetching track info for ISRC: %s\n", deezerISRC)
+func (q *QobuzDownloader) DownloadByTrackID(trackID int64, outputDir, quality, filenameFormat string, includeTrackNumber bool, position int, spotifyTrackName, spotifyArtistName, spotifyAlbumName, spotifyAlbumArtist, spotifyReleaseDate string, useAlbumTrackNumber bool, spotifyCoverURL string, embedMaxQualityCover bool, spotifyTrackNumber, spotifyDiscNumber, spotifyTotalTracks int, spotifyTotalDiscs int, spotifyCopyright, spotifyPublisher, spotifyURL string, allowFallback bool) (string, error) {
+	fmt.Printf("Fetching Qobuz track: %d\n", trackID)
 
 	if outputDir != "." {
 		if err := os.MkdirAll(outputDir, 0755); err != nil {
@@ -442,11 +458,15 @@ func (q *QobuzDownloader) DownloadByISRC(deezerISRC, outputDir, quality, filenam
 		}
 	}
 
-	track, err := q.SearchByISRC(deezerISRC)
+	track, err := q.GetTrack(trackID)
 	if err != nil {
 		return "", err
 	}
 
+	return q.downloadTrack(track, track.ISRC, outputDir, quality, filenameFormat, includeTrackNumber, position, spotifyTrackName, spotifyArtistName, spotifyAlbumName, spotifyAlbumArtist, spotifyReleaseDate, useAlbumTrackNumber, spotifyCoverURL, embedMaxQualityCover, spotifyTrackNumber, spotifyDiscNumber, spotifyTotalTracks, spotifyTotalDiscs, spotifyCopyright, spotifyPublisher, spotifyURL, allowFallback)
+}
+
+func (q *QobuzDownloader) downloadTrack(track *QobuzTrack, isrc, outputDir, quality, filenameFormat string, includeTrackNumber bool, position int, spotifyTrackName, spotifyArtistName, spotifyAlbumName, spotifyAlbumArtist, spotifyReleaseDate string, useAlbumTrackNumber bool, spotifyCoverURL string, embedMaxQualityCover bool, spotifyTrackNumber, spotifyDiscNumber, spotifyTotalTracks int, spotifyTotalDiscs int, spotifyCopyright, spotifyPublisher, spotifyURL string, allowFallback bool) (string, error) {
 	artists := spotifyArtistName
 	trackTitle := spotifyTrackName
 	albumTitle := spotifyAlbumName
@@ -476,13 +496,22 @@ func (q *QobuzDownloader) DownloadByISRC(deezerISRC, outputDir, quality, filenam
 	}
 	fmt.Printf("Download URL obtained: %s\n", urlPreview)
 
-	safeArtist := sanitizeFilename(artists)
-	safeTitle := sanitizeFilename(trackTitle)
-	safeAlbum := sanitizeFilename(albumTitle)
-	safeAlbumArtist := sanitizeFilename(spotifyAlbumArtist)
-
-	filename := buildQobuzFilename(safeTitle, safeArtist, safeAlbum, safeAlbumArtist, spotifyReleaseDate, spotifyTrackNumber, spotifyDiscNumber, filenameFormat, includeTrackNumber, position, useAlbumTrackNumber)
-	filepath := filepath.Join(outputDir, filename)
+	filename := buildDownloadFilename(filenameFormat, TemplateData{
+		Title:       trackTitle,
+		Artist:      artists,
+		Album:       albumTitle,
+		AlbumArtist: spotifyAlbumArtist,
+		ReleaseDate: spotifyReleaseDate,
+		ISRC:        isrc,
+		Track:       spotifyTrackNumber,
+		Disc:        spotifyDiscNumber,
+		TotalTracks: spotifyTotalTracks,
+		TotalDiscs:  spotifyTotalDiscs,
+	}, includeTrackNumber, position, useAlbumTrackNumber)
+	if err := os.MkdirAll(filepath.Dir(FitPath(outputDir, filename)), 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+	filepath := FitPath(outputDir, filename)
 
 	if fileInfo, err := os.Stat(filepath); err == nil && fileInfo.Size() > 0 {
 		fmt.Printf("File already exists: %s (%.2f MB)\n", filepath, float64(fileInfo.Size())/(1024*1024))
@@ -532,6 +561,7 @@ func (q *QobuzDownloader) DownloadByISRC(deezerISRC, outputDir, quality, filenam
 		Publisher:   spotifyPublisher,
 		Description: "https://github.com/afkarxyz/SpotiFLAC",
 	}
+	QobuzTrackCredits(track).ApplyTo(&metadata)
 
 	if err := EmbedMetadata(filepath, metadata, coverPath); err != nil {
 		return "", fmt.Errorf("failed to embed metadata: %w", err)
@@ -0,0 +1,725 @@
+package backend
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	reorganizeJournalBucket = "ReorganizeJournal"
+	maxReorganizeJournal    = 50
+)
+
+const (
+	ReorganizeCollisionSkip   = "skip"
+	ReorganizeCollisionRename = "rename"
+)
+
+var trackSidecarSuffixes = []string{".lrc", ".ttml", ".cover.jpg", ".cover.png"}
+
+type ReorganizeRequest struct {
+	SourceDir       string   `json:"source_dir"`
+	TargetDir       string   `json:"target_dir"`
+	Template        string   `json:"template"`
+	Collision       string   `json:"collision"`
+	IncludeSidecars bool     `json:"include_sidecars"`
+	IncludeCovers   bool     `json:"include_covers"`
+	RemoveEmptyDirs bool     `json:"remove_empty_dirs"`
+	PlaylistDirs    []string `json:"playlist_dirs,omitempty"`
+	Description     string   `json:"description,omitempty"`
+}
+
+type ReorganizeMove struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Kind        string `json:"kind"`
+	Copy        bool   `json:"copy,omitempty"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+type ReorganizeResult struct {
+	JournalID        string           `json:"journal_id,omitempty"`
+	Moves            []ReorganizeMove `json:"moves"`
+	Moved            int              `json:"moved"`
+	Skipped          int              `json:"skipped"`
+	Failed           int              `json:"failed"`
+	HistoryUpdated   int              `json:"history_updated"`
+	PlaylistsUpdated []string         `json:"playlists_updated,omitempty"`
+	LibraryRemoved   []string         `json:"library_removed,omitempty"`
+}
+
+type ReorganizeJournalEntry struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+	Copy bool   `json:"copy,omitempty"`
+}
+
+type ReorganizeJournal struct {
+	ID           string                   `json:"id"`
+	Description  string                   `json:"description"`
+	Timestamp    int64                    `json:"timestamp"`
+	TargetDir    string                   `json:"target_dir"`
+	PlaylistDirs []string                 `json:"playlist_dirs,omitempty"`
+	Entries      []ReorganizeJournalEntry `json:"entries"`
+}
+
+func PreviewReorganize(req ReorganizeRequest) (*ReorganizeResult, error) {
+	moves, err := planReorganize(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReorganizeResult{Moves: moves}
+	for _, move := range moves {
+		switch move.Status {
+		case "pending":
+			result.Moved++
+		case "skipped", "unchanged":
+			result.Skipped++
+		case "failed":
+			result.Failed++
+		}
+	}
+	return result, nil
+}
+
+func planReorganize(req ReorganizeRequest) ([]ReorganizeMove, error) {
+	if req.SourceDir == "" {
+		return nil, fmt.Errorf("source directory is required")
+	}
+	if strings.TrimSpace(req.Template) == "" {
+		return nil, fmt.Errorf("folder template is required")
+	}
+	if req.TargetDir == "" {
+		req.TargetDir = req.SourceDir
+	}
+	if req.Collision == "" {
+		req.Collision = ReorganizeCollisionSkip
+	}
+
+	files, err := ListAudioFiles(req.SourceDir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+
+	var moves []ReorganizeMove
+	claimed := make(map[string]bool)
+
+	dirTargets := make(map[string]map[string]bool)
+	settle := func(srcDir, finalDir string) {
+		if dirTargets[srcDir] == nil {
+			dirTargets[srcDir] = make(map[string]bool)
+		}
+		dirTargets[srcDir][finalDir] = true
+	}
+
+	for _, file := range files {
+		move := ReorganizeMove{Source: file.Path, Kind: "audio", Status: "pending"}
+		srcDir := filepath.Dir(file.Path)
+
+		metadata, err := ReadAudioMetadata(file.Path)
+		if err != nil {
+			move.Status = "failed"
+			move.Error = err.Error()
+			moves = append(moves, move)
+			settle(srcDir, srcDir)
+			continue
+		}
+
+		ext := filepath.Ext(file.Path)
+		name := GenerateFilename(metadata, req.Template, ext)
+		if name == "" {
+			move.Status = "failed"
+			move.Error = "could not generate path (missing metadata)"
+			moves = append(moves, move)
+			settle(srcDir, srcDir)
+			continue
+		}
+
+		dest := FitPath(req.TargetDir, name)
+		if reorganizeKey(dest) == reorganizeKey(file.Path) {
+			move.Destination = dest
+			move.Status = "unchanged"
+			claimed[reorganizeKey(dest)] = true
+			moves = append(moves, move)
+			settle(srcDir, srcDir)
+			continue
+		}
+
+		dest, ok := resolveReorganizeCollision(dest, req.Collision, claimed)
+		move.Destination = dest
+		if !ok {
+			move.Status = "skipped"
+			move.Error = "destination already exists"
+			moves = append(moves, move)
+			settle(srcDir, srcDir)
+			continue
+		}
+		claimed[reorganizeKey(dest)] = true
+		moves = append(moves, move)
+
+		settle(srcDir, filepath.Dir(dest))
+
+		if !req.IncludeSidecars {
+			continue
+		}
+		srcStem := strings.TrimSuffix(file.Path, ext)
+		destStem := strings.TrimSuffix(dest, ext)
+		for _, suffix := range trackSidecarSuffixes {
+			sidecar := srcStem + suffix
+			if !fileExists(sidecar) {
+				continue
+			}
+			sidecarMove := ReorganizeMove{Source: sidecar, Destination: destStem + suffix, Kind: "sidecar", Status: "pending"}
+			if fileExists(sidecarMove.Destination) || claimed[reorganizeKey(sidecarMove.Destination)] {
+				sidecarMove.Status = "skipped"
+				sidecarMove.Error = "destination already exists"
+			} else {
+				claimed[reorganizeKey(sidecarMove.Destination)] = true
+			}
+			moves = append(moves, sidecarMove)
+		}
+	}
+
+	if req.IncludeCovers {
+		dirs := make([]string, 0, len(dirTargets))
+		for dir := range dirTargets {
+			dirs = append(dirs, dir)
+		}
+		sort.Strings(dirs)
+
+		for _, dir := range dirs {
+			keepOriginal := false
+			targets := make([]string, 0, len(dirTargets[dir]))
+			for target := range dirTargets[dir] {
+				if reorganizeKey(target) == reorganizeKey(dir) {
+					keepOriginal = true
+				} else {
+					targets = append(targets, target)
+				}
+			}
+			sort.Strings(targets)
+
+			for _, coverName := range findFolderCovers(dir) {
+				cover := filepath.Join(dir, coverName)
+				for i, target := range targets {
+					copyOnly := keepOriginal || i < len(targets)-1
+					coverMove := ReorganizeMove{Source: cover, Destination: filepath.Join(target, coverName), Kind: "cover", Copy: copyOnly, Status: "pending"}
+					if fileExists(coverMove.Destination) || claimed[reorganizeKey(coverMove.Destination)] {
+						coverMove.Status = "skipped"
+						coverMove.Error = "destination already exists"
+					} else {
+						claimed[reorganizeKey(coverMove.Destination)] = true
+					}
+					moves = append(moves, coverMove)
+				}
+			}
+		}
+	}
+
+	return moves, nil
+}
+
+func reorganizeKey(path string) string {
+	path = filepath.Clean(path)
+	switch GetSanitizeOptions().Profile {
+	case SanitizeProfileWindows, SanitizeProfileFAT:
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+func resolveReorganizeCollision(dest, policy string, claimed map[string]bool) (string, bool) {
+	taken := func(path string) bool {
+		key := reorganizeKey(path)
+		if claimed[key] {
+			return true
+		}
+		return fileExists(path)
+	}
+
+	if !taken(dest) {
+		return dest, true
+	}
+	if policy != ReorganizeCollisionRename {
+		return dest, false
+	}
+
+	dir := filepath.Dir(dest)
+	stem, ext := splitNameExtension(filepath.Base(dest))
+	for n := 2; n < 1000; n++ {
+		candidate := FitPath(dir, fmt.Sprintf("%s (%d)%s", stem, n, ext))
+		if !taken(candidate) {
+			return candidate, true
+		}
+	}
+	return dest, false
+}
+
+func ApplyReorganize(req ReorganizeRequest, appName string) (*ReorganizeResult, error) {
+	if req.TargetDir == "" {
+		req.TargetDir = req.SourceDir
+	}
+
+	moves, err := planReorganize(req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReorganizeResult{}
+	journal := ReorganizeJournal{
+		Description:  req.Description,
+		Timestamp:    time.Now().Unix(),
+		TargetDir:    req.TargetDir,
+		PlaylistDirs: reorganizePlaylistDirs(req),
+	}
+
+	moved := make(map[string]string)
+	sourceDirs := make(map[string]bool)
+
+	audioFailed := false
+	for _, move := range moves {
+		if move.Kind == "audio" {
+			audioFailed = move.Status != "pending"
+		} else if move.Kind == "sidecar" && audioFailed && move.Status == "pending" {
+			move.Status = "skipped"
+			move.Error = "audio file was not moved"
+		}
+
+		if move.Status != "pending" {
+			if move.Status == "failed" {
+				result.Failed++
+			} else {
+				result.Skipped++
+			}
+			result.Moves = append(result.Moves, move)
+			continue
+		}
+
+		err := os.MkdirAll(filepath.Dir(move.Destination), 0755)
+		if err == nil {
+			if move.Copy {
+				err = copyFile(move.Source, move.Destination)
+			} else {
+				err = moveFile(move.Source, move.Destination)
+			}
+		}
+
+		if err != nil {
+			move.Status = "failed"
+			move.Error = err.Error()
+			result.Failed++
+			if move.Kind == "audio" {
+				audioFailed = true
+			}
+			fmt.Printf("[Reorganize] Failed to move %s: %v\n", move.Source, err)
+		} else {
+			move.Status = "moved"
+			result.Moved++
+			journal.Entries = append(journal.Entries, ReorganizeJournalEntry{From: move.Source, To: move.Destination, Kind: move.Kind, Copy: move.Copy})
+			if !move.Copy {
+				moved[filepath.Clean(move.Source)] = move.Destination
+				sourceDirs[filepath.Dir(move.Source)] = true
+			}
+		}
+		result.Moves = append(result.Moves, move)
+	}
+
+	if len(journal.Entries) == 0 {
+		return result, nil
+	}
+
+	relinkMovedFiles(result, moved, journal.PlaylistDirs, appName)
+
+	if req.RemoveEmptyDirs {
+		removeEmptyDirs(sourceDirs, req.SourceDir)
+	}
+
+	if journal.Description == "" {
+		journal.Description = fmt.Sprintf("Reorganized %d file(s)", result.Moved)
+	}
+
+	id, err := saveReorganizeJournal(journal, appName)
+	if err != nil {
+		return result, fmt.Errorf("files moved but failed to save undo log: %w", err)
+	}
+	result.JournalID = id
+
+	return result, nil
+}
+
+func reorganizePlaylistDirs(req ReorganizeRequest) []string {
+	dirs := req.PlaylistDirs
+	if len(dirs) == 0 {
+		dirs = []string{req.SourceDir}
+		if req.TargetDir != "" && reorganizeKey(req.TargetDir) != reorganizeKey(req.SourceDir) {
+			dirs = append(dirs, req.TargetDir)
+		}
+	}
+	return dirs
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+func relinkMovedFiles(result *ReorganizeResult, moved map[string]string, playlistDirs []string, appName string) {
+	if len(moved) == 0 {
+		return
+	}
+
+	historyUpdated, err := UpdateHistoryItems(func(item *HistoryItem) bool {
+		if item.Path == "" {
+			return false
+		}
+		if dest, ok := moved[filepath.Clean(item.Path)]; ok {
+			item.Path = dest
+			return true
+		}
+		return false
+	}, appName)
+	if err != nil {
+		fmt.Printf("[Reorganize] Failed to update history paths: %v\n", err)
+	}
+
+	removed, err := relinkLibraryTracks(moved)
+	if err != nil {
+		fmt.Printf("[Reorganize] Failed to update library index: %v\n", err)
+	}
+	if len(removed) > 0 {
+		fmt.Printf("[Reorganize] %d file(s) moved outside every library root and were removed from the index\n", len(removed))
+	}
+	result.LibraryRemoved = removed
+
+	var playlists []string
+	seen := make(map[string]bool)
+	for _, dir := range playlistDirs {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return nil
+			}
+			ext := strings.ToLower(filepath.Ext(path))
+			if (ext != ".m3u8" && ext != ".m3u") || seen[path] {
+				return nil
+			}
+			seen[path] = true
+
+			changed, err := relinkPlaylistFile(path, moved)
+			if err != nil {
+				fmt.Printf("[Reorganize] Failed to update playlist %s: %v\n", path, err)
+			} else if changed {
+				playlists = append(playlists, path)
+			}
+			return nil
+		})
+	}
+
+	result.HistoryUpdated = historyUpdated
+	result.PlaylistsUpdated = playlists
+}
+
+func relinkLibraryTracks(moved map[string]string) ([]string, error) {
+	if historyDB == nil {
+		return nil, nil
+	}
+
+	var removed []string
+	err := historyDB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(libraryTracksBucket))
+		if b == nil {
+			return nil
+		}
+
+		var roots []string
+		if rootsBucket := tx.Bucket([]byte(libraryRootsBucket)); rootsBucket != nil {
+			rootsBucket.ForEach(func(k, v []byte) error {
+				roots = append(roots, string(k))
+				return nil
+			})
+		}
+		withinRoot := func(root, path string) bool {
+			rel, err := filepath.Rel(root, path)
+			return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+		}
+
+		for src, dest := range moved {
+			v := b.Get([]byte(src))
+			if v == nil {
+				continue
+			}
+			var track LibraryTrack
+			if err := json.Unmarshal(v, &track); err != nil {
+				continue
+			}
+			if err := b.Delete([]byte(src)); err != nil {
+				return err
+			}
+			if !withinRoot(track.Root, dest) {
+				newRoot := ""
+				for _, root := range roots {
+					if withinRoot(root, dest) && len(root) > len(newRoot) {
+						newRoot = root
+					}
+				}
+				if newRoot == "" {
+					removed = append(removed, dest)
+					continue
+				}
+				track.Root = newRoot
+			}
+			track.Path = dest
+			buf, err := json.Marshal(track)
+			if err != nil {
+				return err
+			}
+			if err := b.Put([]byte(dest), buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	sort.Strings(removed)
+	return removed, err
+}
+
+func relinkPlaylistFile(playlistPath string, moved map[string]string) (bool, error) {
+	f, err := os.Open(playlistPath)
+	if err != nil {
+		return false, err
+	}
+
+	var lines []string
+	changed := false
+	playlistDir := filepath.Dir(playlistPath)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		entry := strings.TrimSpace(line)
+		if entry == "" || strings.HasPrefix(entry, "#") || strings.Contains(entry, "://") {
+			lines = append(lines, line)
+			continue
+		}
+
+		absolute := filepath.IsAbs(filepath.FromSlash(entry))
+		resolved := filepath.FromSlash(entry)
+		if !absolute {
+			resolved = filepath.Join(playlistDir, resolved)
+		}
+
+		dest, ok := moved[filepath.Clean(resolved)]
+		if !ok {
+			lines = append(lines, line)
+			continue
+		}
+
+		newEntry := dest
+		if !absolute {
+			if rel, err := filepath.Rel(playlistDir, dest); err == nil {
+				newEntry = rel
+			}
+		}
+		if strings.Contains(entry, "/") || !strings.Contains(entry, "\\") {
+			newEntry = filepath.ToSlash(newEntry)
+		}
+
+		lines = append(lines, newEntry)
+		changed = true
+	}
+	f.Close()
+
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	if !changed {
+		return false, nil
+	}
+
+	return true, os.WriteFile(playlistPath, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+func removeEmptyDirs(dirs map[string]bool, stopAt string) {
+	stopAt = filepath.Clean(stopAt)
+	for dir := range dirs {
+		for current := filepath.Clean(dir); strings.HasPrefix(current, stopAt+string(filepath.Separator)); current = filepath.Dir(current) {
+			entries, err := os.ReadDir(current)
+			if err != nil || len(entries) > 0 {
+				break
+			}
+			if err := os.Remove(current); err != nil {
+				break
+			}
+		}
+	}
+}
+
+func saveReorganizeJournal(journal ReorganizeJournal, appName string) (string, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return "", err
+		}
+	}
+
+	err := historyDB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(reorganizeJournalBucket))
+		if err != nil {
+			return err
+		}
+		seq, _ := b.NextSequence()
+		journal.ID = fmt.Sprintf("%d-%d", time.Now().UnixNano(), seq)
+
+		buf, err := json.Marshal(journal)
+		if err != nil {
+			return err
+		}
+
+		if b.Stats().KeyN >= maxReorganizeJournal {
+			c := b.Cursor()
+			if k, _ := c.First(); k != nil {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+
+		return b.Put([]byte(journal.ID), buf)
+	})
+
+	return journal.ID, err
+}
+
+func GetReorganizeJournal(appName string) ([]ReorganizeJournal, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	var journals []ReorganizeJournal
+	err := historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(reorganizeJournalBucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var journal ReorganizeJournal
+			if err := json.Unmarshal(v, &journal); err == nil {
+				journals = append(journals, journal)
+			}
+			return nil
+		})
+	})
+
+	sort.Slice(journals, func(i, j int) bool {
+		return journals[i].Timestamp > journals[j].Timestamp
+	})
+
+	return journals, err
+}
+
+func UndoReorganize(journalID string, appName string) (*ReorganizeResult, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	var journal ReorganizeJournal
+	found := false
+	err := historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(reorganizeJournalBucket))
+		if b == nil {
+			return nil
+		}
+		v := b.Get([]byte(journalID))
+		if v == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(v, &journal)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("reorganize journal entry not found: %s", journalID)
+	}
+
+	result := &ReorganizeResult{}
+	restored := make(map[string]string)
+	emptied := make(map[string]bool)
+
+	for i := len(journal.Entries) - 1; i >= 0; i-- {
+		entry := journal.Entries[i]
+		move := ReorganizeMove{Source: entry.To, Destination: entry.From, Kind: entry.Kind}
+
+		var err error
+		if entry.Copy {
+			err = os.Remove(entry.To)
+		} else if fileExists(entry.From) {
+			err = fmt.Errorf("original location is occupied")
+		} else if err = os.MkdirAll(filepath.Dir(entry.From), 0755); err == nil {
+			err = moveFile(entry.To, entry.From)
+		}
+
+		if err != nil && !(entry.Copy && os.IsNotExist(err)) {
+			move.Status = "failed"
+			move.Error = err.Error()
+			result.Failed++
+		} else {
+			move.Status = "moved"
+			result.Moved++
+			emptied[filepath.Dir(entry.To)] = true
+			if !entry.Copy {
+				restored[filepath.Clean(entry.To)] = entry.From
+			}
+		}
+		result.Moves = append(result.Moves, move)
+	}
+
+	relinkMovedFiles(result, restored, journal.PlaylistDirs, appName)
+
+	if journal.TargetDir != "" {
+		removeEmptyDirs(emptied, journal.TargetDir)
+	}
+
+	if result.Failed == 0 {
+		err = historyDB.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(reorganizeJournalBucket))
+			if b == nil {
+				return nil
+			}
+			return b.Delete([]byte(journalID))
+		})
+	}
+
+	return result, err
+}
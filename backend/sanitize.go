@@ -0,0 +1,361 @@
+package backend
+
+import (
+	"fmt"
+	"hash/crc32"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	SanitizeProfileDefault = "default"
+	SanitizeProfileWindows = "windows"
+	SanitizeProfileFAT     = "fat32"
+	SanitizeProfilePOSIX   = "posix"
+	SanitizeProfileASCII   = "ascii"
+)
+
+type SanitizeOptions struct {
+	Profile            string `json:"profile"`
+	MaxComponentLength int    `json:"max_component_length,omitempty"`
+	MaxPathLength      int    `json:"max_path_length,omitempty"`
+}
+
+var (
+	sanitizeOptions     = SanitizeOptions{Profile: SanitizeProfileDefault}
+	sanitizeOptionsLock sync.RWMutex
+)
+
+var windowsInvalidChars = regexp.MustCompile(`[<>:"/\\|?*]`)
+
+var repeatedUnderscores = regexp.MustCompile(`_+`)
+
+var windowsReservedNames = regexp.MustCompile(`(?i)^(con|prn|aux|nul|com[0-9¹²³]|lpt[0-9¹²³])$`)
+
+var asciiTransliterations = map[rune]string{
+	'ß': "ss", 'Æ': "AE", 'æ': "ae", 'Œ': "OE", 'œ': "oe", 'Ø': "O", 'ø': "o",
+	'Ł': "L", 'ł': "l", 'Đ': "D", 'đ': "d", 'Ð': "D", 'ð': "d", 'Þ': "Th",
+	'þ': "th", 'ı': "i", 'Ħ': "H", 'ħ': "h", 'ŀ': "l", 'Ŀ': "L",
+	'‘': "'", '’': "'", '‚': "'", '“': "'", '”': "'", '„': "'", '«': "'", '»': "'",
+	'–': "-", '—': "-", '‐': "-", '−': "-", '…': "...", '×': "x", '÷': "-",
+	'¡': "!", '¿': "", '€': "EUR", '£': "GBP", '¥': "JPY", '©': "(c)", '®': "(R)",
+	'™': "TM", '°': "", '·': "-", '•': "-", '№': "No",
+}
+
+var scriptTransliterations = func() map[rune]string {
+	table := make(map[rune]string)
+	groups := "а:a б:b в:v г:g д:d е:e ё:yo ж:zh з:z и:i й:y к:k л:l м:m н:n о:o п:p р:r с:s т:t у:u ф:f " +
+		"х:kh ц:ts ч:ch ш:sh щ:shch ъ: ы:y ь: э:e ю:yu я:ya і:i ї:yi є:ye ґ:g ў:u " +
+		"α:a β:v γ:g δ:d ε:e ζ:z η:i θ:th ι:i κ:k λ:l μ:m ν:n ξ:x ο:o π:p ρ:r σ:s ς:s τ:t υ:y φ:f χ:ch ψ:ps ω:o"
+	for _, group := range strings.Fields(groups) {
+		letter, latin, _ := strings.Cut(group, ":")
+		r := []rune(letter)[0]
+		table[r] = latin
+		if upper := unicode.ToUpper(r); upper != r {
+			if latin != "" {
+				table[upper] = strings.ToUpper(latin[:1]) + latin[1:]
+			} else {
+				table[upper] = ""
+			}
+		}
+	}
+	return table
+}()
+
+func GetSanitizeOptions() SanitizeOptions {
+	sanitizeOptionsLock.RLock()
+	defer sanitizeOptionsLock.RUnlock()
+	return sanitizeOptions
+}
+
+func SetSanitizeOptions(options SanitizeOptions) error {
+	switch options.Profile {
+	case "":
+		options.Profile = SanitizeProfileDefault
+	case SanitizeProfileDefault, SanitizeProfileWindows, SanitizeProfileFAT, SanitizeProfilePOSIX, SanitizeProfileASCII:
+	default:
+		return fmt.Errorf("unknown sanitization profile: %s", options.Profile)
+	}
+	if options.MaxComponentLength < 0 || options.MaxPathLength < 0 {
+		return fmt.Errorf("length limits must not be negative")
+	}
+
+	sanitizeOptionsLock.Lock()
+	sanitizeOptions = options
+	sanitizeOptionsLock.Unlock()
+	return nil
+}
+
+func SanitizeFilename(name string) string {
+	return SanitizeFilenameWithProfile(name, GetSanitizeOptions().Profile)
+}
+
+func SanitizeFilenameWithProfile(name, profile string) string {
+	var sanitized string
+	switch profile {
+	case SanitizeProfileWindows:
+		sanitized = sanitizeWindowsName(name)
+	case SanitizeProfileFAT:
+		sanitized = sanitizeFATName(name)
+	case SanitizeProfilePOSIX:
+		sanitized = sanitizePOSIXName(name)
+	case SanitizeProfileASCII:
+		sanitized = sanitizeWindowsName(transliterateASCII(name))
+	default:
+		return sanitizeDefaultName(name)
+	}
+
+	if sanitized == "" {
+		return "Unknown"
+	}
+	return sanitized
+}
+
+func stripControlChars(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func sanitizeWindowsName(name string) string {
+	sanitized := windowsInvalidChars.ReplaceAllString(stripControlChars(name), " ")
+	sanitized = strings.Join(strings.Fields(sanitized), " ")
+	sanitized = strings.TrimRight(strings.TrimSpace(sanitized), ". ")
+
+	base := sanitized
+	if idx := strings.IndexByte(base, '.'); idx >= 0 {
+		base = base[:idx]
+	}
+	if windowsReservedNames.MatchString(strings.TrimSpace(base)) {
+		sanitized = "_" + sanitized
+	}
+
+	return sanitized
+}
+
+func sanitizeFATName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if r > 0xFFFF {
+			b.WriteByte('_')
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	sanitized := repeatedUnderscores.ReplaceAllString(b.String(), "_")
+	return sanitizeWindowsName(sanitized)
+}
+
+func sanitizePOSIXName(name string) string {
+	sanitized := strings.ReplaceAll(stripControlChars(name), "/", " ")
+	sanitized = strings.Join(strings.Fields(sanitized), " ")
+	if sanitized == "." || sanitized == ".." {
+		return ""
+	}
+	return sanitized
+}
+
+func romanizeScripts(name string) string {
+	hasKana, hasHangul, hasHan := false, false, false
+	for _, r := range name {
+		switch {
+		case isKana(r):
+			hasKana = true
+		case isHangulSyllable(r):
+			hasHangul = true
+		case unicode.Is(unicode.Han, r):
+			hasHan = true
+		}
+	}
+	if hasHangul {
+		name = RomanizeHangul(name)
+	}
+	if hasKana {
+		name = RomanizeKana(name)
+	}
+	if hasHan {
+		name = RomanizePinyin(name)
+	}
+	return name
+}
+
+func transliterateASCII(name string) string {
+	var b strings.Builder
+	lossy := false
+	for _, r := range norm.NFKD.String(romanizeScripts(name)) {
+		latin, mapped := scriptTransliterations[r]
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case unicode.Is(unicode.Mn, r):
+		case mapped:
+			b.WriteString(latin)
+		case asciiTransliterations[r] != "":
+			b.WriteString(asciiTransliterations[r])
+		case unicode.IsSpace(r):
+			b.WriteByte(' ')
+		default:
+			b.WriteByte('_')
+			lossy = true
+		}
+	}
+
+	result := repeatedUnderscores.ReplaceAllString(b.String(), "_")
+	result = strings.Trim(result, "_ ")
+	if lossy || (result == "" && strings.TrimSpace(name) != "") {
+		suffix := fmt.Sprintf("~%08x", crc32.ChecksumIEEE([]byte(name)))
+		if result == "" {
+			return suffix[1:]
+		}
+		return result + " " + suffix
+	}
+	return result
+}
+
+func profileLimits(options SanitizeOptions) (int, int) {
+	component, path := 255, 0
+	switch options.Profile {
+	case SanitizeProfileWindows, SanitizeProfileFAT, SanitizeProfileASCII:
+		path = 260
+	case SanitizeProfilePOSIX:
+		path = 4096
+	}
+	if options.MaxComponentLength > 0 {
+		component = options.MaxComponentLength
+	}
+	if options.MaxPathLength > 0 {
+		path = options.MaxPathLength
+	}
+	if options.Profile == SanitizeProfileFAT {
+		if component > 255 {
+			component = 255
+		}
+		if path > 260 {
+			path = 260
+		}
+	}
+	return component, path
+}
+
+func pathLength(profile, value string) int {
+	switch profile {
+	case SanitizeProfileWindows, SanitizeProfileFAT, SanitizeProfileASCII:
+		return len(utf16.Encode([]rune(value)))
+	default:
+		return len(value)
+	}
+}
+
+func splitNameExtension(name string) (string, string) {
+	stem, ext := name, ""
+	for i := 0; i < 2; i++ {
+		e := filepath.Ext(stem)
+		if e == "" || len(e) > 6 || e == stem || strings.ContainsAny(e, " -_") {
+			break
+		}
+		stem = strings.TrimSuffix(stem, e)
+		ext = e + ext
+	}
+	return stem, ext
+}
+
+func shortenComponent(name string, limit int, profile string, keepExtension bool) string {
+	if limit <= 0 || pathLength(profile, name) <= limit {
+		return name
+	}
+
+	stem, ext := name, ""
+	if keepExtension {
+		stem, ext = splitNameExtension(name)
+	}
+
+	suffix := fmt.Sprintf("~%04x", crc32.ChecksumIEEE([]byte(name))&0xffff)
+	budget := limit - pathLength(profile, ext) - pathLength(profile, suffix)
+	if budget < 1 {
+		budget = 1
+	}
+
+	runes := []rune(stem)
+	for len(runes) > 1 && pathLength(profile, string(runes)) > budget {
+		runes = runes[:len(runes)-1]
+	}
+	shortened := string(runes)
+
+	if idx := strings.LastIndexByte(shortened, ' '); idx > len(shortened)*2/3 {
+		shortened = shortened[:idx]
+	}
+	shortened = strings.TrimRight(shortened, " -_.,(")
+
+	return shortened + suffix + ext
+}
+
+func FitPath(baseDir, relPath string) string {
+	options := GetSanitizeOptions()
+	componentLimit, pathLimit := profileLimits(options)
+
+	segments := strings.FieldsFunc(relPath, func(r rune) bool { return r == '/' || r == '\\' })
+	for i, segment := range segments {
+		segments[i] = shortenComponent(segment, componentLimit, options.Profile, i == len(segments)-1)
+	}
+
+	full := filepath.Join(append([]string{baseDir}, segments...)...)
+	if pathLimit <= 0 || len(segments) == 0 {
+		return full
+	}
+
+	const minStem = 16
+	floor := func(i int) int {
+		if i == len(segments)-1 {
+			_, ext := splitNameExtension(segments[i])
+			return minStem + pathLength(options.Profile, ext)
+		}
+		return minStem
+	}
+
+	for pathLength(options.Profile, full) > pathLimit {
+		longest, longestLen, secondLen := -1, 0, 0
+		for i, segment := range segments {
+			l := pathLength(options.Profile, segment)
+			if l <= floor(i) {
+				continue
+			}
+			if l > longestLen {
+				longest, longestLen, secondLen = i, l, longestLen
+			} else if l > secondLen {
+				secondLen = l
+			}
+		}
+		if longest < 0 {
+			break
+		}
+
+		target := longestLen - (pathLength(options.Profile, full) - pathLimit)
+		if target < secondLen {
+			target = secondLen
+		}
+		if target >= longestLen {
+			target = longestLen - 1
+		}
+		if f := floor(longest); target < f {
+			target = f
+		}
+
+		segments[longest] = shortenComponent(segments[longest], target, options.Profile, longest == len(segments)-1)
+		full = filepath.Join(append([]string{baseDir}, segments...)...)
+	}
+
+	return full
+}
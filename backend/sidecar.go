@@ -0,0 +1,254 @@
+package backend
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type SidecarTrack struct {
+	SpotifyID   string        `json:"spotify_id"`
+	Name        string        `json:"name"`
+	Artists     string        `json:"artists"`
+	TrackNumber int           `json:"track_number"`
+	DiscNumber  int           `json:"disc_number"`
+	DurationMS  int           `json:"duration_ms"`
+	File        string        `json:"file,omitempty"`
+	Provider    string        `json:"provider,omitempty"`
+	Quality     *AudioQuality `json:"quality,omitempty"`
+}
+
+type SidecarDownload struct {
+	SpotifyID string `json:"spotify_id"`
+	File      string `json:"file"`
+	Provider  string `json:"provider,omitempty"`
+}
+
+type AlbumSidecar struct {
+	GeneratedAt string                `json:"generated_at"`
+	Service     string                `json:"service"`
+	Quality     string                `json:"quality"`
+	Album       *AlbumResponsePayload `json:"album"`
+	Tracks      []SidecarTrack        `json:"tracks"`
+}
+
+type SidecarOptions struct {
+	OutputDir string `json:"output_dir"`
+	WriteJSON bool   `json:"write_json"`
+	WriteNFO  bool   `json:"write_nfo"`
+	WriteCUE  bool   `json:"write_cue"`
+}
+
+type nfoAlbum struct {
+	XMLName     xml.Name    `xml:"album"`
+	Title       string      `xml:"title"`
+	ArtistDesc  string      `xml:"artistdesc,omitempty"`
+	Artists     []nfoCredit `xml:"albumArtistCredits"`
+	Type        string      `xml:"type,omitempty"`
+	ReleaseDate string      `xml:"releasedate,omitempty"`
+	Year        string      `xml:"year,omitempty"`
+	Thumb       string      `xml:"thumb,omitempty"`
+	Tracks      []nfoTrack  `xml:"track"`
+}
+
+type nfoCredit struct {
+	Artist string `xml:"artist"`
+}
+
+type nfoTrack struct {
+	Disc     int    `xml:"disc,omitempty"`
+	Position int    `xml:"position"`
+	Title    string `xml:"title"`
+	Duration string `xml:"duration,omitempty"`
+}
+
+func BuildAlbumSidecar(album *AlbumResponsePayload, service, quality string, downloads []SidecarDownload) *AlbumSidecar {
+	sidecar := &AlbumSidecar{
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		Service:     service,
+		Quality:     quality,
+		Album:       album,
+	}
+
+	byID := make(map[string]SidecarDownload)
+	for _, download := range downloads {
+		if download.SpotifyID != "" && download.File != "" {
+			byID[download.SpotifyID] = download
+		}
+	}
+
+	counters := make(map[int]int)
+	for _, track := range album.TrackList {
+		disc := track.DiscNumber
+		if disc == 0 {
+			disc = 1
+		}
+		counters[disc]++
+
+		entry := SidecarTrack{
+			SpotifyID:   track.SpotifyID,
+			Name:        track.Name,
+			Artists:     track.Artists,
+			TrackNumber: counters[disc],
+			DiscNumber:  disc,
+			DurationMS:  track.DurationMS,
+		}
+
+		if download, ok := byID[track.SpotifyID]; ok {
+			entry.File = download.File
+			entry.Provider = download.Provider
+			if entry.Provider == "" && service != "auto" && service != "mixed" {
+				entry.Provider = service
+			}
+			if q, err := ProbeAudioQuality(download.File); err == nil {
+				entry.Quality = q
+			} else {
+				fmt.Printf("[Sidecar] Failed to probe %s: %v\n", download.File, err)
+			}
+		}
+
+		sidecar.Tracks = append(sidecar.Tracks, entry)
+	}
+
+	return sidecar
+}
+
+func WriteAlbumSidecars(sidecar *AlbumSidecar, options SidecarOptions) ([]string, error) {
+	if sidecar == nil || sidecar.Album == nil {
+		return nil, fmt.Errorf("album data is required")
+	}
+	if options.OutputDir == "" {
+		return nil, fmt.Errorf("output directory is required")
+	}
+	if err := os.MkdirAll(options.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	var written []string
+
+	if options.WriteJSON {
+		data, err := json.MarshalIndent(sidecar, "", "  ")
+		if err != nil {
+			return written, fmt.Errorf("failed to encode album.json: %w", err)
+		}
+		path := filepath.Join(options.OutputDir, "album.json")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return written, fmt.Errorf("failed to write album.json: %w", err)
+		}
+		written = append(written, path)
+	}
+
+	if options.WriteNFO {
+		data, err := buildAlbumNFO(sidecar)
+		if err != nil {
+			return written, fmt.Errorf("failed to encode album.nfo: %w", err)
+		}
+		path := filepath.Join(options.OutputDir, "album.nfo")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return written, fmt.Errorf("failed to write album.nfo: %w", err)
+		}
+		written = append(written, path)
+	}
+
+	if options.WriteCUE {
+		name := SanitizeFilename(sidecar.Album.AlbumInfo.Name)
+		if name == "" {
+			name = "album"
+		}
+		path := filepath.Join(options.OutputDir, name+".cue")
+		if err := os.WriteFile(path, []byte(buildAlbumCue(sidecar, options.OutputDir)), 0644); err != nil {
+			return written, fmt.Errorf("failed to write cue sheet: %w", err)
+		}
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+func buildAlbumNFO(sidecar *AlbumSidecar) ([]byte, error) {
+	info := sidecar.Album.AlbumInfo
+	album := nfoAlbum{
+		Title:       info.Name,
+		ArtistDesc:  info.Artists,
+		ReleaseDate: info.ReleaseDate,
+		Year:        extractYear(info.ReleaseDate),
+		Thumb:       info.Images,
+	}
+
+	artists := info.ArtistsList
+	if len(artists) == 0 && info.Artists != "" {
+		artists = []string{info.Artists}
+	}
+	for _, artist := range artists {
+		album.Artists = append(album.Artists, nfoCredit{Artist: artist})
+	}
+
+	if len(sidecar.Album.TrackList) > 0 {
+		album.Type = sidecar.Album.TrackList[0].AlbumType
+	}
+
+	for _, track := range sidecar.Tracks {
+		entry := nfoTrack{
+			Disc:     track.DiscNumber,
+			Position: track.TrackNumber,
+			Title:    track.Name,
+		}
+		if track.DurationMS > 0 {
+			seconds := track.DurationMS / 1000
+			entry.Duration = fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+		}
+		album.Tracks = append(album.Tracks, entry)
+	}
+
+	data, err := xml.MarshalIndent(album, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func buildAlbumCue(sidecar *AlbumSidecar, outputDir string) string {
+	info := sidecar.Album.AlbumInfo
+
+	var b strings.Builder
+	if year := extractYear(info.ReleaseDate); year != "" {
+		fmt.Fprintf(&b, "REM DATE %s\n", year)
+	}
+	fmt.Fprintf(&b, "REM COMMENT \"SpotiFLAC\"\n")
+	fmt.Fprintf(&b, "PERFORMER \"%s\"\n", cueEscape(info.Artists))
+	fmt.Fprintf(&b, "TITLE \"%s\"\n", cueEscape(info.Name))
+
+	number := 0
+	for _, track := range sidecar.Tracks {
+		if track.File == "" {
+			continue
+		}
+		number++
+
+		file := track.File
+		if rel, err := filepath.Rel(outputDir, track.File); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+
+		fileType := "WAVE"
+		if strings.EqualFold(filepath.Ext(file), ".mp3") {
+			fileType = "MP3"
+		}
+
+		fmt.Fprintf(&b, "FILE \"%s\" %s\n", cueEscape(filepath.ToSlash(file)), fileType)
+		fmt.Fprintf(&b, "  TRACK %02d AUDIO\n", number)
+		fmt.Fprintf(&b, "    TITLE \"%s\"\n", cueEscape(track.Name))
+		fmt.Fprintf(&b, "    PERFORMER \"%s\"\n", cueEscape(track.Artists))
+		fmt.Fprintf(&b, "    INDEX 01 00:00:00\n")
+	}
+
+	return b.String()
+}
+
+func cueEscape(value string) string {
+	return strings.ReplaceAll(value, "\"", "'")
+}
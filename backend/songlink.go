@@ -1,6 +1,7 @@
 package backend
 
 import (
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -8,14 +9,13 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 )
 
 type SongLinkClient struct {
-	client           *http.Client
-	lastAPICallTime  time.Time
-	apiCallCount     int
-	apiCallResetTime time.Time
+	client *http.Client
+	ctx    context.Context
 }
 
 type SongLinkURLs struct {
@@ -33,80 +33,145 @@ type TrackAvailability struct {
 	QobuzURL  string `json:"qobuz_url,omitempty"`
 }
 
-func NewSongLinkClient() *SongLinkClient {
-	return &SongLinkClient{
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		apiCallResetTime: time.Now(),
+type songLinkResponse struct {
+	EntityUniqueID     string `json:"entityUniqueId"`
+	EntitiesByUniqueID map[string]struct {
+		ID         string `json:"id"`
+		Type       string `json:"type"`
+		Title      string `json:"title"`
+		ArtistName string `json:"artistName"`
+	} `json:"entitiesByUniqueId"`
+	LinksByPlatform map[string]struct {
+		URL            string `json:"url"`
+		EntityUniqueID string `json:"entityUniqueId"`
+	} `json:"linksByPlatform"`
+}
+
+func (r *songLinkResponse) links() map[string]string {
+	links := make(map[string]string)
+	for platform, entry := range r.LinksByPlatform {
+		if entry.URL != "" {
+			links[platform] = entry.URL
+		}
 	}
+	return links
 }
 
-func (s *SongLinkClient) GetAllURLsFromSpotify(spotifyTrackID string, region string) (*SongLinkURLs, error) {
+const (
+	songLinkCallsPerMinute = 9
+	songLinkMinGap         = 7 * time.Second
+	songLinkBackoff        = 15 * time.Second
+	songLinkMaxRetries     = 3
+)
 
-	now := time.Now()
-	if now.Sub(s.apiCallResetTime) >= time.Minute {
-		s.apiCallCount = 0
-		s.apiCallResetTime = now
-	}
+type songLinkLimiter struct {
+	mu      sync.Mutex
+	tokens  float64
+	updated time.Time
+	next    time.Time
+}
+
+var songLinkRateLimiter = &songLinkLimiter{tokens: songLinkCallsPerMinute}
 
-	if s.apiCallCount >= 9 {
-		waitTime := time.Minute - now.Sub(s.apiCallResetTime)
-		if waitTime > 0 {
-			fmt.Printf("Rate limit reached, waiting %v...\n", waitTime.Round(time.Second))
-			time.Sleep(waitTime)
-			s.apiCallCount = 0
-			s.apiCallResetTime = time.Now()
+func (l *songLinkLimiter) refill(now time.Time) {
+	if !l.updated.IsZero() {
+		l.tokens += now.Sub(l.updated).Minutes() * songLinkCallsPerMinute
+		if l.tokens > songLinkCallsPerMinute {
+			l.tokens = songLinkCallsPerMinute
 		}
 	}
+	l.updated = now
+}
+
+func (l *songLinkLimiter) Wait(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		l.refill(now)
 
-	if !s.lastAPICallTime.IsZero() {
-		timeSinceLastCall := now.Sub(s.lastAPICallTime)
-		minDelay := 7 * time.Second
-		if timeSinceLastCall < minDelay {
-			waitTime := minDelay - timeSinceLastCall
-			fmt.Printf("Rate limiting: waiting %v...\n", waitTime.Round(time.Second))
-			time.Sleep(waitTime)
+		var wait time.Duration
+		if l.tokens < 1 {
+			wait = time.Duration((1 - l.tokens) / songLinkCallsPerMinute * float64(time.Minute))
+		}
+		if gap := l.next.Sub(now); gap > wait {
+			wait = gap
+		}
+		if wait <= 0 {
+			l.tokens--
+			l.next = now.Add(songLinkMinGap)
+			l.mu.Unlock()
+			return nil
+		}
+		l.mu.Unlock()
+
+		fmt.Printf("Rate limiting: waiting %v...\n", wait.Round(time.Second))
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
 		}
 	}
+}
 
-	spotifyBase, _ := base64.StdEncoding.DecodeString("aHR0cHM6Ly9vcGVuLnNwb3RpZnkuY29tL3RyYWNrLw==")
-	spotifyURL := fmt.Sprintf("%s%s", string(spotifyBase), spotifyTrackID)
+func (l *songLinkLimiter) Backoff(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = 0
+	l.updated = time.Now()
+	if until := l.updated.Add(d); until.After(l.next) {
+		l.next = until
+	}
+}
 
-	apiBase, _ := base64.StdEncoding.DecodeString("aHR0cHM6Ly9hcGkuc29uZy5saW5rL3YxLWFscGhhLjEvbGlua3M/dXJsPQ==")
-	apiURL := fmt.Sprintf("%s%s", string(apiBase), url.QueryEscape(spotifyURL))
+func NewSongLinkClient() *SongLinkClient {
+	return &SongLinkClient{
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		ctx: context.Background(),
+	}
+}
 
-	if region != "" {
-		apiURL += fmt.Sprintf("&userCountry=%s", region)
+func (s *SongLinkClient) WithContext(ctx context.Context) *SongLinkClient {
+	if ctx != nil {
+		s.ctx = ctx
 	}
+	return s
+}
 
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+func (s *SongLinkClient) fetch(link, region string) (*songLinkResponse, error) {
+	apiBase, _ := base64.StdEncoding.DecodeString("aHR0cHM6Ly9hcGkuc29uZy5saW5rL3YxLWFscGhhLjEvbGlua3M/dXJsPQ==")
+	apiURL := fmt.Sprintf("%s%s", string(apiBase), url.QueryEscape(link))
+	if region != "" {
+		apiURL += fmt.Sprintf("&userCountry=%s", region)
 	}
 
-	fmt.Println("Getting streaming URLs from song.link...")
+	var body []byte
+	for i := 0; i < songLinkMaxRetries; i++ {
+		if err := songLinkRateLimiter.Wait(s.ctx); err != nil {
+			return nil, err
+		}
 
-	maxRetries := 3
-	var resp *http.Response
-	for i := 0; i < maxRetries; i++ {
-		resp, err = s.client.Do(req)
+		req, err := http.NewRequestWithContext(s.ctx, "GET", apiURL, nil)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get URLs: %w", err)
+			return nil, fmt.Errorf("failed to create request: %w", err)
 		}
 
-		s.lastAPICallTime = time.Now()
-		s.apiCallCount++
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
 
 		if resp.StatusCode == 429 {
 			resp.Body.Close()
-			if i < maxRetries-1 {
-				waitTime := 15 * time.Second
-				fmt.Printf("Rate limited by API, waiting %v before retry...\n", waitTime)
-				time.Sleep(waitTime)
+			songLinkRateLimiter.Backoff(songLinkBackoff)
+			if i < songLinkMaxRetries-1 {
+				fmt.Printf("Rate limited by API, waiting %v before retry...\n", songLinkBackoff)
 				continue
 			}
-			return nil, fmt.Errorf("API rate limit exceeded after %d retries", maxRetries)
+			return nil, fmt.Errorf("API rate limit exceeded after %d retries", songLinkMaxRetries)
 		}
 
 		if resp.StatusCode != 200 {
@@ -114,174 +179,101 @@ func (s *SongLinkClient) GetAllURLsFromSpotify(spotifyTrackID string, region str
 			return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
 		}
 
+		body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
 		break
 	}
-	defer resp.Body.Close()
-
-	var songLinkResp struct {
-		LinksByPlatform map[string]struct {
-			URL string `json:"url"`
-		} `json:"linksByPlatform"`
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
 
 	if len(body) == 0 {
 		return nil, fmt.Errorf("API returned empty response")
 	}
 
+	var songLinkResp songLinkResponse
 	if err := json.Unmarshal(body, &songLinkResp); err != nil {
-
 		bodyStr := string(body)
 		if len(bodyStr) > 200 {
 			bodyStr = bodyStr[:200] + "..."
 		}
 		return nil, fmt.Errorf("failed to decode response: %w (response: %s)", err, bodyStr)
 	}
-
-	urls := &SongLinkURLs{}
-
-	if tidalLink, ok := songLinkResp.LinksByPlatform["tidal"]; ok && tidalLink.URL != "" {
-		urls.TidalURL = tidalLink.URL
-		fmt.Printf("✓ Tidal URL found\n")
-	}
-
-	if amazonLink, ok := songLinkResp.LinksByPlatform["amazonMusic"]; ok && amazonLink.URL != "" {
-		amazonURL := amazonLink.URL
-
-		if len(amazonURL) > 0 {
-			urls.AmazonURL = amazonURL
-			fmt.Printf("✓ Amazon URL found\n")
-		}
-	}
-
-	if urls.TidalURL == "" && urls.AmazonURL == "" {
-		return nil, fmt.Errorf("no streaming URLs found")
-	}
-
-	return urls, nil
+	return &songLinkResp, nil
 }
 
-func (s *SongLinkClient) CheckTrackAvailability(spotifyTrackID string, isrc string) (*TrackAvailability, error) {
-
-	now := time.Now()
-	if now.Sub(s.apiCallResetTime) >= time.Minute {
-		s.apiCallCount = 0
-		s.apiCallResetTime = now
-	}
-
-	if s.apiCallCount >= 9 {
-		waitTime := time.Minute - now.Sub(s.apiCallResetTime)
-		if waitTime > 0 {
-			fmt.Printf("Rate limit reached, waiting %v...\n", waitTime.Round(time.Second))
-			time.Sleep(waitTime)
-			s.apiCallCount = 0
-			s.apiCallResetTime = time.Now()
-		}
-	}
-
-	if !s.lastAPICallTime.IsZero() {
-		timeSinceLastCall := now.Sub(s.lastAPICallTime)
-		minDelay := 7 * time.Second
-		if timeSinceLastCall < minDelay {
-			waitTime := minDelay - timeSinceLastCall
-			fmt.Printf("Rate limiting: waiting %v...\n", waitTime.Round(time.Second))
-			time.Sleep(waitTime)
-		}
+func (s *SongLinkClient) spotifyTrackLinks(spotifyTrackID, region string) (map[string]string, error) {
+	if entry := lookupSongLinkCache(spotifyTrackID, region); entry != nil {
+		fmt.Printf("Using cached song.link result for %s\n", spotifyTrackID)
+		return entry.Links, nil
 	}
 
 	spotifyBase, _ := base64.StdEncoding.DecodeString("aHR0cHM6Ly9vcGVuLnNwb3RpZnkuY29tL3RyYWNrLw==")
 	spotifyURL := fmt.Sprintf("%s%s", string(spotifyBase), spotifyTrackID)
 
-	apiBase, _ := base64.StdEncoding.DecodeString("aHR0cHM6Ly9hcGkuc29uZy5saW5rL3YxLWFscGhhLjEvbGlua3M/dXJsPQ==")
-	apiURL := fmt.Sprintf("%s%s", string(apiBase), url.QueryEscape(spotifyURL))
-
-	req, err := http.NewRequest("GET", apiURL, nil)
+	songLinkResp, err := s.fetch(spotifyURL, region)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
 
-	fmt.Printf("Checking availability for track: %s\n", spotifyTrackID)
-
-	maxRetries := 3
-	var resp *http.Response
-	for i := 0; i < maxRetries; i++ {
-		resp, err = s.client.Do(req)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check availability: %w", err)
-		}
+	links := songLinkResp.links()
+	storeSongLinkCache(spotifyTrackID, region, links)
+	return links, nil
+}
 
-		s.lastAPICallTime = time.Now()
-		s.apiCallCount++
+func (s *SongLinkClient) GetAllURLsFromSpotify(spotifyTrackID string, region string) (*SongLinkURLs, error) {
+	fmt.Println("Getting streaming URLs from song.link...")
 
-		if resp.StatusCode == 429 {
-			resp.Body.Close()
-			if i < maxRetries-1 {
-				waitTime := 15 * time.Second
-				fmt.Printf("Rate limited by API, waiting %v before retry...\n", waitTime)
-				time.Sleep(waitTime)
-				continue
-			}
-			return nil, fmt.Errorf("API rate limit exceeded after %d retries", maxRetries)
-		}
+	links, err := s.spotifyTrackLinks(spotifyTrackID, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get URLs: %w", err)
+	}
 
-		if resp.StatusCode != 200 {
-			resp.Body.Close()
-			return nil, fmt.Errorf("API returned status %d", resp.StatusCode)
-		}
+	urls := &SongLinkURLs{}
 
-		break
+	if tidalURL := links["tidal"]; tidalURL != "" {
+		urls.TidalURL = tidalURL
+		fmt.Printf("✓ Tidal URL found\n")
 	}
-	defer resp.Body.Close()
 
-	var songLinkResp struct {
-		LinksByPlatform map[string]struct {
-			URL string `json:"url"`
-		} `json:"linksByPlatform"`
+	if amazonURL := links["amazonMusic"]; amazonURL != "" {
+		urls.AmazonURL = amazonURL
+		fmt.Printf("✓ Amazon URL found\n")
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	if urls.TidalURL == "" && urls.AmazonURL == "" {
+		return nil, fmt.Errorf("no streaming URLs found")
 	}
 
-	if len(body) == 0 {
-		return nil, fmt.Errorf("API returned empty response")
-	}
+	return urls, nil
+}
 
-	if err := json.Unmarshal(body, &songLinkResp); err != nil {
+func (s *SongLinkClient) CheckTrackAvailability(spotifyTrackID string, isrc string) (*TrackAvailability, error) {
+	fmt.Printf("Checking availability for track: %s\n", spotifyTrackID)
 
-		bodyStr := string(body)
-		if len(bodyStr) > 200 {
-			bodyStr = bodyStr[:200] + "..."
-		}
-		return nil, fmt.Errorf("failed to decode response: %w (response: %s)", err, bodyStr)
+	links, err := s.spotifyTrackLinks(spotifyTrackID, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check availability: %w", err)
 	}
 
 	availability := &TrackAvailability{
 		SpotifyID: spotifyTrackID,
 	}
 
-	if tidalLink, ok := songLinkResp.LinksByPlatform["tidal"]; ok && tidalLink.URL != "" {
+	if tidalURL := links["tidal"]; tidalURL != "" {
 		availability.Tidal = true
-		availability.TidalURL = tidalLink.URL
+		availability.TidalURL = tidalURL
 	}
 
-	if amazonLink, ok := songLinkResp.LinksByPlatform["amazonMusic"]; ok && amazonLink.URL != "" {
+	if amazonURL := links["amazonMusic"]; amazonURL != "" {
 		availability.Amazon = true
-		availability.AmazonURL = amazonLink.URL
+		availability.AmazonURL = amazonURL
 	}
 
-	if deezerLink, ok := songLinkResp.LinksByPlatform["deezer"]; ok && deezerLink.URL != "" {
-		deezerURL := deezerLink.URL
-
-		deezerISRC, err := GetDeezerISRC(deezerURL)
-		if err == nil && deezerISRC != "" {
-			qobuzAvailable := checkQobuzAvailability(deezerISRC)
-			availability.Qobuz = qobuzAvailable
+	if links["deezer"] != "" || IsValidISRC(NormalizeISRC(isrc)) {
+		resolution, err := ResolveISRC(s.ctx, ISRCQuery{SpotifyID: spotifyTrackID, ISRC: isrc})
+		if err == nil {
+			availability.Qobuz = checkQobuzAvailability(resolution.ISRC)
 		}
 	}
 
@@ -318,92 +310,18 @@ func checkQobuzAvailability(isrc string) bool {
 }
 
 func (s *SongLinkClient) GetDeezerURLFromSpotify(spotifyTrackID string) (string, error) {
-
-	now := time.Now()
-	if now.Sub(s.apiCallResetTime) >= time.Minute {
-		s.apiCallCount = 0
-		s.apiCallResetTime = now
-	}
-
-	if s.apiCallCount >= 9 {
-		waitTime := time.Minute - now.Sub(s.apiCallResetTime)
-		if waitTime > 0 {
-			fmt.Printf("Rate limit reached, waiting %v...\n", waitTime.Round(time.Second))
-			time.Sleep(waitTime)
-			s.apiCallCount = 0
-			s.apiCallResetTime = time.Now()
-		}
-	}
-
-	if !s.lastAPICallTime.IsZero() {
-		timeSinceLastCall := now.Sub(s.lastAPICallTime)
-		minDelay := 7 * time.Second
-		if timeSinceLastCall < minDelay {
-			waitTime := minDelay - timeSinceLastCall
-			fmt.Printf("Rate limiting: waiting %v...\n", waitTime.Round(time.Second))
-			time.Sleep(waitTime)
-		}
-	}
-
-	spotifyBase, _ := base64.StdEncoding.DecodeString("aHR0cHM6Ly9vcGVuLnNwb3RpZnkuY29tL3RyYWNrLw==")
-	spotifyURL := fmt.Sprintf("%s%s", string(spotifyBase), spotifyTrackID)
-
-	apiBase, _ := base64.StdEncoding.DecodeString("aHR0cHM6Ly9hcGkuc29uZy5saW5rL3YxLWFscGhhLjEvbGlua3M/dXJsPQ==")
-	apiURL := fmt.Sprintf("%s%s", string(apiBase), url.QueryEscape(spotifyURL))
-
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
 	fmt.Println("Getting Deezer URL from song.link...")
 
-	maxRetries := 3
-	var resp *http.Response
-	for i := 0; i < maxRetries; i++ {
-		resp, err = s.client.Do(req)
-		if err != nil {
-			return "", fmt.Errorf("failed to get Deezer URL: %w", err)
-		}
-
-		s.lastAPICallTime = time.Now()
-		s.apiCallCount++
-
-		if resp.StatusCode == 429 {
-			resp.Body.Close()
-			if i < maxRetries-1 {
-				waitTime := 15 * time.Second
-				fmt.Printf("Rate limited by API, waiting %v before retry...\n", waitTime)
-				time.Sleep(waitTime)
-				continue
-			}
-			return "", fmt.Errorf("API rate limit exceeded after %d retries", maxRetries)
-		}
-
-		if resp.StatusCode != 200 {
-			resp.Body.Close()
-			return "", fmt.Errorf("API returned status %d", resp.StatusCode)
-		}
-
-		break
-	}
-	defer resp.Body.Close()
-
-	var songLinkResp struct {
-		LinksByPlatform map[string]struct {
-			URL string `json:"url"`
-		} `json:"linksByPlatform"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&songLinkResp); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+	links, err := s.spotifyTrackLinks(spotifyTrackID, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to get Deezer URL: %w", err)
 	}
 
-	deezerLink, ok := songLinkResp.LinksByPlatform["deezer"]
-	if !ok || deezerLink.URL == "" {
+	deezerURL := links["deezer"]
+	if deezerURL == "" {
 		return "", fmt.Errorf("deezer link not found")
 	}
 
-	deezerURL := deezerLink.URL
 	fmt.Printf("Found Deezer URL: %s\n", deezerURL)
 	return deezerURL, nil
 }
@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type SongLinkCacheEntry struct {
+	SpotifyID string            `json:"spotify_id"`
+	Region    string            `json:"region,omitempty"`
+	Links     map[string]string `json:"links"`
+	Timestamp int64             `json:"timestamp"`
+}
+
+const (
+	songLinkCacheBucket = "SongLinkCache"
+	songLinkCacheTTL    = 30 * 24 * time.Hour
+)
+
+func songLinkCacheKey(spotifyID, region string) string {
+	if region == "" {
+		return spotifyID
+	}
+	return spotifyID + "|" + strings.ToUpper(region)
+}
+
+func (e *SongLinkCacheEntry) Expired() bool {
+	return time.Since(time.Unix(e.Timestamp, 0)) >= songLinkCacheTTL
+}
+
+func lookupSongLinkCache(spotifyID, region string) *SongLinkCacheEntry {
+	if historyDB == nil || spotifyID == "" {
+		return nil
+	}
+
+	var found *SongLinkCacheEntry
+	historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(songLinkCacheBucket))
+		if b == nil {
+			return nil
+		}
+		v := b.Get([]byte(songLinkCacheKey(spotifyID, region)))
+		if v == nil {
+			return nil
+		}
+		var entry SongLinkCacheEntry
+		if err := json.Unmarshal(v, &entry); err == nil && !entry.Expired() && len(entry.Links) > 0 {
+			found = &entry
+		}
+		return nil
+	})
+	return found
+}
+
+func storeSongLinkCache(spotifyID, region string, links map[string]string) {
+	if historyDB == nil || spotifyID == "" || len(links) == 0 {
+		return
+	}
+
+	entry := SongLinkCacheEntry{
+		SpotifyID: spotifyID,
+		Region:    strings.ToUpper(region),
+		Links:     links,
+		Timestamp: time.Now().Unix(),
+	}
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	err = historyDB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(songLinkCacheBucket))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(songLinkCacheKey(spotifyID, region)), buf)
+	})
+	if err != nil {
+		fmt.Printf("[SongLinkCache] Failed to store %s: %v\n", spotifyID, err)
+	}
+}
+
+func GetCachedSongLinks(spotifyID, region, appName string) (*SongLinkCacheEntry, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+	return lookupSongLinkCache(spotifyID, region), nil
+}
+
+func DeleteCachedSongLinks(spotifyID, appName string) error {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return err
+		}
+	}
+
+	return historyDB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(songLinkCacheBucket))
+		if b == nil {
+			return nil
+		}
+
+		var keys [][]byte
+		c := b.Cursor()
+		prefix := []byte(spotifyID)
+		for k, _ := c.Seek(prefix); k != nil && strings.HasPrefix(string(k), spotifyID); k, _ = c.Next() {
+			if len(k) == len(prefix) || k[len(prefix)] == '|' {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+		}
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func ClearSongLinkCache(appName string) error {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return err
+		}
+	}
+
+	return historyDB.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(songLinkCacheBucket)) == nil {
+			return nil
+		}
+		return tx.DeleteBucket([]byte(songLinkCacheBucket))
+	})
+}
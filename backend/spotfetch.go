@@ -317,6 +317,46 @@ func (c *SpotifyClient) Query(payload map[string]interface{}) (map[string]interf
 	return result, nil
 }
 
+func (c *SpotifyClient) Get(endpoint string, out interface{}) error {
+	if c.accessToken == "" || c.clientToken == "" {
+		if err := c.Initialize(); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("Client-Token", c.clientToken)
+	req.Header.Set("Spotify-App-Version", c.clientVersion)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		errorText := string(body)
+		if len(errorText) > 200 {
+			errorText = errorText[:200]
+		}
+		return fmt.Errorf("%w: API request failed: HTTP %d | %s", SpotifyError, resp.StatusCode, errorText)
+	}
+
+	return json.Unmarshal(body, out)
+}
+
 func getString(m map[string]interface{}, key string) string {
 	if val, ok := m[key].(string); ok {
 		return val
@@ -698,13 +738,13 @@ func FilterTrack(data map[string]interface{}, albumFetchData ...map[string]inter
 		}
 
 		albumArtistsString := ""
+		albumArtistNames := []string{}
 		albumLabel := ""
 		if albumFetchDataMap != nil && len(albumFetchDataMap) > 0 {
 			albumUnionData := getMap(getMap(albumFetchDataMap, "data"), "albumUnion")
 			if len(albumUnionData) > 0 {
 				albumArtists := extractArtists(getMap(albumUnionData, "artists"))
 				if len(albumArtists) > 0 {
-					albumArtistNames := []string{}
 					for _, artist := range albumArtists {
 						albumArtistNames = append(albumArtistNames, getString(artist, "name"))
 					}
@@ -717,7 +757,7 @@ func FilterTrack(data map[string]interface{}, albumFetchData ...map[string]inter
 		if albumArtistsString == "" {
 			albumArtists := extractArtists(getMap(albumData, "artists"))
 			if len(albumArtists) > 0 {
-				albumArtistNames := []string{}
+				albumArtistNames = []string{}
 				for _, artist := range albumArtists {
 					albumArtistNames = append(albumArtistNames, getString(artist, "name"))
 				}
@@ -735,6 +775,7 @@ func FilterTrack(data map[string]interface{}, albumFetchData ...map[string]inter
 
 		if albumArtistsString != "" {
 			albumInfo["artists"] = albumArtistsString
+			albumInfo["artists_list"] = albumArtistNames
 		}
 
 		if albumLabel != "" {
@@ -825,18 +866,19 @@ func FilterTrack(data map[string]interface{}, albumFetchData ...map[string]inter
 	isExplicit := getString(contentRating, "label") == "EXPLICIT"
 
 	filtered := map[string]interface{}{
-		"id":          getString(trackData, "id"),
-		"name":        getString(trackData, "name"),
-		"artists":     artistsString,
-		"album":       albumInfo,
-		"duration":    durationString,
-		"track":       int(getFloat64(trackData, "trackNumber")),
-		"disc":        discNumber,
-		"discs":       totalDiscs,
-		"copyright":   copyrightString,
-		"plays":       getString(trackData, "playcount"),
-		"cover":       cover,
-		"is_explicit": isExplicit,
+		"id":           getString(trackData, "id"),
+		"name":         getString(trackData, "name"),
+		"artists":      artistsString,
+		"artists_list": artistNames,
+		"album":        albumInfo,
+		"duration":     durationString,
+		"track":        int(getFloat64(trackData, "trackNumber")),
+		"disc":         discNumber,
+		"discs":        totalDiscs,
+		"copyright":    copyrightString,
+		"plays":        getString(trackData, "playcount"),
+		"cover":        cover,
+		"is_explicit":  isExplicit,
 	}
 
 	return filtered
@@ -936,6 +978,7 @@ func FilterAlbum(data map[string]interface{}) map[string]interface{} {
 				"id":          trackID,
 				"name":        getString(track, "name"),
 				"artists":     trackArtistsString,
+				"artistsList": trackArtistNames,
 				"artistIds":   artistIDs,
 				"duration":    durationString,
 				"plays":       getString(track, "playcount"),
@@ -970,6 +1013,7 @@ func FilterAlbum(data map[string]interface{}) map[string]interface{} {
 		"id":          albumID,
 		"name":        getString(albumData, "name"),
 		"artists":     albumArtistsString,
+		"artistsList": artistNames,
 		"cover":       cover,
 		"releaseDate": releaseDate,
 		"count":       len(tracks),
@@ -1133,6 +1177,7 @@ func FilterPlaylist(data map[string]interface{}) map[string]interface{} {
 			albumName := ""
 			albumID := ""
 			albumArtistsString := ""
+			albumArtistNames := []string{}
 			var trackCover interface{}
 
 			if len(albumData) > 0 {
@@ -1156,7 +1201,6 @@ func FilterPlaylist(data map[string]interface{}) map[string]interface{} {
 
 				albumArtists := extractArtists(getMap(albumData, "artists"))
 				if len(albumArtists) > 0 {
-					albumArtistNames := []string{}
 					for _, artist := range albumArtists {
 						albumArtistNames = append(albumArtistNames, getString(artist, "name"))
 					}
@@ -1173,19 +1217,21 @@ func FilterPlaylist(data map[string]interface{}) map[string]interface{} {
 			}
 
 			trackInfo := map[string]interface{}{
-				"id":          trackID,
-				"cover":       trackCover,
-				"title":       trackName,
-				"artist":      artistsString,
-				"artistIds":   artistIDs,
-				"plays":       rank,
-				"status":      status,
-				"album":       albumName,
-				"albumArtist": albumArtistsString,
-				"albumId":     albumID,
-				"duration":    durationString,
-				"is_explicit": isExplicit,
-				"disc_number": int(getFloat64(trackData, "discNumber")),
+				"id":           trackID,
+				"cover":        trackCover,
+				"title":        trackName,
+				"artist":       artistsString,
+				"artistsList":  trackArtistNames,
+				"artistIds":    artistIDs,
+				"plays":        rank,
+				"status":       status,
+				"album":        albumName,
+				"albumArtist":  albumArtistsString,
+				"albumArtists": albumArtistNames,
+				"albumId":      albumID,
+				"duration":     durationString,
+				"is_explicit":  isExplicit,
+				"disc_number":  int(getFloat64(trackData, "discNumber")),
 			}
 			tracks = append(tracks, trackInfo)
 		}
@@ -29,25 +29,27 @@ func NewSpotifyMetadataClient() *SpotifyMetadataClient {
 }
 
 type TrackMetadata struct {
-	SpotifyID   string `json:"spotify_id,omitempty"`
-	Artists     string `json:"artists"`
-	Name        string `json:"name"`
-	AlbumName   string `json:"album_name"`
-	AlbumArtist string `json:"album_artist,omitempty"`
-	DurationMS  int    `json:"duration_ms"`
-	Images      string `json:"images"`
-	ReleaseDate string `json:"release_date"`
-	TrackNumber int    `json:"track_number"`
-	TotalTracks int    `json:"total_tracks,omitempty"`
-	DiscNumber  int    `json:"disc_number,omitempty"`
-	TotalDiscs  int    `json:"total_discs,omitempty"`
-	ExternalURL string `json:"external_urls"`
-	ISRC        string `json:"isrc"`
-	Copyright   string `json:"copyright,omitempty"`
-	Publisher   string `json:"publisher,omitempty"`
-	Plays       string `json:"plays,omitempty"`
-	PreviewURL  string `json:"preview_url,omitempty"`
-	IsExplicit  bool   `json:"is_explicit,omitempty"`
+	SpotifyID        string   `json:"spotify_id,omitempty"`
+	Artists          string   `json:"artists"`
+	Name             string   `json:"name"`
+	AlbumName        string   `json:"album_name"`
+	AlbumArtist      string   `json:"album_artist,omitempty"`
+	DurationMS       int      `json:"duration_ms"`
+	Images           string   `json:"images"`
+	ReleaseDate      string   `json:"release_date"`
+	TrackNumber      int      `json:"track_number"`
+	TotalTracks      int      `json:"total_tracks,omitempty"`
+	DiscNumber       int      `json:"disc_number,omitempty"`
+	TotalDiscs       int      `json:"total_discs,omitempty"`
+	ExternalURL      string   `json:"external_urls"`
+	ISRC             string   `json:"isrc"`
+	Copyright        string   `json:"copyright,omitempty"`
+	Publisher        string   `json:"publisher,omitempty"`
+	Plays            string   `json:"plays,omitempty"`
+	PreviewURL       string   `json:"preview_url,omitempty"`
+	IsExplicit       bool     `json:"is_explicit,omitempty"`
+	ArtistsList      []string `json:"artists_list,omitempty"`
+	AlbumArtistsList []string `json:"album_artists_list,omitempty"`
 }
 
 type ArtistSimple struct {
@@ -57,30 +59,32 @@ type ArtistSimple struct {
 }
 
 type AlbumTrackMetadata struct {
-	SpotifyID   string         `json:"spotify_id,omitempty"`
-	Artists     string         `json:"artists"`
-	Name        string         `json:"name"`
-	AlbumName   string         `json:"album_name"`
-	AlbumArtist string         `json:"album_artist,omitempty"`
-	DurationMS  int            `json:"duration_ms"`
-	Images      string         `json:"images"`
-	ReleaseDate string         `json:"release_date"`
-	TrackNumber int            `json:"track_number"`
-	TotalTracks int            `json:"total_tracks,omitempty"`
-	DiscNumber  int            `json:"disc_number,omitempty"`
-	TotalDiscs  int            `json:"total_discs,omitempty"`
-	ExternalURL string         `json:"external_urls"`
-	ISRC        string         `json:"isrc"`
-	AlbumType   string         `json:"album_type,omitempty"`
-	AlbumID     string         `json:"album_id,omitempty"`
-	AlbumURL    string         `json:"album_url,omitempty"`
-	ArtistID    string         `json:"artist_id,omitempty"`
-	ArtistURL   string         `json:"artist_url,omitempty"`
-	ArtistsData []ArtistSimple `json:"artists_data,omitempty"`
-	Plays       string         `json:"plays,omitempty"`
-	Status      string         `json:"status,omitempty"`
-	PreviewURL  string         `json:"preview_url,omitempty"`
-	IsExplicit  bool           `json:"is_explicit,omitempty"`
+	SpotifyID        string         `json:"spotify_id,omitempty"`
+	Artists          string         `json:"artists"`
+	Name             string         `json:"name"`
+	AlbumName        string         `json:"album_name"`
+	AlbumArtist      string         `json:"album_artist,omitempty"`
+	DurationMS       int            `json:"duration_ms"`
+	Images           string         `json:"images"`
+	ReleaseDate      string         `json:"release_date"`
+	TrackNumber      int            `json:"track_number"`
+	TotalTracks      int            `json:"total_tracks,omitempty"`
+	DiscNumber       int            `json:"disc_number,omitempty"`
+	TotalDiscs       int            `json:"total_discs,omitempty"`
+	ExternalURL      string         `json:"external_urls"`
+	ISRC             string         `json:"isrc"`
+	AlbumType        string         `json:"album_type,omitempty"`
+	AlbumID          string         `json:"album_id,omitempty"`
+	AlbumURL         string         `json:"album_url,omitempty"`
+	ArtistID         string         `json:"artist_id,omitempty"`
+	ArtistURL        string         `json:"artist_url,omitempty"`
+	ArtistsData      []ArtistSimple `json:"artists_data,omitempty"`
+	Plays            string         `json:"plays,omitempty"`
+	Status           string         `json:"status,omitempty"`
+	PreviewURL       string         `json:"preview_url,omitempty"`
+	IsExplicit       bool           `json:"is_explicit,omitempty"`
+	ArtistsList      []string       `json:"artists_list,omitempty"`
+	AlbumArtistsList []string       `json:"album_artists_list,omitempty"`
 }
 
 type TrackResponse struct {
@@ -88,14 +92,15 @@ type TrackResponse struct {
 }
 
 type AlbumInfoMetadata struct {
-	TotalTracks int    `json:"total_tracks"`
-	Name        string `json:"name"`
-	ReleaseDate string `json:"release_date"`
-	Artists     string `json:"artists"`
-	Images      string `json:"images"`
-	Batch       string `json:"batch,omitempty"`
-	ArtistID    string `json:"artist_id,omitempty"`
-	ArtistURL   string `json:"artist_url,omitempty"`
+	TotalTracks int      `json:"total_tracks"`
+	Name        string   `json:"name"`
+	ReleaseDate string   `json:"release_date"`
+	Artists     string   `json:"artists"`
+	ArtistsList []string `json:"artists_list,omitempty"`
+	Images      string   `json:"images"`
+	Batch       string   `json:"batch,omitempty"`
+	ArtistID    string   `json:"artist_id,omitempty"`
+	ArtistURL   string   `json:"artist_url,omitempty"`
 }
 
 type AlbumResponsePayload struct {
@@ -177,23 +182,25 @@ type spotifyURI struct {
 }
 
 type apiTrackResponse struct {
-	ID        string `json:"id"`
-	Name      string `json:"name"`
-	Artists   string `json:"artists"`
-	Duration  string `json:"duration"`
-	Track     int    `json:"track"`
-	Disc      int    `json:"disc"`
-	Discs     int    `json:"discs"`
-	Copyright string `json:"copyright"`
-	Plays     string `json:"plays"`
-	Album     struct {
-		ID       string `json:"id"`
-		Name     string `json:"name"`
-		Released string `json:"released"`
-		Year     int    `json:"year"`
-		Tracks   int    `json:"tracks"`
-		Artists  string `json:"artists"`
-		Label    string `json:"label"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Artists     string   `json:"artists"`
+	ArtistsList []string `json:"artists_list"`
+	Duration    string   `json:"duration"`
+	Track       int      `json:"track"`
+	Disc        int      `json:"disc"`
+	Discs       int      `json:"discs"`
+	Copyright   string   `json:"copyright"`
+	Plays       string   `json:"plays"`
+	Album       struct {
+		ID          string   `json:"id"`
+		Name        string   `json:"name"`
+		Released    string   `json:"released"`
+		Year        int      `json:"year"`
+		Tracks      int      `json:"tracks"`
+		Artists     string   `json:"artists"`
+		ArtistsList []string `json:"artists_list"`
+		Label       string   `json:"label"`
 	} `json:"album"`
 	Cover struct {
 		Small  string `json:"small"`
@@ -204,24 +211,26 @@ type apiTrackResponse struct {
 }
 
 type apiAlbumResponse struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Artists     string `json:"artists"`
-	Cover       string `json:"cover"`
-	ReleaseDate string `json:"releaseDate"`
-	Count       int    `json:"count"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Artists     string   `json:"artists"`
+	ArtistsList []string `json:"artistsList"`
+	Cover       string   `json:"cover"`
+	ReleaseDate string   `json:"releaseDate"`
+	Count       int      `json:"count"`
 	Discs       struct {
 		TotalCount int `json:"totalCount"`
 	} `json:"discs"`
 	Tracks []struct {
-		ID         string   `json:"id"`
-		Name       string   `json:"name"`
-		Artists    string   `json:"artists"`
-		ArtistIds  []string `json:"artistIds"`
-		Duration   string   `json:"duration"`
-		Plays      string   `json:"plays"`
-		IsExplicit bool     `json:"is_explicit"`
-		DiscNumber int      `json:"disc_number"`
+		ID          string   `json:"id"`
+		Name        string   `json:"name"`
+		Artists     string   `json:"artists"`
+		ArtistsList []string `json:"artistsList"`
+		ArtistIds   []string `json:"artistIds"`
+		Duration    string   `json:"duration"`
+		Plays       string   `json:"plays"`
+		IsExplicit  bool     `json:"is_explicit"`
+		DiscNumber  int      `json:"disc_number"`
 	} `json:"tracks"`
 }
 
@@ -237,19 +246,21 @@ type apiPlaylistResponse struct {
 	Count     int    `json:"count"`
 	Followers int    `json:"followers"`
 	Tracks    []struct {
-		ID          string   `json:"id"`
-		Cover       string   `json:"cover"`
-		Title       string   `json:"title"`
-		Artist      string   `json:"artist"`
-		ArtistIds   []string `json:"artistIds"`
-		Plays       string   `json:"plays"`
-		Status      string   `json:"status"`
-		Album       string   `json:"album"`
-		AlbumArtist string   `json:"albumArtist"`
-		AlbumID     string   `json:"albumId"`
-		Duration    string   `json:"duration"`
-		IsExplicit  bool     `json:"is_explicit"`
-		DiscNumber  int      `json:"disc_number"`
+		ID           string   `json:"id"`
+		Cover        string   `json:"cover"`
+		Title        string   `json:"title"`
+		Artist       string   `json:"artist"`
+		ArtistsList  []string `json:"artistsList"`
+		ArtistIds    []string `json:"artistIds"`
+		Plays        string   `json:"plays"`
+		Status       string   `json:"status"`
+		Album        string   `json:"album"`
+		AlbumArtist  string   `json:"albumArtist"`
+		AlbumArtists []string `json:"albumArtists"`
+		AlbumID      string   `json:"albumId"`
+		Duration     string   `json:"duration"`
+		IsExplicit   bool     `json:"is_explicit"`
+		DiscNumber   int      `json:"disc_number"`
 	} `json:"tracks"`
 }
 
@@ -496,6 +507,55 @@ func (c *SpotifyMetadataClient) fetchTrack(ctx context.Context, trackID string)
 	return &result, nil
 }
 
+type SpotifyTrackIDs struct {
+	ISRC    string `json:"isrc"`
+	AlbumID string `json:"album_id"`
+}
+
+func (c *SpotifyMetadataClient) FetchTrackISRCs(ctx context.Context, trackIDs []string) (map[string]SpotifyTrackIDs, error) {
+	client := NewSpotifyClient()
+	if err := client.Initialize(); err != nil {
+		return nil, fmt.Errorf("failed to initialize spotify client: %w", err)
+	}
+
+	result := make(map[string]SpotifyTrackIDs)
+	for start := 0; start < len(trackIDs); start += 50 {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		end := start + 50
+		if end > len(trackIDs) {
+			end = len(trackIDs)
+		}
+
+		var resp struct {
+			Tracks []struct {
+				ID          string `json:"id"`
+				ExternalIDs struct {
+					ISRC string `json:"isrc"`
+				} `json:"external_ids"`
+				Album struct {
+					ID string `json:"id"`
+				} `json:"album"`
+			} `json:"tracks"`
+		}
+		endpoint := "https://api.spotify.com/v1/tracks?ids=" + url.QueryEscape(strings.Join(trackIDs[start:end], ","))
+		if err := client.Get(endpoint, &resp); err != nil {
+			return result, fmt.Errorf("failed to fetch track ISRCs: %w", err)
+		}
+		for _, track := range resp.Tracks {
+			if track.ID == "" {
+				continue
+			}
+			result[track.ID] = SpotifyTrackIDs{
+				ISRC:    strings.ToUpper(track.ExternalIDs.ISRC),
+				AlbumID: track.Album.ID,
+			}
+		}
+	}
+	return result, nil
+}
+
 func (c *SpotifyMetadataClient) fetchAlbum(ctx context.Context, albumID string) (*apiAlbumResponse, error) {
 	client := NewSpotifyClient()
 	if err := client.Initialize(); err != nil {
@@ -873,24 +933,26 @@ func (c *SpotifyMetadataClient) formatTrackData(raw *apiTrackResponse) TrackResp
 		releaseDate = fmt.Sprintf("%d", raw.Album.Year)
 	}
 	trackMetadata := TrackMetadata{
-		SpotifyID:   raw.ID,
-		Artists:     raw.Artists,
-		Name:        raw.Name,
-		AlbumName:   raw.Album.Name,
-		AlbumArtist: raw.Album.Artists,
-		DurationMS:  durationMS,
-		Images:      coverURL,
-		ReleaseDate: releaseDate,
-		TrackNumber: raw.Track,
-		TotalTracks: raw.Album.Tracks,
-		DiscNumber:  raw.Disc,
-		TotalDiscs:  raw.Discs,
-		ExternalURL: externalURL,
-		ISRC:        raw.ID,
-		Copyright:   raw.Copyright,
-		Publisher:   raw.Album.Label,
-		Plays:       raw.Plays,
-		IsExplicit:  raw.IsExplicit,
+		SpotifyID:        raw.ID,
+		Artists:          raw.Artists,
+		Name:             raw.Name,
+		AlbumName:        raw.Album.Name,
+		AlbumArtist:      raw.Album.Artists,
+		DurationMS:       durationMS,
+		Images:           coverURL,
+		ReleaseDate:      releaseDate,
+		TrackNumber:      raw.Track,
+		TotalTracks:      raw.Album.Tracks,
+		DiscNumber:       raw.Disc,
+		TotalDiscs:       raw.Discs,
+		ExternalURL:      externalURL,
+		ISRC:             raw.ID,
+		Copyright:        raw.Copyright,
+		Publisher:        raw.Album.Label,
+		Plays:            raw.Plays,
+		IsExplicit:       raw.IsExplicit,
+		ArtistsList:      raw.ArtistsList,
+		AlbumArtistsList: raw.Album.ArtistsList,
 	}
 
 	return TrackResponse{
@@ -906,6 +968,7 @@ func (c *SpotifyMetadataClient) formatAlbumData(raw *apiAlbumResponse) (*AlbumRe
 		Name:        raw.Name,
 		ReleaseDate: raw.ReleaseDate,
 		Artists:     raw.Artists,
+		ArtistsList: raw.ArtistsList,
 		Images:      raw.Cover,
 		ArtistID:    artistID,
 		ArtistURL:   artistURL,
@@ -923,36 +986,42 @@ func (c *SpotifyMetadataClient) formatAlbumData(raw *apiAlbumResponse) (*AlbumRe
 		}
 
 		artistsData := make([]ArtistSimple, 0, len(item.ArtistIds))
-		for _, id := range item.ArtistIds {
+		for i, id := range item.ArtistIds {
+			name := ""
+			if len(item.ArtistsList) == len(item.ArtistIds) {
+				name = item.ArtistsList[i]
+			}
 			artistsData = append(artistsData, ArtistSimple{
 				ID:          id,
-				Name:        "",
+				Name:        name,
 				ExternalURL: fmt.Sprintf("https://open.spotify.com/artist/%s", id),
 			})
 		}
 
 		tracks = append(tracks, AlbumTrackMetadata{
-			SpotifyID:   item.ID,
-			Artists:     item.Artists,
-			Name:        item.Name,
-			AlbumName:   raw.Name,
-			AlbumArtist: raw.Artists,
-			DurationMS:  durationMS,
-			Images:      raw.Cover,
-			ReleaseDate: raw.ReleaseDate,
-			TrackNumber: trackNumber,
-			TotalTracks: raw.Count,
-			DiscNumber:  item.DiscNumber,
-			TotalDiscs:  raw.Discs.TotalCount,
-			ExternalURL: fmt.Sprintf("https://open.spotify.com/track/%s", item.ID),
-			ISRC:        item.ID,
-			AlbumID:     raw.ID,
-			AlbumURL:    fmt.Sprintf("https://open.spotify.com/album/%s", raw.ID),
-			ArtistID:    artistID,
-			ArtistURL:   artistURL,
-			ArtistsData: artistsData,
-			Plays:       item.Plays,
-			IsExplicit:  item.IsExplicit,
+			SpotifyID:        item.ID,
+			Artists:          item.Artists,
+			Name:             item.Name,
+			AlbumName:        raw.Name,
+			AlbumArtist:      raw.Artists,
+			DurationMS:       durationMS,
+			Images:           raw.Cover,
+			ReleaseDate:      raw.ReleaseDate,
+			TrackNumber:      trackNumber,
+			TotalTracks:      raw.Count,
+			DiscNumber:       item.DiscNumber,
+			TotalDiscs:       raw.Discs.TotalCount,
+			ExternalURL:      fmt.Sprintf("https://open.spotify.com/track/%s", item.ID),
+			ISRC:             item.ID,
+			AlbumID:          raw.ID,
+			AlbumURL:         fmt.Sprintf("https://open.spotify.com/album/%s", raw.ID),
+			ArtistID:         artistID,
+			ArtistURL:        artistURL,
+			ArtistsData:      artistsData,
+			Plays:            item.Plays,
+			IsExplicit:       item.IsExplicit,
+			ArtistsList:      item.ArtistsList,
+			AlbumArtistsList: raw.ArtistsList,
 		})
 	}
 
@@ -983,37 +1052,43 @@ func (c *SpotifyMetadataClient) formatPlaylistData(raw *apiPlaylistResponse) Pla
 		}
 
 		artistsData := make([]ArtistSimple, 0, len(item.ArtistIds))
-		for _, id := range item.ArtistIds {
+		for i, id := range item.ArtistIds {
+			name := ""
+			if len(item.ArtistsList) == len(item.ArtistIds) {
+				name = item.ArtistsList[i]
+			}
 			artistsData = append(artistsData, ArtistSimple{
 				ID:          id,
-				Name:        "",
+				Name:        name,
 				ExternalURL: fmt.Sprintf("https://open.spotify.com/artist/%s", id),
 			})
 		}
 
 		tracks = append(tracks, AlbumTrackMetadata{
-			SpotifyID:   item.ID,
-			Artists:     item.Artist,
-			Name:        item.Title,
-			AlbumName:   item.Album,
-			AlbumArtist: item.AlbumArtist,
-			DurationMS:  durationMS,
-			Images:      item.Cover,
-			ReleaseDate: "",
-			TrackNumber: 0,
-			TotalTracks: 0,
-			DiscNumber:  item.DiscNumber,
-			TotalDiscs:  0,
-			ExternalURL: fmt.Sprintf("https://open.spotify.com/track/%s", item.ID),
-			ISRC:        item.ID,
-			AlbumID:     item.AlbumID,
-			AlbumURL:    fmt.Sprintf("https://open.spotify.com/album/%s", item.AlbumID),
-			ArtistID:    artistID,
-			ArtistURL:   artistURL,
-			ArtistsData: artistsData,
-			Plays:       item.Plays,
-			Status:      item.Status,
-			IsExplicit:  item.IsExplicit,
+			SpotifyID:        item.ID,
+			Artists:          item.Artist,
+			Name:             item.Title,
+			AlbumName:        item.Album,
+			AlbumArtist:      item.AlbumArtist,
+			DurationMS:       durationMS,
+			Images:           item.Cover,
+			ReleaseDate:      "",
+			TrackNumber:      0,
+			TotalTracks:      0,
+			DiscNumber:       item.DiscNumber,
+			TotalDiscs:       0,
+			ExternalURL:      fmt.Sprintf("https://open.spotify.com/track/%s", item.ID),
+			ISRC:             item.ID,
+			AlbumID:          item.AlbumID,
+			AlbumURL:         fmt.Sprintf("https://open.spotify.com/album/%s", item.AlbumID),
+			ArtistID:         artistID,
+			ArtistURL:        artistURL,
+			ArtistsData:      artistsData,
+			Plays:            item.Plays,
+			Status:           item.Status,
+			IsExplicit:       item.IsExplicit,
+			ArtistsList:      item.ArtistsList,
+			AlbumArtistsList: item.AlbumArtists,
 		})
 	}
 
@@ -1102,36 +1177,42 @@ func (c *SpotifyMetadataClient) formatArtistDiscographyData(ctx context.Context,
 				}
 
 				artistsData := make([]ArtistSimple, 0, len(tr.ArtistIds))
-				for _, id := range tr.ArtistIds {
+				for i, id := range tr.ArtistIds {
+					name := ""
+					if len(tr.ArtistsList) == len(tr.ArtistIds) {
+						name = tr.ArtistsList[i]
+					}
 					artistsData = append(artistsData, ArtistSimple{
 						ID:          id,
-						Name:        "",
+						Name:        name,
 						ExternalURL: fmt.Sprintf("https://open.spotify.com/artist/%s", id),
 					})
 				}
 
 				tracks = append(tracks, AlbumTrackMetadata{
-					SpotifyID:   tr.ID,
-					Artists:     tr.Artists,
-					Name:        tr.Name,
-					AlbumName:   albumData.Name,
-					AlbumArtist: raw.Name,
-					AlbumType:   "album",
-					DurationMS:  durationMS,
-					Images:      albumData.Cover,
-					ReleaseDate: albumData.ReleaseDate,
-					TrackNumber: trackNumber,
-					TotalTracks: albumData.Count,
-					DiscNumber:  tr.DiscNumber,
-					ExternalURL: fmt.Sprintf("https://open.spotify.com/track/%s", tr.ID),
-					ISRC:        tr.ID,
-					AlbumID:     albumID,
-					AlbumURL:    fmt.Sprintf("https://open.spotify.com/album/%s", albumID),
-					ArtistID:    artistID,
-					ArtistURL:   artistURL,
-					ArtistsData: artistsData,
-					Plays:       tr.Plays,
-					IsExplicit:  tr.IsExplicit,
+					SpotifyID:        tr.ID,
+					Artists:          tr.Artists,
+					Name:             tr.Name,
+					AlbumName:        albumData.Name,
+					AlbumArtist:      raw.Name,
+					AlbumType:        "album",
+					DurationMS:       durationMS,
+					Images:           albumData.Cover,
+					ReleaseDate:      albumData.ReleaseDate,
+					TrackNumber:      trackNumber,
+					TotalTracks:      albumData.Count,
+					DiscNumber:       tr.DiscNumber,
+					ExternalURL:      fmt.Sprintf("https://open.spotify.com/track/%s", tr.ID),
+					ISRC:             tr.ID,
+					AlbumID:          albumID,
+					AlbumURL:         fmt.Sprintf("https://open.spotify.com/album/%s", albumID),
+					ArtistID:         artistID,
+					ArtistURL:        artistURL,
+					ArtistsData:      artistsData,
+					Plays:            tr.Plays,
+					IsExplicit:       tr.IsExplicit,
+					ArtistsList:      tr.ArtistsList,
+					AlbumArtistsList: albumData.ArtistsList,
 				})
 			}
 			resultsChan <- fetchResult{tracks: tracks}
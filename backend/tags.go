@@ -0,0 +1,1066 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	id3v2 "github.com/bogem/id3v2/v2"
+	"github.com/go-flac/flacvorbis"
+	"github.com/go-flac/go-flac"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	tagJournalBucket = "TagJournal"
+	maxTagJournal    = 200
+)
+
+var id3FrameFields = map[string]string{
+	"TIT1": "GROUPING",
+	"TIT2": "TITLE",
+	"TIT3": "SUBTITLE",
+	"TPE1": "ARTIST",
+	"TPE2": "ALBUMARTIST",
+	"TPE3": "CONDUCTOR",
+	"TPE4": "REMIXER",
+	"TALB": "ALBUM",
+	"TDRC": "DATE",
+	"TYER": "DATE",
+	"TDOR": "ORIGINALDATE",
+	"TCON": "GENRE",
+	"TCOM": "COMPOSER",
+	"TEXT": "LYRICIST",
+	"TCOP": "COPYRIGHT",
+	"TPUB": "PUBLISHER",
+	"TSRC": "ISRC",
+	"TBPM": "BPM",
+	"TKEY": "KEY",
+	"TLAN": "LANGUAGE",
+	"TMED": "MEDIA",
+	"TENC": "ENCODEDBY",
+	"TSSE": "ENCODER",
+	"TSOA": "ALBUMSORT",
+	"TSOP": "ARTISTSORT",
+	"TSOT": "TITLESORT",
+	"TSO2": "ALBUMARTISTSORT",
+	"TSOC": "COMPOSERSORT",
+}
+
+var id3FieldFrames = map[string]string{
+	"GROUPING":        "TIT1",
+	"TITLE":           "TIT2",
+	"SUBTITLE":        "TIT3",
+	"ARTIST":          "TPE1",
+	"ALBUMARTIST":     "TPE2",
+	"CONDUCTOR":       "TPE3",
+	"REMIXER":         "TPE4",
+	"ALBUM":           "TALB",
+	"ORIGINALDATE":    "TDOR",
+	"GENRE":           "TCON",
+	"COMPOSER":        "TCOM",
+	"LYRICIST":        "TEXT",
+	"COPYRIGHT":       "TCOP",
+	"PUBLISHER":       "TPUB",
+	"ISRC":            "TSRC",
+	"BPM":             "TBPM",
+	"KEY":             "TKEY",
+	"LANGUAGE":        "TLAN",
+	"MEDIA":           "TMED",
+	"ENCODEDBY":       "TENC",
+	"ENCODER":         "TSSE",
+	"ALBUMSORT":       "TSOA",
+	"ARTISTSORT":      "TSOP",
+	"TITLESORT":       "TSOT",
+	"ALBUMARTISTSORT": "TSO2",
+	"COMPOSERSORT":    "TSOC",
+}
+
+var m4aKeyFields = map[string]string{
+	"album_artist": "ALBUMARTIST",
+	"albumartist":  "ALBUMARTIST",
+	"track":        "TRACKNUMBER",
+	"disc":         "DISCNUMBER",
+	"disk":         "DISCNUMBER",
+	"lyrics":       "LYRICS",
+	"comment":      "COMMENT",
+	"date":         "DATE",
+	"year":         "DATE",
+}
+
+var m4aFieldKeys = map[string]string{
+	"ALBUMARTIST": "album_artist",
+	"DISCNUMBER":  "disc",
+	"TRACKNUMBER": "track",
+}
+
+var m4aWritableKeys = map[string]bool{
+	"title":             true,
+	"artist":            true,
+	"album_artist":      true,
+	"album":             true,
+	"composer":          true,
+	"date":              true,
+	"genre":             true,
+	"comment":           true,
+	"copyright":         true,
+	"grouping":          true,
+	"lyrics":            true,
+	"description":       true,
+	"synopsis":          true,
+	"compilation":       true,
+	"track":             true,
+	"disc":              true,
+	"sort_name":         true,
+	"sort_artist":       true,
+	"sort_album_artist": true,
+	"sort_album":        true,
+	"sort_composer":     true,
+}
+
+const m4aValueSeparator = "; "
+
+var m4aIgnoredKeys = map[string]bool{
+	"major_brand":       true,
+	"minor_version":     true,
+	"compatible_brands": true,
+	"encoder":           true,
+	"handler_name":      true,
+	"vendor_id":         true,
+	"creation_time":     true,
+}
+
+type FileTags struct {
+	Path   string              `json:"path"`
+	Format string              `json:"format"`
+	Tags   map[string][]string `json:"tags"`
+	Error  string              `json:"error,omitempty"`
+}
+
+type TagEditOperation struct {
+	Action        string   `json:"action"`
+	Field         string   `json:"field"`
+	Values        []string `json:"values,omitempty"`
+	Find          string   `json:"find,omitempty"`
+	Replace       string   `json:"replace,omitempty"`
+	Regex         bool     `json:"regex,omitempty"`
+	CaseSensitive bool     `json:"case_sensitive,omitempty"`
+	SourceField   string   `json:"source_field,omitempty"`
+}
+
+type TagEditRequest struct {
+	Files       []string           `json:"files"`
+	Operations  []TagEditOperation `json:"operations"`
+	DryRun      bool               `json:"dry_run,omitempty"`
+	Description string             `json:"description,omitempty"`
+}
+
+type TagEditFileResult struct {
+	Path    string              `json:"path"`
+	Before  map[string][]string `json:"before,omitempty"`
+	After   map[string][]string `json:"after,omitempty"`
+	Changed bool                `json:"changed"`
+	Error   string              `json:"error,omitempty"`
+}
+
+type TagEditResult struct {
+	JournalID string              `json:"journal_id,omitempty"`
+	Results   []TagEditFileResult `json:"results"`
+}
+
+type TagJournalEntry struct {
+	Path   string              `json:"path"`
+	Before map[string][]string `json:"before"`
+}
+
+type TagJournal struct {
+	ID          string            `json:"id"`
+	Description string            `json:"description"`
+	Timestamp   int64             `json:"timestamp"`
+	Entries     []TagJournalEntry `json:"entries"`
+}
+
+func ReadAllTags(filePath string) (map[string][]string, error) {
+	if !fileExists(filePath) {
+		return nil, fmt.Errorf("file does not exist: %s", filePath)
+	}
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".flac":
+		return readFlacTags(filePath)
+	case ".mp3":
+		return readMp3Tags(filePath)
+	case ".m4a":
+		return readM4aTags(filePath)
+	default:
+		return nil, fmt.Errorf("unsupported file format: %s", filepath.Ext(filePath))
+	}
+}
+
+func ReadAllTagsForFiles(files []string) []FileTags {
+	results := make([]FileTags, 0, len(files))
+	for _, file := range files {
+		result := FileTags{
+			Path:   file,
+			Format: strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), "."),
+		}
+		tags, err := ReadAllTags(file)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Tags = tags
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func WriteAllTags(filePath string, tags map[string][]string) error {
+	if !fileExists(filePath) {
+		return fmt.Errorf("file does not exist: %s", filePath)
+	}
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".flac":
+		return writeFlacTags(filePath, tags)
+	case ".mp3":
+		return writeMp3Tags(filePath, tags)
+	case ".m4a":
+		return writeM4aTags(filePath, tags)
+	default:
+		return fmt.Errorf("unsupported file format: %s", filepath.Ext(filePath))
+	}
+}
+
+func readFlacTags(filePath string) (map[string][]string, error) {
+	f, err := flac.ParseFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse FLAC file: %w", err)
+	}
+
+	tags := make(map[string][]string)
+	for _, block := range f.Meta {
+		if block.Type != flac.VorbisComment {
+			continue
+		}
+		cmt, err := flacvorbis.ParseFromMetaDataBlock(*block)
+		if err != nil {
+			continue
+		}
+		for _, comment := range cmt.Comments {
+			parts := strings.SplitN(comment, "=", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			field := strings.ToUpper(parts[0])
+			tags[field] = append(tags[field], parts[1])
+		}
+	}
+
+	return tags, nil
+}
+
+func writeFlacTags(filePath string, tags map[string][]string) error {
+	f, err := flac.ParseFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to parse FLAC file: %w", err)
+	}
+
+	cmtIdx := -1
+	for idx, block := range f.Meta {
+		if block.Type == flac.VorbisComment {
+			cmtIdx = idx
+			break
+		}
+	}
+
+	cmt := flacvorbis.New()
+	for _, field := range sortedTagFields(tags) {
+		for _, value := range tags[field] {
+			if err := cmt.Add(field, value); err != nil {
+				return fmt.Errorf("invalid field %s: %w", field, err)
+			}
+		}
+	}
+
+	cmtBlock := cmt.Marshal()
+	if cmtIdx < 0 {
+		f.Meta = append(f.Meta, &cmtBlock)
+	} else {
+		f.Meta[cmtIdx] = &cmtBlock
+	}
+
+	if err := f.Save(filePath); err != nil {
+		return fmt.Errorf("failed to save FLAC file: %w", err)
+	}
+
+	return nil
+}
+
+func readMp3Tags(filePath string) (map[string][]string, error) {
+	tag, err := id3v2.Open(filePath, id3v2.Options{Parse: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open MP3 file: %w", err)
+	}
+	defer tag.Close()
+
+	tags := make(map[string][]string)
+	for id, frames := range tag.AllFrames() {
+		for _, frame := range frames {
+			switch f := frame.(type) {
+			case id3v2.TextFrame:
+				values := splitID3Values(f.Text)
+				switch id {
+				case "TRCK":
+					addNumberPair(tags, "TRACKNUMBER", "TOTALTRACKS", f.Text)
+				case "TPOS":
+					addNumberPair(tags, "DISCNUMBER", "TOTALDISCS", f.Text)
+				default:
+					field, ok := id3FrameFields[id]
+					if !ok {
+						field = id
+					}
+					tags[field] = append(tags[field], values...)
+				}
+			case id3v2.UserDefinedTextFrame:
+				field := strings.ToUpper(f.Description)
+				if field == "" {
+					field = "TXXX"
+				}
+				tags[field] = append(tags[field], splitID3Values(f.Value)...)
+			case id3v2.CommentFrame:
+				if f.Text != "" {
+					tags["COMMENT"] = append(tags["COMMENT"], f.Text)
+				}
+			case id3v2.UnsynchronisedLyricsFrame:
+				if f.Lyrics != "" {
+					field := mp3LyricsField(f.ContentDescriptor)
+					tags[field] = append(tags[field], f.Lyrics)
+				}
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+func writeMp3Tags(filePath string, tags map[string][]string) error {
+	tag, err := id3v2.Open(filePath, id3v2.Options{Parse: true})
+	if err != nil {
+		return fmt.Errorf("failed to open MP3 file: %w", err)
+	}
+	defer tag.Close()
+
+	lyricsFrames := make(map[string][]id3v2.UnsynchronisedLyricsFrame)
+	for _, frame := range tag.GetFrames(tag.CommonID("Unsynchronised lyrics/text transcription")) {
+		if uslt, ok := frame.(id3v2.UnsynchronisedLyricsFrame); ok {
+			field := mp3LyricsField(uslt.ContentDescriptor)
+			lyricsFrames[field] = append(lyricsFrames[field], uslt)
+		}
+	}
+
+	var commentFrames []id3v2.CommentFrame
+	for _, frame := range tag.GetFrames(tag.CommonID("Comments")) {
+		if comm, ok := frame.(id3v2.CommentFrame); ok && comm.Text != "" {
+			commentFrames = append(commentFrames, comm)
+		}
+	}
+
+	descriptions := make(map[string]string)
+	for _, frame := range tag.GetFrames(tag.CommonID("User defined text information frame")) {
+		if txxx, ok := frame.(id3v2.UserDefinedTextFrame); ok && txxx.Description != "" {
+			descriptions[strings.ToUpper(txxx.Description)] = txxx.Description
+		}
+	}
+
+	for id := range tag.AllFrames() {
+		if strings.HasPrefix(id, "T") || id == "COMM" || id == "USLT" {
+			tag.DeleteFrames(id)
+		}
+	}
+
+	for _, values := range tags {
+		if len(values) > 1 {
+			tag.SetVersion(4)
+			break
+		}
+	}
+
+	for _, field := range sortedTagFields(tags) {
+		values := tags[field]
+		if len(values) == 0 {
+			continue
+		}
+
+		switch field {
+		case "TRACKNUMBER", "TOTALTRACKS", "DISCNUMBER", "TOTALDISCS":
+			continue
+		case "DATE":
+			frameID := "TYER"
+			if tag.Version() == 4 {
+				frameID = "TDRC"
+			}
+			tag.AddTextFrame(frameID, id3v2.EncodingUTF8, values[0])
+		case "COMMENT":
+			addMp3CommentFrames(tag, values, commentFrames)
+		case "LYRICS":
+			addMp3LyricsFrames(tag, field, values, lyricsFrames[field])
+		default:
+			if strings.HasPrefix(field, "LYRICS-") {
+				addMp3LyricsFrames(tag, field, values, lyricsFrames[field])
+			} else if frameID, ok := id3FieldFrames[field]; ok {
+				tag.AddTextFrame(frameID, id3v2.EncodingUTF8, strings.Join(values, "\x00"))
+			} else if len(field) == 4 && strings.HasPrefix(field, "T") && strings.ToUpper(field) == field {
+				tag.AddTextFrame(field, id3v2.EncodingUTF8, strings.Join(values, "\x00"))
+			} else {
+				description := field
+				if original, ok := descriptions[field]; ok {
+					description = original
+				}
+				tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+					Encoding:    id3v2.EncodingUTF8,
+					Description: description,
+					Value:       strings.Join(values, "\x00"),
+				})
+			}
+		}
+	}
+
+	if value := joinNumberPair(tags, "TRACKNUMBER", "TOTALTRACKS"); value != "" {
+		tag.AddTextFrame("TRCK", id3v2.EncodingUTF8, value)
+	}
+	if value := joinNumberPair(tags, "DISCNUMBER", "TOTALDISCS"); value != "" {
+		tag.AddTextFrame("TPOS", id3v2.EncodingUTF8, value)
+	}
+
+	if err := tag.Save(); err != nil {
+		return fmt.Errorf("failed to save MP3 tags: %w", err)
+	}
+
+	return nil
+}
+
+var mp3LyricsDuplicateSuffix = regexp.MustCompile(`^(?:(.*) \(\d+\)|\d+)$`)
+
+func mp3LyricsField(descriptor string) string {
+	if m := mp3LyricsDuplicateSuffix.FindStringSubmatch(descriptor); m != nil {
+		descriptor = m[1]
+	}
+	if descriptor == "" {
+		return "LYRICS"
+	}
+	return "LYRICS-" + strings.ToUpper(descriptor)
+}
+
+func addMp3CommentFrames(tag *id3v2.Tag, values []string, existing []id3v2.CommentFrame) {
+	used := make([]bool, len(existing))
+	for i, value := range values {
+		frame := id3v2.CommentFrame{Encoding: id3v2.EncodingUTF8, Language: "eng", Text: value}
+		match := -1
+		for j, comm := range existing {
+			if !used[j] && comm.Text == value {
+				match = j
+				break
+			}
+		}
+		if match < 0 && i < len(existing) && !used[i] {
+			match = i
+		}
+		if match >= 0 {
+			used[match] = true
+			if len(existing[match].Language) == 3 {
+				frame.Language = existing[match].Language
+			}
+			frame.Description = existing[match].Description
+		}
+		tag.AddCommentFrame(frame)
+	}
+}
+
+func addMp3LyricsFrames(tag *id3v2.Tag, field string, values []string, existing []id3v2.UnsynchronisedLyricsFrame) {
+	descriptor := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(field, "LYRICS"), "-"))
+	if len(existing) > 0 {
+		if m := mp3LyricsDuplicateSuffix.FindStringSubmatch(existing[0].ContentDescriptor); m != nil {
+			descriptor = m[1]
+		} else {
+			descriptor = existing[0].ContentDescriptor
+		}
+	}
+	seen := make(map[string]bool)
+	for i, value := range values {
+		language := "eng"
+		if i < len(existing) && len(existing[i].Language) == 3 {
+			language = existing[i].Language
+		}
+		frameDescriptor := descriptor
+		if i < len(existing) && mp3LyricsField(existing[i].ContentDescriptor) == field {
+			frameDescriptor = existing[i].ContentDescriptor
+		}
+		for n := 2; seen[language+frameDescriptor]; n++ {
+			frameDescriptor = fmt.Sprintf("%s (%d)", descriptor, n)
+			if descriptor == "" {
+				frameDescriptor = fmt.Sprintf("%d", n)
+			}
+		}
+		seen[language+frameDescriptor] = true
+
+		tag.AddUnsynchronisedLyricsFrame(id3v2.UnsynchronisedLyricsFrame{
+			Encoding:          id3v2.EncodingUTF8,
+			Language:          language,
+			ContentDescriptor: frameDescriptor,
+			Lyrics:            value,
+		})
+	}
+}
+
+func probeM4aTags(filePath string) (map[string]string, error) {
+	ffprobePath, err := GetFFprobePath()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateExecutable(ffprobePath); err != nil {
+		return nil, fmt.Errorf("invalid ffprobe executable: %w", err)
+	}
+
+	cmd := exec.Command(ffprobePath,
+		"-v", "quiet",
+		"-print_format", "json",
+		"-show_format",
+		filePath,
+	)
+
+	setHideWindow(cmd)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe failed: %w", err)
+	}
+
+	var result struct {
+		Format struct {
+			Tags map[string]string `json:"tags"`
+		} `json:"format"`
+	}
+
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+	return result.Format.Tags, nil
+}
+
+func m4aKeyField(key string) string {
+	lower := strings.ToLower(key)
+	if strings.HasPrefix(lower, "lyrics") {
+		lower = "lyrics"
+	}
+	if field, ok := m4aKeyFields[lower]; ok {
+		return field
+	}
+	return strings.ToUpper(lower)
+}
+
+func m4aFieldKey(field string) string {
+	if key, ok := m4aFieldKeys[field]; ok {
+		return key
+	}
+	return strings.ToLower(field)
+}
+
+func m4aFieldValue(field string, values []string) string {
+	switch field {
+	case "LYRICS", "COMMENT":
+		return strings.Join(values, "\n")
+	}
+	return strings.Join(values, m4aValueSeparator)
+}
+
+func readM4aTags(filePath string) (map[string][]string, error) {
+	raw, err := probeM4aTags(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string][]string)
+	for key, value := range raw {
+		if m4aIgnoredKeys[strings.ToLower(key)] {
+			continue
+		}
+
+		field := m4aKeyField(key)
+		switch field {
+		case "TRACKNUMBER":
+			addNumberPair(tags, "TRACKNUMBER", "TOTALTRACKS", value)
+			continue
+		case "DISCNUMBER":
+			addNumberPair(tags, "DISCNUMBER", "TOTALDISCS", value)
+			continue
+		}
+
+		values := []string{value}
+		if field != "LYRICS" && field != "COMMENT" {
+			values = strings.Split(value, m4aValueSeparator)
+		}
+		for _, v := range values {
+			duplicate := v == ""
+			for _, existing := range tags[field] {
+				duplicate = duplicate || existing == v
+			}
+			if !duplicate {
+				tags[field] = append(tags[field], v)
+			}
+		}
+	}
+
+	return tags, nil
+}
+
+func writeM4aTags(filePath string, tags map[string][]string) error {
+	ffmpegPath, err := GetFFmpegPath()
+	if err != nil {
+		return fmt.Errorf("ffmpeg not found: %w", err)
+	}
+
+	if err := ValidateExecutable(ffmpegPath); err != nil {
+		return fmt.Errorf("invalid ffmpeg executable: %w", err)
+	}
+
+	existing, err := probeM4aTags(filePath)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]string)
+	for _, field := range sortedTagFields(tags) {
+		values := tags[field]
+		if len(values) == 0 {
+			continue
+		}
+		switch field {
+		case "TRACKNUMBER", "TOTALTRACKS", "DISCNUMBER", "TOTALDISCS":
+			continue
+		}
+		key := m4aFieldKey(field)
+		if !m4aWritableKeys[key] {
+			fmt.Printf("[Tags] M4A has no atom for %s, skipping it for %s\n", field, filePath)
+			continue
+		}
+		wanted[key] = m4aFieldValue(field, values)
+	}
+	if value := joinNumberPair(tags, "TRACKNUMBER", "TOTALTRACKS"); value != "" {
+		wanted["track"] = value
+	}
+	if value := joinNumberPair(tags, "DISCNUMBER", "TOTALDISCS"); value != "" {
+		wanted["disc"] = value
+	}
+
+	args := []string{
+		"-i", filePath,
+		"-y",
+		"-map", "0",
+		"-codec", "copy",
+		"-map_metadata", "0",
+	}
+
+	changed := false
+	for key := range existing {
+		if m4aIgnoredKeys[strings.ToLower(key)] {
+			continue
+		}
+		if _, keep := wanted[key]; !keep {
+			args = append(args, "-metadata", key+"=")
+			changed = true
+		}
+	}
+	keys := make([]string, 0, len(wanted))
+	for key := range wanted {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if current, ok := existing[key]; ok && current == wanted[key] {
+			continue
+		}
+		args = append(args, "-metadata", key+"="+wanted[key])
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	tmpOutputFile := strings.TrimSuffix(filePath, filepath.Ext(filePath)) + ".tmp" + filepath.Ext(filePath)
+	defer func() {
+		if _, err := os.Stat(tmpOutputFile); err == nil {
+			os.Remove(tmpOutputFile)
+		}
+	}()
+
+	args = append(args, "-f", "ipod", tmpOutputFile)
+
+	cmd := exec.Command(ffmpegPath, args...)
+	setHideWindow(cmd)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed: %s - %w", string(output), err)
+	}
+
+	if err := os.Rename(tmpOutputFile, filePath); err != nil {
+		return fmt.Errorf("failed to replace file: %w", err)
+	}
+
+	return nil
+}
+
+func splitID3Values(text string) []string {
+	parts := strings.Split(strings.TrimRight(text, "\x00"), "\x00")
+	values := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+func addNumberPair(tags map[string][]string, numberField, totalField, value string) {
+	parts := strings.SplitN(strings.TrimSpace(value), "/", 2)
+	if parts[0] != "" {
+		tags[numberField] = []string{parts[0]}
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		tags[totalField] = []string{parts[1]}
+	}
+}
+
+func joinNumberPair(tags map[string][]string, numberField, totalField string) string {
+	number := firstTagValue(tags, numberField)
+	if number == "" {
+		return ""
+	}
+	if total := firstTagValue(tags, totalField); total != "" {
+		return number + "/" + total
+	}
+	return number
+}
+
+func firstTagValue(tags map[string][]string, field string) string {
+	if values := tags[field]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func sortedTagFields(tags map[string][]string) []string {
+	fields := make([]string, 0, len(tags))
+	for field := range tags {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func cloneTags(tags map[string][]string) map[string][]string {
+	clone := make(map[string][]string, len(tags))
+	for field, values := range tags {
+		clone[field] = append([]string(nil), values...)
+	}
+	return clone
+}
+
+func tagsEqual(a, b map[string][]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for field, values := range a {
+		other, ok := b[field]
+		if !ok || len(other) != len(values) {
+			return false
+		}
+		for i := range values {
+			if values[i] != other[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func applyTagOperation(tags map[string][]string, op TagEditOperation) error {
+	field := strings.ToUpper(strings.TrimSpace(op.Field))
+	if field == "" {
+		return fmt.Errorf("field is required")
+	}
+
+	switch op.Action {
+	case "set":
+		var values []string
+		for _, value := range op.Values {
+			if value != "" {
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			delete(tags, field)
+		} else {
+			tags[field] = values
+		}
+
+	case "clear":
+		delete(tags, field)
+
+	case "replace":
+		if op.Find == "" {
+			return fmt.Errorf("find text is required")
+		}
+		pattern := op.Find
+		if !op.Regex {
+			pattern = regexp.QuoteMeta(pattern)
+		}
+		if !op.CaseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+		replacement := op.Replace
+		if !op.Regex {
+			replacement = strings.ReplaceAll(replacement, "$", "$$")
+		}
+
+		fields := []string{field}
+		if field == "*" {
+			fields = sortedTagFields(tags)
+		}
+		for _, f := range fields {
+			var values []string
+			for _, value := range tags[f] {
+				if replaced := re.ReplaceAllString(value, replacement); replaced != "" {
+					values = append(values, replaced)
+				}
+			}
+			if len(values) == 0 {
+				delete(tags, f)
+			} else {
+				tags[f] = values
+			}
+		}
+
+	case "copy":
+		source := strings.ToUpper(strings.TrimSpace(op.SourceField))
+		if source == "" {
+			return fmt.Errorf("source field is required")
+		}
+		if values, ok := tags[source]; ok && len(values) > 0 {
+			tags[field] = append([]string(nil), values...)
+		}
+
+	case "append":
+		for _, value := range op.Values {
+			if value != "" {
+				tags[field] = append(tags[field], value)
+			}
+		}
+
+	default:
+		return fmt.Errorf("unsupported tag action: %s", op.Action)
+	}
+
+	return nil
+}
+
+func ApplyTagEdits(req TagEditRequest, appName string) (*TagEditResult, error) {
+	if len(req.Files) == 0 {
+		return nil, fmt.Errorf("no files selected")
+	}
+	if len(req.Operations) == 0 {
+		return nil, fmt.Errorf("no tag operations specified")
+	}
+
+	result := &TagEditResult{}
+	journal := TagJournal{
+		Description: req.Description,
+		Timestamp:   time.Now().Unix(),
+	}
+
+	for _, file := range req.Files {
+		fileResult := TagEditFileResult{Path: file}
+
+		before, err := ReadAllTags(file)
+		if err != nil {
+			fileResult.Error = err.Error()
+			result.Results = append(result.Results, fileResult)
+			continue
+		}
+
+		after := cloneTags(before)
+		for _, op := range req.Operations {
+			if err := applyTagOperation(after, op); err != nil {
+				fileResult.Error = err.Error()
+				break
+			}
+		}
+
+		fileResult.Before = before
+		fileResult.After = after
+
+		if fileResult.Error != "" || tagsEqual(before, after) {
+			result.Results = append(result.Results, fileResult)
+			continue
+		}
+
+		fileResult.Changed = true
+		if !req.DryRun {
+			if err := WriteAllTags(file, after); err != nil {
+				fileResult.Error = err.Error()
+				fileResult.Changed = false
+			} else {
+				journal.Entries = append(journal.Entries, TagJournalEntry{Path: file, Before: before})
+			}
+		}
+
+		result.Results = append(result.Results, fileResult)
+	}
+
+	if req.DryRun || len(journal.Entries) == 0 {
+		return result, nil
+	}
+
+	if journal.Description == "" {
+		journal.Description = fmt.Sprintf("Edited %d file(s)", len(journal.Entries))
+	}
+
+	id, err := saveTagJournal(journal, appName)
+	if err != nil {
+		return result, fmt.Errorf("tags written but failed to save undo journal: %w", err)
+	}
+	result.JournalID = id
+
+	return result, nil
+}
+
+func saveTagJournal(journal TagJournal, appName string) (string, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return "", err
+		}
+	}
+
+	err := historyDB.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(tagJournalBucket))
+		if err != nil {
+			return err
+		}
+		seq, _ := b.NextSequence()
+		journal.ID = fmt.Sprintf("%d-%d", time.Now().UnixNano(), seq)
+
+		buf, err := json.Marshal(journal)
+		if err != nil {
+			return err
+		}
+
+		if b.Stats().KeyN >= maxTagJournal {
+			c := b.Cursor()
+			if k, _ := c.First(); k != nil {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+		}
+
+		return b.Put([]byte(journal.ID), buf)
+	})
+
+	return journal.ID, err
+}
+
+func GetTagJournal(appName string) ([]TagJournal, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	var journals []TagJournal
+	err := historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(tagJournalBucket))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var journal TagJournal
+			if err := json.Unmarshal(v, &journal); err == nil {
+				journals = append(journals, journal)
+			}
+			return nil
+		})
+	})
+
+	sort.Slice(journals, func(i, j int) bool {
+		return journals[i].Timestamp > journals[j].Timestamp
+	})
+
+	return journals, err
+}
+
+func UndoTagEdit(journalID string, appName string) ([]TagEditFileResult, error) {
+	if historyDB == nil {
+		if err := InitHistoryDB(appName); err != nil {
+			return nil, err
+		}
+	}
+
+	var journal TagJournal
+	found := false
+	err := historyDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(tagJournalBucket))
+		if b == nil {
+			return nil
+		}
+		v := b.Get([]byte(journalID))
+		if v == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(v, &journal)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("tag journal entry not found: %s", journalID)
+	}
+
+	var results []TagEditFileResult
+	failed := false
+	for _, entry := range journal.Entries {
+		result := TagEditFileResult{Path: entry.Path, After: entry.Before}
+		if err := WriteAllTags(entry.Path, entry.Before); err != nil {
+			result.Error = err.Error()
+			failed = true
+		} else {
+			result.Changed = true
+		}
+		results = append(results, result)
+	}
+
+	if !failed {
+		err = historyDB.Update(func(tx *bolt.Tx) error {
+			b := tx.Bucket([]byte(tagJournalBucket))
+			if b == nil {
+				return nil
+			}
+			return b.Delete([]byte(journalID))
+		})
+	}
+
+	return results, err
+}
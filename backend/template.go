@@ -0,0 +1,373 @@
+package backend
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type TemplateData struct {
+	Title       string `json:"title"`
+	Artist      string `json:"artist"`
+	Album       string `json:"album"`
+	AlbumArtist string `json:"album_artist"`
+	ReleaseDate string `json:"release_date"`
+	Playlist    string `json:"playlist,omitempty"`
+	Creator     string `json:"creator,omitempty"`
+	ISRC        string `json:"isrc,omitempty"`
+	Track       int    `json:"track"`
+	Disc        int    `json:"disc"`
+	TotalTracks int    `json:"total_tracks"`
+	TotalDiscs  int    `json:"total_discs"`
+}
+
+type templateNode struct {
+	text      string
+	expr      *templateExpr
+	cond      string
+	then      []templateNode
+	otherwise []templateNode
+}
+
+type templateExpr struct {
+	fields    []string
+	modifiers []string
+}
+
+var templateSeparatorPattern = regexp.MustCompile(`^(\.\s*|\s*-\s*|\s+)`)
+
+var templateConditionPattern = regexp.MustCompile(`^\s*(!?)([a-z_]+)\s*(?:(>=|<=|!=|>|<|=)\s*(.*?))?\s*$`)
+
+var templateNumericFields = map[string]bool{
+	"track":        true,
+	"disc":         true,
+	"total_tracks": true,
+	"total_discs":  true,
+}
+
+func (d TemplateData) fieldValue(name string) string {
+	switch name {
+	case "title":
+		return d.Title
+	case "artist":
+		return d.Artist
+	case "album":
+		return d.Album
+	case "album_artist":
+		return d.AlbumArtist
+	case "year":
+		return extractYear(d.ReleaseDate)
+	case "date":
+		return d.ReleaseDate
+	case "playlist":
+		return d.Playlist
+	case "creator":
+		return d.Creator
+	case "isrc":
+		return d.ISRC
+	case "track":
+		return positiveInt(d.Track)
+	case "disc":
+		return positiveInt(d.Disc)
+	case "total_tracks":
+		return positiveInt(d.TotalTracks)
+	case "total_discs":
+		return positiveInt(d.TotalDiscs)
+	}
+	return ""
+}
+
+func positiveInt(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+func parseTemplate(format string) []templateNode {
+	nodes, _, _ := parseTemplateNodes(format, 0)
+	return nodes
+}
+
+func parseTemplateNodes(format string, pos int) ([]templateNode, int, string) {
+	var nodes []templateNode
+	var text strings.Builder
+
+	flushText := func() {
+		if text.Len() > 0 {
+			nodes = append(nodes, templateNode{text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for pos < len(format) {
+		if format[pos] != '{' {
+			text.WriteByte(format[pos])
+			pos++
+			continue
+		}
+
+		end := strings.IndexByte(format[pos:], '}')
+		if end < 0 {
+			text.WriteString(format[pos:])
+			break
+		}
+
+		tag := strings.TrimSpace(format[pos+1 : pos+end])
+		pos += end + 1
+
+		switch {
+		case tag == "else" || tag == "end":
+			flushText()
+			return nodes, pos, tag
+		case strings.HasPrefix(tag, "if:"):
+			flushText()
+			node := templateNode{cond: strings.TrimSpace(tag[3:])}
+			var closer string
+			node.then, pos, closer = parseTemplateNodes(format, pos)
+			if closer == "else" {
+				node.otherwise, pos, _ = parseTemplateNodes(format, pos)
+			}
+			nodes = append(nodes, node)
+		case tag == "":
+			text.WriteString("{}")
+		default:
+			flushText()
+			nodes = append(nodes, templateNode{expr: parseTemplateExpr(tag)})
+		}
+	}
+
+	flushText()
+	return nodes, pos, ""
+}
+
+func parseTemplateExpr(tag string) *templateExpr {
+	expr := &templateExpr{}
+	parts := strings.Split(tag, ":")
+	for _, field := range strings.Split(parts[0], "|") {
+		if field = strings.TrimSpace(field); field != "" {
+			expr.fields = append(expr.fields, field)
+		}
+	}
+	for _, modifier := range parts[1:] {
+		if modifier = strings.TrimSpace(modifier); modifier != "" {
+			expr.modifiers = append(expr.modifiers, strings.ToLower(modifier))
+		}
+	}
+	return expr
+}
+
+func (e *templateExpr) render(data TemplateData, sanitize func(string) string) string {
+	field := ""
+	value := ""
+	for _, candidate := range e.fields {
+		if len(candidate) >= 2 && (candidate[0] == '\'' || candidate[0] == '"') && candidate[len(candidate)-1] == candidate[0] {
+			value = candidate[1 : len(candidate)-1]
+		} else {
+			field = strings.ToLower(candidate)
+			value = data.fieldValue(field)
+		}
+		if value != "" {
+			break
+		}
+	}
+	if value == "" {
+		return ""
+	}
+
+	if templateNumericFields[field] {
+		width := 0
+		if field == "track" {
+			width = 2
+		}
+		for _, modifier := range e.modifiers {
+			if n, err := strconv.Atoi(modifier); err == nil {
+				width = n
+			}
+		}
+		if n, err := strconv.Atoi(value); err == nil && width > 0 {
+			value = fmt.Sprintf("%0*d", width, n)
+		}
+	} else if sanitize != nil {
+		value = sanitize(value)
+	}
+
+	for _, modifier := range e.modifiers {
+		switch {
+		case modifier == "upper":
+			value = strings.ToUpper(value)
+		case modifier == "lower":
+			value = strings.ToLower(value)
+		case modifier == "title":
+			value = titleCase(value)
+		case modifier == "first":
+			for _, r := range value {
+				value = string(unicode.ToUpper(r))
+				break
+			}
+		case strings.HasPrefix(modifier, "max"):
+			if n, err := strconv.Atoi(modifier[3:]); err == nil && n > 0 {
+				if runes := []rune(value); len(runes) > n {
+					value = strings.TrimSpace(string(runes[:n]))
+				}
+			}
+		}
+	}
+
+	return value
+}
+
+func titleCase(value string) string {
+	runes := []rune(value)
+	startOfWord := true
+	for i, r := range runes {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' {
+			if startOfWord {
+				runes[i] = unicode.ToUpper(r)
+			} else {
+				runes[i] = unicode.ToLower(r)
+			}
+			startOfWord = false
+		} else {
+			startOfWord = true
+		}
+	}
+	return string(runes)
+}
+
+func evalTemplateCondition(cond string, data TemplateData) bool {
+	for _, alternative := range strings.Split(cond, "||") {
+		matched := true
+		for _, clause := range strings.Split(alternative, "&&") {
+			if !evalTemplateClause(clause, data) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func evalTemplateClause(clause string, data TemplateData) bool {
+	m := templateConditionPattern.FindStringSubmatch(clause)
+	if m == nil {
+		return false
+	}
+	negate := m[1] == "!"
+	value := data.fieldValue(m[2])
+	op, operand := m[3], strings.Trim(m[4], `'"`)
+
+	var result bool
+	switch op {
+	case "":
+		result = value != ""
+	case "=":
+		result = strings.EqualFold(value, operand)
+	case "!=":
+		result = !strings.EqualFold(value, operand)
+	default:
+		left, _ := strconv.Atoi(value)
+		right, err := strconv.Atoi(operand)
+		if err != nil {
+			return false
+		}
+		switch op {
+		case ">":
+			result = left > right
+		case "<":
+			result = left < right
+		case ">=":
+			result = left >= right
+		case "<=":
+			result = left <= right
+		}
+	}
+
+	if negate {
+		return !result
+	}
+	return result
+}
+
+func renderTemplateNodes(nodes []templateNode, data TemplateData, sanitize func(string) string, b *strings.Builder) {
+	skipSeparator := false
+	for _, node := range nodes {
+		switch {
+		case node.expr != nil:
+			value := node.expr.render(data, sanitize)
+			b.WriteString(value)
+			skipSeparator = value == ""
+		case node.cond != "":
+			if evalTemplateCondition(node.cond, data) {
+				renderTemplateNodes(node.then, data, sanitize, b)
+			} else {
+				renderTemplateNodes(node.otherwise, data, sanitize, b)
+			}
+			skipSeparator = false
+		default:
+			text := node.text
+			if skipSeparator {
+				text = templateSeparatorPattern.ReplaceAllString(text, "")
+			}
+			b.WriteString(text)
+			skipSeparator = false
+		}
+	}
+}
+
+func RenderTemplate(format string, data TemplateData, sanitize func(string) string) string {
+	var b strings.Builder
+	renderTemplateNodes(parseTemplate(format), data, sanitize, &b)
+
+	segments := strings.FieldsFunc(b.String(), func(r rune) bool { return r == '/' || r == '\\' })
+	cleaned := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		segment = strings.Join(strings.Fields(segment), " ")
+		segment = strings.Trim(segment, " -_")
+		segment = strings.TrimRight(segment, ".")
+		if segment != "" {
+			cleaned = append(cleaned, segment)
+		}
+	}
+
+	return filepath.Join(cleaned...)
+}
+
+func legacyFilenameTemplate(format string, includeTrackNumber bool, trackSeparator string) string {
+	if strings.Contains(format, "{") {
+		return format
+	}
+
+	var template string
+	switch format {
+	case "artist-title":
+		template = "{artist} - {title}"
+	case "title":
+		template = "{title}"
+	default:
+		template = "{title} - {artist}"
+	}
+
+	if includeTrackNumber {
+		template = "{track}" + trackSeparator + template
+	}
+	return template
+}
+
+func RenderFilename(format string, data TemplateData, includeTrackNumber bool, ext string) string {
+	return renderFilenameWithSeparator(format, data, includeTrackNumber, ". ", ext)
+}
+
+func renderFilenameWithSeparator(format string, data TemplateData, includeTrackNumber bool, trackSeparator, ext string) string {
+	name := RenderTemplate(legacyFilenameTemplate(format, includeTrackNumber, trackSeparator), data, SanitizeFilename)
+	if name == "" {
+		name = SanitizeFilename(data.Title)
+	}
+	return name + ext
+}
@@ -8,7 +8,6 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
-	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -89,47 +88,18 @@ func (t *TidalDownloader) GetAvailableAPIs() ([]string, error) {
 }
 
 func (t *TidalDownloader) GetTidalURLFromSpotify(spotifyTrackID string) (string, error) {
-
-	spotifyBase := "https://open.spotify.com/track/"
-	spotifyURL := fmt.Sprintf("%s%s", spotifyBase, spotifyTrackID)
-
-	apiBase := "https://api.song.link/v1-alpha.1/links?url="
-	apiURL := fmt.Sprintf("%s%s", apiBase, url.QueryEscape(spotifyURL))
-
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/144.0.0.0 Safari/537.36")
-
 	fmt.Println("Getting Tidal URL...")
 
-	resp, err := t.client.Do(req)
+	links, err := NewSongLinkClient().spotifyTrackLinks(spotifyTrackID, "")
 	if err != nil {
 		return "", fmt.Errorf("failed to get Tidal URL: %w", err)
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("API returned status %d", resp.StatusCode)
-	}
-
-	var songLinkResp struct {
-		LinksByPlatform map[string]struct {
-			URL string `json:"url"`
-		} `json:"linksByPlatform"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&songLinkResp); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
-	}
 
-	tidalLink, ok := songLinkResp.LinksByPlatform["tidal"]
-	if !ok || tidalLink.URL == "" {
+	tidalURL := links["tidal"]
+	if tidalURL == "" {
 		return "", fmt.Errorf("tidal link not found")
 	}
 
-	tidalURL := tidalLink.URL
 	fmt.Printf("Found Tidal URL: %s\n", tidalURL)
 	return tidalURL, nil
 }
@@ -468,13 +438,21 @@ func (t *TidalDownloader) DownloadByURL(tidalURL, outputDir, quality, filenameFo
 	trackTitle := spotifyTrackName
 	albumTitle := spotifyAlbumName
 
-	artistNameForFile := sanitizeFilename(artistName)
-	trackTitleForFile := sanitizeFilename(trackTitle)
-	albumTitleForFile := sanitizeFilename(albumTitle)
-	albumArtistForFile := sanitizeFilename(spotifyAlbumArtist)
-
-	filename := buildTidalFilename(trackTitleForFile, artistNameForFile, albumTitleForFile, albumArtistForFile, spotifyReleaseDate, spotifyTrackNumber, spotifyDiscNumber, filenameFormat, includeTrackNumber, position, useAlbumTrackNumber)
-	outputFilename := filepath.Join(outputDir, filename)
+	filename := buildDownloadFilename(filenameFormat, TemplateData{
+		Title:       trackTitle,
+		Artist:      artistName,
+		Album:       albumTitle,
+		AlbumArtist: spotifyAlbumArtist,
+		ReleaseDate: spotifyReleaseDate,
+		Track:       spotifyTrackNumber,
+		Disc:        spotifyDiscNumber,
+		TotalTracks: spotifyTotalTracks,
+		TotalDiscs:  spotifyTotalDiscs,
+	}, includeTrackNumber, position, useAlbumTrackNumber)
+	outputFilename := FitPath(outputDir, filename)
+	if err := os.MkdirAll(filepath.Dir(outputFilename), 0755); err != nil {
+		return "", fmt.Errorf("directory error: %w", err)
+	}
 
 	if fileInfo, err := os.Stat(outputFilename); err == nil && fileInfo.Size() > 0 {
 		fmt.Printf("File already exists: %s (%.2f MB)\n", outputFilename, float64(fileInfo.Size())/(1024*1024))
@@ -574,13 +552,21 @@ func (t *TidalDownloader) DownloadByURLWithFallback(tidalURL, outputDir, quality
 	trackTitle := spotifyTrackName
 	albumTitle := spotifyAlbumName
 
-	artistNameForFile := sanitizeFilename(artistName)
-	trackTitleForFile := sanitizeFilename(trackTitle)
-	albumTitleForFile := sanitizeFilename(albumTitle)
-	albumArtistForFile := sanitizeFilename(spotifyAlbumArtist)
-
-	filename := buildTidalFilename(trackTitleForFile, artistNameForFile, albumTitleForFile, albumArtistForFile, spotifyReleaseDate, spotifyTrackNumber, spotifyDiscNumber, filenameFormat, includeTrackNumber, position, useAlbumTrackNumber)
-	outputFilename := filepath.Join(outputDir, filename)
+	filename := buildDownloadFilename(filenameFormat, TemplateData{
+		Title:       trackTitle,
+		Artist:      artistName,
+		Album:       albumTitle,
+		AlbumArtist: spotifyAlbumArtist,
+		ReleaseDate: spotifyReleaseDate,
+		Track:       spotifyTrackNumber,
+		Disc:        spotifyDiscNumber,
+		TotalTracks: spotifyTotalTracks,
+		TotalDiscs:  spotifyTotalDiscs,
+	}, includeTrackNumber, position, useAlbumTrackNumber)
+	outputFilename := FitPath(outputDir, filename)
+	if err := os.MkdirAll(filepath.Dir(outputFilename), 0755); err != nil {
+		return "", fmt.Errorf("directory error: %w", err)
+	}
 
 	if fileInfo, err := os.Stat(outputFilename); err == nil && fileInfo.Size() > 0 {
 		fmt.Printf("File already exists: %s (%.2f MB)\n", outputFilename, float64(fileInfo.Size())/(1024*1024))
@@ -897,56 +883,78 @@ func getDownloadURLRotated(apis []string, trackID int64, quality string) (string
 	return "", "", fmt.Errorf("all %d APIs failed. Last error: %v", len(apis), lastError)
 }
 
-func buildTidalFilename(title, artist, album, albumArtist, releaseDate string, trackNumber, discNumber int, format string, includeTrackNumber bool, position int, useAlbumTrackNumber bool) string {
-	var filename string
+type TidalTrackQuality struct {
+	TrackID      int64  `json:"track_id"`
+	AudioQuality string `json:"audio_quality"`
+	BitDepth     int    `json:"bit_depth"`
+	SampleRate   int    `json:"sample_rate"`
+}
 
-	numberToUse := position
-	if useAlbumTrackNumber && trackNumber > 0 {
-		numberToUse = trackNumber
+func (t *TidalDownloader) GetTrackQuality(trackID int64) (*TidalTrackQuality, error) {
+	apis, err := t.GetAvailableAPIs()
+	if err != nil {
+		return nil, err
+	}
+	if t.apiURL != "" {
+		apis = append([]string{t.apiURL}, apis...)
 	}
 
-	year := ""
-	if len(releaseDate) >= 4 {
-		year = releaseDate[:4]
+	client := &http.Client{
+		Timeout: 15 * time.Second,
 	}
 
-	if strings.Contains(format, "{") {
-		filename = format
-		filename = strings.ReplaceAll(filename, "{title}", title)
-		filename = strings.ReplaceAll(filename, "{artist}", artist)
-		filename = strings.ReplaceAll(filename, "{album}", album)
-		filename = strings.ReplaceAll(filename, "{album_artist}", albumArtist)
-		filename = strings.ReplaceAll(filename, "{year}", year)
+	var lastError error
+	tried := make(map[string]bool)
+	for _, apiURL := range apis {
+		if tried[apiURL] {
+			continue
+		}
+		tried[apiURL] = true
 
-		if discNumber > 0 {
-			filename = strings.ReplaceAll(filename, "{disc}", fmt.Sprintf("%d", discNumber))
-		} else {
-			filename = strings.ReplaceAll(filename, "{disc}", "")
+		url := fmt.Sprintf("%s/track/?id=%d&quality=HI_RES_LOSSLESS", apiURL, trackID)
+		resp, err := client.Get(url)
+		if err != nil {
+			lastError = err
+			continue
 		}
 
-		if numberToUse > 0 {
-			filename = strings.ReplaceAll(filename, "{track}", fmt.Sprintf("%02d", numberToUse))
-		} else {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastError = err
+			continue
+		}
+
+		if resp.StatusCode != 200 {
+			lastError = fmt.Errorf("HTTP %d", resp.StatusCode)
+			continue
+		}
 
-			filename = regexp.MustCompile(`\{track\}\.\s*`).ReplaceAllString(filename, "")
-			filename = regexp.MustCompile(`\{track\}\s*-\s*`).ReplaceAllString(filename, "")
-			filename = regexp.MustCompile(`\{track\}\s*`).ReplaceAllString(filename, "")
+		var v2Response TidalAPIResponseV2
+		if err := json.Unmarshal(body, &v2Response); err != nil || v2Response.Data.AudioQuality == "" {
+			lastError = fmt.Errorf("no quality information in response")
+			continue
 		}
-	} else {
 
-		switch format {
-		case "artist-title":
-			filename = fmt.Sprintf("%s - %s", artist, title)
-		case "title":
-			filename = title
-		default:
-			filename = fmt.Sprintf("%s - %s", title, artist)
+		quality := &TidalTrackQuality{
+			TrackID:      trackID,
+			AudioQuality: v2Response.Data.AudioQuality,
+			BitDepth:     v2Response.Data.BitDepth,
+			SampleRate:   v2Response.Data.SampleRate,
 		}
 
-		if includeTrackNumber && position > 0 {
-			filename = fmt.Sprintf("%02d. %s", numberToUse, filename)
+		if quality.BitDepth == 0 {
+			switch quality.AudioQuality {
+			case "HI_RES_LOSSLESS", "HI_RES":
+				quality.BitDepth = 24
+			case "LOSSLESS":
+				quality.BitDepth = 16
+				quality.SampleRate = 44100
+			}
 		}
+
+		return quality, nil
 	}
 
-	return filename + ".flac"
+	return nil, fmt.Errorf("failed to get Tidal track quality: %v", lastError)
 }
@@ -0,0 +1,392 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type UpgradeOffer struct {
+	Service    string `json:"service"`
+	BitDepth   int    `json:"bit_depth"`
+	SampleRate int    `json:"sample_rate"`
+	TrackID    int64  `json:"track_id,omitempty"`
+	URL        string `json:"url,omitempty"`
+}
+
+type UpgradeCandidate struct {
+	Path       string         `json:"path"`
+	HistoryID  string         `json:"history_id,omitempty"`
+	SpotifyID  string         `json:"spotify_id,omitempty"`
+	ISRC       string         `json:"isrc,omitempty"`
+	Title      string         `json:"title"`
+	Artist     string         `json:"artist"`
+	Current    *AudioQuality  `json:"current,omitempty"`
+	CurrentStr string         `json:"current_str"`
+	Offers     []UpgradeOffer `json:"offers"`
+	Best       *UpgradeOffer  `json:"best,omitempty"`
+	BestStr    string         `json:"best_str,omitempty"`
+	Upgradable bool           `json:"upgradable"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type UpgradeScanResult struct {
+	Scanned    int                `json:"scanned"`
+	Candidates []UpgradeCandidate `json:"candidates"`
+	Skipped    []UpgradeCandidate `json:"skipped,omitempty"`
+}
+
+type UpgradeRequest struct {
+	Path      string `json:"path"`
+	SpotifyID string `json:"spotify_id,omitempty"`
+	ISRC      string `json:"isrc,omitempty"`
+	Service   string `json:"service"`
+	URL       string `json:"url,omitempty"`
+	BackupDir string `json:"backup_dir,omitempty"`
+}
+
+type UpgradeResult struct {
+	Path       string        `json:"path"`
+	NewPath    string        `json:"new_path,omitempty"`
+	BackupPath string        `json:"backup_path,omitempty"`
+	OldQuality *AudioQuality `json:"old_quality,omitempty"`
+	NewQuality *AudioQuality `json:"new_quality,omitempty"`
+	Success    bool          `json:"success"`
+	Error      string        `json:"error,omitempty"`
+}
+
+func ScanUpgradesFromHistory(progressCallback func(done, total int), appName string) (*UpgradeScanResult, error) {
+	items, err := GetHistoryItems(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var targets []HistoryItem
+	for _, item := range items {
+		if item.Path == "" || seen[item.Path] || !fileExists(item.Path) {
+			continue
+		}
+		seen[item.Path] = true
+		targets = append(targets, item)
+	}
+
+	result := &UpgradeScanResult{Scanned: len(targets)}
+	for i, item := range targets {
+		candidate := checkUpgradeForFile(item.Path, item.SpotifyID)
+		candidate.HistoryID = item.ID
+		if candidate.Title == "" {
+			candidate.Title = item.Title
+		}
+		if candidate.Artist == "" {
+			candidate.Artist = item.Artists
+		}
+		addUpgradeCandidate(result, candidate)
+
+		if progressCallback != nil {
+			progressCallback(i+1, len(targets))
+		}
+	}
+
+	return result, nil
+}
+
+func ScanUpgradesInFolder(folder string, progressCallback func(done, total int)) (*UpgradeScanResult, error) {
+	var paths []string
+	err := filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".flac", ".mp3", ".m4a":
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	result := &UpgradeScanResult{Scanned: len(paths)}
+	for i, path := range paths {
+		addUpgradeCandidate(result, checkUpgradeForFile(path, ""))
+
+		if progressCallback != nil {
+			progressCallback(i+1, len(paths))
+		}
+	}
+
+	return result, nil
+}
+
+func addUpgradeCandidate(result *UpgradeScanResult, candidate UpgradeCandidate) {
+	if candidate.Upgradable {
+		result.Candidates = append(result.Candidates, candidate)
+	} else {
+		result.Skipped = append(result.Skipped, candidate)
+	}
+}
+
+func checkUpgradeForFile(path, spotifyID string) UpgradeCandidate {
+	candidate := UpgradeCandidate{Path: path, SpotifyID: spotifyID}
+
+	quality, err := ProbeAudioQuality(path)
+	if err != nil {
+		candidate.Error = err.Error()
+		return candidate
+	}
+	candidate.Current = quality
+	candidate.CurrentStr = quality.Label()
+
+	var album string
+	if metadata, err := ExtractFullMetadataFromFile(path); err == nil {
+		candidate.Title = metadata.Title
+		candidate.Artist = metadata.Artist
+		album = metadata.Album
+		if IsValidISRC(metadata.ISRC) {
+			candidate.ISRC = metadata.ISRC
+		}
+		if candidate.SpotifyID == "" {
+			candidate.SpotifyID = spotifyIDFromURL(metadata.URL)
+		}
+	}
+
+	songLink := NewSongLinkClient()
+
+	if candidate.ISRC == "" && candidate.SpotifyID != "" {
+		durationSec := 0
+		if duration, err := GetAudioDuration(path); err == nil {
+			durationSec = int(duration + 0.5)
+		}
+		if resolution, err := ResolveISRC(context.Background(), ISRCQuery{
+			SpotifyID:   candidate.SpotifyID,
+			TrackName:   candidate.Title,
+			ArtistName:  candidate.Artist,
+			AlbumName:   album,
+			DurationSec: durationSec,
+		}); err == nil {
+			candidate.ISRC = resolution.ISRC
+		}
+	}
+
+	if candidate.ISRC != "" {
+		if track, err := NewQobuzDownloader().SearchByISRC(candidate.ISRC); err == nil {
+			candidate.Offers = append(candidate.Offers, UpgradeOffer{
+				Service:    "qobuz",
+				BitDepth:   track.MaximumBitDepth,
+				SampleRate: int(track.MaximumSamplingRate * 1000),
+				TrackID:    track.ID,
+			})
+		}
+	}
+
+	if candidate.SpotifyID != "" {
+		if urls, err := songLink.GetAllURLsFromSpotify(candidate.SpotifyID, ""); err == nil && urls.TidalURL != "" {
+			tidal := NewTidalDownloader("")
+			if trackID, err := tidal.GetTrackIDFromURL(urls.TidalURL); err == nil {
+				if tq, err := tidal.GetTrackQuality(trackID); err == nil {
+					candidate.Offers = append(candidate.Offers, UpgradeOffer{
+						Service:    "tidal",
+						BitDepth:   tq.BitDepth,
+						SampleRate: tq.SampleRate,
+						TrackID:    trackID,
+						URL:        urls.TidalURL,
+					})
+				}
+			}
+		}
+	}
+
+	if len(candidate.Offers) == 0 {
+		if candidate.Error == "" {
+			candidate.Error = "no higher quality source found"
+		}
+		return candidate
+	}
+
+	for i := range candidate.Offers {
+		offer := &candidate.Offers[i]
+		if candidate.Best == nil || offer.BitDepth > candidate.Best.BitDepth ||
+			(offer.BitDepth == candidate.Best.BitDepth && offer.SampleRate > candidate.Best.SampleRate) {
+			candidate.Best = offer
+		}
+	}
+
+	best := candidate.Best
+	candidate.BestStr = fmt.Sprintf("%d-bit/%.1fkHz (%s)", best.BitDepth, float64(best.SampleRate)/1000.0, best.Service)
+	candidate.Upgradable = isQualityUpgrade(quality, best.BitDepth, best.SampleRate)
+
+	return candidate
+}
+
+func isQualityUpgrade(current *AudioQuality, bitDepth, sampleRate int) bool {
+	return !current.Lossless ||
+		bitDepth > current.BitDepth ||
+		(bitDepth == current.BitDepth && sampleRate > current.SampleRate)
+}
+
+func spotifyIDFromURL(value string) string {
+	if !strings.Contains(value, "spotify") {
+		return ""
+	}
+	parsed, err := parseSpotifyURI(value)
+	if err != nil || parsed.Type != "track" {
+		return ""
+	}
+	return parsed.ID
+}
+
+func UpgradeFile(req UpgradeRequest, appName string) UpgradeResult {
+	result := UpgradeResult{Path: req.Path}
+
+	if !fileExists(req.Path) {
+		result.Error = "file does not exist"
+		return result
+	}
+
+	oldQuality, err := ProbeAudioQuality(req.Path)
+	if err == nil {
+		result.OldQuality = oldQuality
+	}
+
+	metadata, err := ExtractFullMetadataFromFile(req.Path)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to read existing tags: %v", err)
+		return result
+	}
+
+	lyrics, _ := ExtractLyrics(req.Path)
+
+	coverPath, err := ExtractCoverArt(req.Path)
+	if err == nil && coverPath != "" {
+		defer os.Remove(coverPath)
+	} else {
+		coverPath = ""
+	}
+
+	tmpDir, err := os.MkdirTemp("", "spotiflac-upgrade-*")
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to create temp directory: %v", err)
+		return result
+	}
+	defer os.RemoveAll(tmpDir)
+
+	isrc := req.ISRC
+	if isrc == "" {
+		isrc = metadata.ISRC
+	}
+
+	var downloaded string
+	switch req.Service {
+	case "qobuz":
+		if !IsValidISRC(isrc) {
+			result.Error = "a valid ISRC is required to upgrade from Qobuz"
+			return result
+		}
+		downloaded, err = NewQobuzDownloader().DownloadByISRC(isrc, tmpDir, "27", "title", false, 0,
+			metadata.Title, metadata.Artist, metadata.Album, metadata.AlbumArtist, metadata.Date, false, "", false,
+			metadata.TrackNumber, metadata.DiscNumber, metadata.TotalTracks, metadata.TotalDiscs,
+			metadata.Copyright, metadata.Publisher, metadata.URL, true)
+	case "tidal":
+		tidalURL := req.URL
+		if tidalURL == "" && req.SpotifyID != "" {
+			urls, urlErr := NewSongLinkClient().GetAllURLsFromSpotify(req.SpotifyID, "")
+			if urlErr == nil {
+				tidalURL = urls.TidalURL
+			}
+		}
+		if tidalURL == "" {
+			result.Error = "a Tidal URL or Spotify ID is required to upgrade from Tidal"
+			return result
+		}
+		downloaded, err = NewTidalDownloader("").DownloadByURLWithFallback(tidalURL, tmpDir, "HI_RES_LOSSLESS", "title", false, 0,
+			metadata.Title, metadata.Artist, metadata.Album, metadata.AlbumArtist, metadata.Date, false, "", false,
+			metadata.TrackNumber, metadata.DiscNumber, metadata.TotalTracks, metadata.TotalDiscs,
+			metadata.Copyright, metadata.Publisher, metadata.URL, true)
+	default:
+		result.Error = fmt.Sprintf("unsupported upgrade service: %s", req.Service)
+		return result
+	}
+
+	if err != nil {
+		result.Error = fmt.Sprintf("download failed: %v", err)
+		return result
+	}
+	downloaded = strings.TrimPrefix(downloaded, "EXISTS:")
+
+	newQuality, err := ProbeAudioQuality(downloaded)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to inspect downloaded file: %v", err)
+		return result
+	}
+	result.NewQuality = newQuality
+
+	if oldQuality != nil && (!newQuality.Lossless || !isQualityUpgrade(oldQuality, newQuality.BitDepth, newQuality.SampleRate)) {
+		os.Remove(downloaded)
+		result.Error = fmt.Sprintf("downloaded file (%s) is not better than the existing one (%s)", newQuality.Label(), oldQuality.Label())
+		return result
+	}
+
+	if err := EmbedMetadata(downloaded, metadata, coverPath); err != nil {
+		result.Error = fmt.Sprintf("failed to restore tags: %v", err)
+		return result
+	}
+
+	if strings.TrimSpace(lyrics) != "" {
+		if err := EmbedLyricsOnly(downloaded, lyrics); err != nil {
+			fmt.Printf("[Upgrade] Warning: failed to restore lyrics: %v\n", err)
+		}
+	}
+
+	backupDir := req.BackupDir
+	if backupDir == "" {
+		appDir, err := GetFFmpegDir()
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		backupDir = filepath.Join(appDir, "backup", time.Now().Format("20060102"))
+	}
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		result.Error = fmt.Sprintf("failed to create backup folder: %v", err)
+		return result
+	}
+
+	backupPath := filepath.Join(backupDir, filepath.Base(req.Path))
+	for n := 2; fileExists(backupPath); n++ {
+		ext := filepath.Ext(req.Path)
+		backupPath = filepath.Join(backupDir, fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(filepath.Base(req.Path), ext), n, ext))
+	}
+
+	if err := moveFile(req.Path, backupPath); err != nil {
+		result.Error = fmt.Sprintf("failed to back up original file: %v", err)
+		return result
+	}
+	result.BackupPath = backupPath
+
+	newPath := strings.TrimSuffix(req.Path, filepath.Ext(req.Path)) + ".flac"
+	if err := moveFile(downloaded, newPath); err != nil {
+		moveFile(backupPath, req.Path)
+		result.BackupPath = ""
+		result.Error = fmt.Sprintf("failed to move upgraded file into place: %v", err)
+		return result
+	}
+	result.NewPath = newPath
+	result.Success = true
+
+	qualityStr := newQuality.Label()
+	UpdateHistoryItems(func(item *HistoryItem) bool {
+		if item.Path != req.Path {
+			return false
+		}
+		item.Path = newPath
+		item.Quality = qualityStr
+		item.Format = "FLAC"
+		return true
+	}, appName)
+
+	return result
+}
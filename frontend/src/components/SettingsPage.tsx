@@ -530,6 +530,26 @@ export function SettingsPage({ onUnsavedChangesChange, onResetRequest, }: Settin
                 </Label>
               </div>
 
+              <div className="flex items-center gap-3">
+                <Switch id="write-album-sidecars" checked={tempSettings.writeAlbumSidecars} onCheckedChange={(checked) => setTempSettings((prev) => ({
+                ...prev,
+                writeAlbumSidecars: checked,
+            }))}/>
+                <Label htmlFor="write-album-sidecars" className="text-sm cursor-pointer font-normal">
+                  Write Album JSON/NFO
+                </Label>
+              </div>
+
+              {tempSettings.writeAlbumSidecars && (<div className="flex items-center gap-3">
+                  <Switch id="write-album-cue" checked={tempSettings.writeAlbumCue} onCheckedChange={(checked) => setTempSettings((prev) => ({
+                    ...prev,
+                    writeAlbumCue: checked,
+                }))}/>
+                  <Label htmlFor="write-album-cue" className="text-sm cursor-pointer font-normal">
+                    Include CUE Sheet
+                  </Label>
+                </div>)}
+
               <div className="flex items-center gap-3">
                 <Switch id="use-first-artist-only" checked={tempSettings.useFirstArtistOnly} onCheckedChange={(checked) => setTempSettings((prev) => ({
                 ...prev,
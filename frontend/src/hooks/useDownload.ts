@@ -21,6 +21,9 @@ interface CheckFileExistenceRequest {
     release_date?: string;
     track_number?: number;
     disc_number?: number;
+    total_tracks?: number;
+    total_discs?: number;
+    isrc?: string;
     position?: number;
     use_album_track_number?: boolean;
     filename_format?: string;
@@ -38,6 +41,21 @@ interface FileExistenceResult {
 const CheckFilesExistence = (outputDir: string, rootDir: string, tracks: CheckFileExistenceRequest[]): Promise<FileExistenceResult[]> => (window as any)["go"]["main"]["App"]["CheckFilesExistence"](outputDir, rootDir, tracks);
 const SkipDownloadItem = (itemID: string, filePath: string): Promise<void> => (window as any)["go"]["main"]["App"]["SkipDownloadItem"](itemID, filePath);
 const CreateM3U8File = (playlistName: string, outputDir: string, filePaths: string[]): Promise<void> => (window as any)["go"]["main"]["App"]["CreateM3U8File"](playlistName, outputDir, filePaths);
+interface AlbumSidecarRequest {
+    output_dir: string;
+    spotify_url: string;
+    service: string;
+    quality: string;
+    downloads: {
+        spotify_id: string;
+        file: string;
+        provider?: string;
+    }[];
+    write_json: boolean;
+    write_nfo: boolean;
+    write_cue: boolean;
+}
+const WriteAlbumSidecars = (req: AlbumSidecarRequest): Promise<string[]> => (window as any)["go"]["main"]["App"]["WriteAlbumSidecars"](req);
 export function useDownload(region: string) {
     const [downloadProgress, setDownloadProgress] = useState<number>(0);
     const [isDownloading, setIsDownloading] = useState(false);
@@ -661,6 +679,9 @@ export function useDownload(region: string) {
                 release_date: track.release_date || "",
                 track_number: track.track_number || 0,
                 disc_number: track.disc_number || 0,
+                total_tracks: track.total_tracks || 0,
+                total_discs: track.total_discs || 0,
+                isrc: track.isrc,
                 position: index + 1,
                 use_album_track_number: useAlbumTrackNumber,
                 filename_format: settings.filenameTemplate || "",
@@ -827,6 +848,9 @@ export function useDownload(region: string) {
                 release_date: track.release_date || "",
                 track_number: track.track_number || 0,
                 disc_number: track.disc_number || 0,
+                total_tracks: track.total_tracks || 0,
+                total_discs: track.total_discs || 0,
+                isrc: track.isrc,
                 position: index + 1,
                 use_album_track_number: useAlbumTrackNumber,
                 filename_format: settings.filenameTemplate || "",
@@ -836,6 +860,7 @@ export function useDownload(region: string) {
         });
         const existenceResults = await CheckFilesExistence(outputDir, settings.downloadPath, existenceChecks);
         const finalFilePaths: string[] = new Array(tracksWithIsrc.length).fill("");
+        const finalProviders: string[] = new Array(tracksWithIsrc.length).fill("");
         const existingSpotifyIDs = new Set<string>();
         const existingFilePaths = new Map<string, string>();
         for (let i = 0; i < existenceResults.length; i++) {
@@ -901,6 +926,9 @@ export function useDownload(region: string) {
                     if (response.file) {
                         finalFilePaths[originalIndex] = response.file;
                     }
+                    if (response.service) {
+                        finalProviders[originalIndex] = response.service;
+                    }
                 }
                 else {
                     errorCount++;
@@ -936,6 +964,38 @@ export function useDownload(region: string) {
                 toast.error(`Failed to create M3U8 playlist: ${err}`);
             }
         }
+        const albumID = tracksWithIsrc[0]?.album_id;
+        if (isAlbum && settings.writeAlbumSidecars && albumID) {
+            const downloads = tracksWithIsrc
+                .map((track, index) => ({ spotify_id: track.spotify_id || "", file: finalFilePaths[index], provider: finalProviders[index] || undefined }))
+                .filter((download) => download.spotify_id && download.file);
+            if (downloads.length > 0) {
+                const providers = new Set(downloads.map((download) => download.provider).filter(Boolean));
+                const service = settings.downloader !== "auto" ? settings.downloader
+                    : providers.size === 1 ? Array.from(providers)[0] as string
+                        : providers.size > 1 ? "mixed" : "auto";
+                const quality = settings.downloader === "tidal" ? settings.tidalQuality
+                    : settings.downloader === "qobuz" ? settings.qobuzQuality
+                        : settings.downloader === "amazon" ? settings.amazonQuality
+                            : settings.autoQuality;
+                try {
+                    const written = await WriteAlbumSidecars({
+                        output_dir: "",
+                        spotify_url: `https://open.spotify.com/album/${albumID}`,
+                        service,
+                        quality,
+                        downloads,
+                        write_json: true,
+                        write_nfo: true,
+                        write_cue: settings.writeAlbumCue,
+                    });
+                    logger.info(`album sidecars written: ${written.length} files`);
+                }
+                catch (err) {
+                    logger.error(`failed to write album sidecars: ${err}`);
+                }
+            }
+        }
         logger.info(`batch complete: ${successCount} downloaded, ${skippedCount} skipped, ${errorCount} failed`);
         if (errorCount === 0 && skippedCount === 0) {
             toast.success(`Downloaded ${successCount} tracks successfully`);
@@ -30,6 +30,8 @@ export interface Settings {
     spotFetchAPIUrl: string;
     createPlaylistFolder: boolean;
     createM3u8File: boolean;
+    writeAlbumSidecars: boolean;
+    writeAlbumCue: boolean;
     useFirstArtistOnly: boolean;
 }
 export const FOLDER_PRESETS: Record<FolderPreset, {
@@ -111,6 +113,8 @@ export const DEFAULT_SETTINGS: Settings = {
     spotFetchAPIUrl: "https://spotify.afkarxyz.fun/api",
     createPlaylistFolder: true,
     createM3u8File: false,
+    writeAlbumSidecars: false,
+    writeAlbumCue: false,
     useFirstArtistOnly: false
 };
 export const FONT_OPTIONS: {
@@ -306,6 +310,12 @@ export async function loadSettings(): Promise<Settings> {
             if (!('createM3u8File' in parsed)) {
                 parsed.createM3u8File = false;
             }
+            if (!('writeAlbumSidecars' in parsed)) {
+                parsed.writeAlbumSidecars = false;
+            }
+            if (!('writeAlbumCue' in parsed)) {
+                parsed.writeAlbumCue = false;
+            }
             if (!('useFirstArtistOnly' in parsed)) {
                 parsed.useFirstArtistOnly = false;
             }
@@ -147,6 +147,7 @@ export interface DownloadResponse {
     error?: string;
     already_exists?: boolean;
     item_id?: string;
+    service?: string;
 }
 export interface HealthResponse {
     status: string;